@@ -0,0 +1,169 @@
+// Command dlq lists dead-lettered queue messages and replays them once the cause of the failure
+// has been fixed. It goes through the admin API, so replays reach whichever queue the running
+// service publishes to, the local in-memory stand-in included.
+//
+//	go run ./cmd/dlq list [-queue bulk-orders.fifo] [-include-replayed]
+//	go run ./cmd/dlq replay <id>...
+//	go run ./cmd/dlq replay -all [-queue bulk-orders.fifo]
+//
+// The service address defaults to OMS_URL or http://localhost:8080 and can be set with -server.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"oms-service-goc/internals/models"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage:
+  dlq list [-server url] [-queue name] [-include-replayed]
+  dlq replay [-server url] <id>...
+  dlq replay [-server url] -all [-queue name]`
+
+func main() {
+	if len(os.Args) < 2 {
+		fail(usage)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Args[2:])
+	case "replay":
+		err = replay(os.Args[2:])
+	default:
+		fail(usage)
+	}
+	if err != nil {
+		fail(err.Error())
+	}
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
+
+func serverFlag(flags *flag.FlagSet) *string {
+	server := os.Getenv("OMS_URL")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	return flags.String("server", server, "base URL of the OMS service")
+}
+
+func list(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	server := serverFlag(flags)
+	queue := flags.String("queue", "", "only list messages from this queue")
+	includeReplayed := flags.Bool("include-replayed", false, "also list messages already replayed")
+	flags.Parse(args)
+
+	query := url.Values{}
+	if *queue != "" {
+		query.Set("queue", *queue)
+	}
+	if *includeReplayed {
+		query.Set("include_replayed", "true")
+	}
+
+	var data struct {
+		DeadLetters []*models.DeadLetter `json:"dead_letters"`
+	}
+	if err := call(http.MethodGet, *server, "/api/v1/admin/dead-letters", query, &data); err != nil {
+		return err
+	}
+	if len(data.DeadLetters) == 0 {
+		fmt.Println("No dead letters")
+		return nil
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "ID\tQUEUE\tJOB\tRECEIVES\tFAILED AT\tREPLAYS\tREASON")
+	for _, entry := range data.DeadLetters {
+		fmt.Fprintf(out, "%s\t%s\t%s\t%d\t%s\t%d\t%s\n", entry.ID.Hex(), entry.Queue, entry.JobID,
+			entry.ReceiveCount, entry.FailedAt.Format(time.RFC3339), entry.Replays, entry.Reason)
+	}
+	return out.Flush()
+}
+
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	server := serverFlag(flags)
+	all := flags.Bool("all", false, "replay every message not replayed yet")
+	queue := flags.String("queue", "", "with -all, only replay messages from this queue")
+	flags.Parse(args)
+
+	if *all == (flags.NArg() > 0) {
+		return fmt.Errorf("give either -all or dead letter IDs\n%s", usage)
+	}
+
+	if *all {
+		query := url.Values{}
+		if *queue != "" {
+			query.Set("queue", *queue)
+		}
+		var data struct {
+			DeadLetters []*models.DeadLetter `json:"dead_letters"`
+		}
+		if err := call(http.MethodPost, *server, "/api/v1/admin/dead-letters/replay", query, &data); err != nil {
+			return err
+		}
+		for _, entry := range data.DeadLetters {
+			fmt.Printf("Replayed %s to %s\n", entry.ID.Hex(), entry.Queue)
+		}
+		fmt.Printf("%d dead letters replayed\n", len(data.DeadLetters))
+		return nil
+	}
+
+	for _, id := range flags.Args() {
+		var data struct {
+			DeadLetter *models.DeadLetter `json:"dead_letter"`
+		}
+		if err := call(http.MethodPost, *server, "/api/v1/admin/dead-letters/"+url.PathEscape(id)+"/replay", nil, &data); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		fmt.Printf("Replayed %s to %s\n", id, data.DeadLetter.Queue)
+	}
+	return nil
+}
+
+// call sends a request to the admin API and decodes the data of a successful response into data
+func call(method, server, path string, query url.Values, data interface{}) error {
+	target := strings.TrimRight(server, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	request, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		Data    json.RawMessage `json:"data"`
+		Error   string          `json:"error"`
+		Details string          `json:"details"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return fmt.Errorf("unexpected response (%s): %w", response.Status, err)
+	}
+	if response.StatusCode >= 300 {
+		if body.Details != "" {
+			return fmt.Errorf("%s: %s (%s)", response.Status, body.Error, body.Details)
+		}
+		return fmt.Errorf("%s: %s", response.Status, body.Error)
+	}
+	return json.Unmarshal(body.Data, data)
+}
@@ -3,65 +3,173 @@ package main
 import (
 	"context"
 	"log"
+	nethttp "net/http"
+	"oms-service-goc/internals/allocation"
 	"oms-service-goc/internals/configs"
 	"oms-service-goc/internals/handlers/http"
+	"oms-service-goc/internals/holds"
+	"oms-service-goc/internals/queue"
 	"oms-service-goc/internals/repositories"
 	"oms-service-goc/internals/services"
+	"oms-service-goc/internals/storage"
 	"oms-service-goc/routes"
 	"os"
 
 	"github.com/omniful/go_commons/db/nosql/mongodm"
-	"github.com/omniful/go_commons/sqs"
 )
 
-// Mock SQS Publisher for testing - implements services.SQSPublisher interface
-type MockSQSPublisher struct{}
-
-func (m *MockSQSPublisher) Publish(ctx context.Context, message *sqs.Message) error {
-	log.Printf("Mock SQS: Publishing message - GroupID: %s, DeduplicationID: %s", message.GroupId, message.DeduplicationId)
-	return nil
-}
-
 func main() {
 	log.Println("Starting OMS Service...")
 
 	// Load configuration
-	cfg := configs.LoadConfig()
+	cfg, err := configs.LoadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	// Initialize MongoDB connection
-	db := mongodm.NewDatabase(cfg.MongoDB)
+	db := mongodm.NewDatabase(cfg.MongoDB.Mongodm())
 	log.Println("Connected to MongoDB")
 
-	// Initialize SQS publisher
-	var sqsPublisher services.SQSPublisher
-	env := os.Getenv("ENVIRONMENT")
-	if env == "" || env == "local" {
-		// Use mock for local development
-		sqsPublisher = &MockSQSPublisher{}
-		log.Println("Using Mock SQS Publisher for local development")
-	} else {
-		// For production, you would initialize real SQS client here
-		// sqsClient := sqs.NewClient(cfg.SQS)
-		// sqsPublisher = sqsClient
-		// For now, using mock in all environments
-		sqsPublisher = &MockSQSPublisher{}
-		log.Println("Using Mock SQS Publisher")
+	// Initialize SQS publishers (mock, LocalStack or AWS, per config)
+	sqsPublisher, err := queue.NewPublisher(context.Background(), cfg, cfg.Queue.BulkOrderQueue)
+	if err != nil {
+		log.Fatalf("Failed to initialize SQS publisher: %v", err)
+	}
+	log.Printf("Using %s SQS publisher for queue %s", cfg.Queue.Publisher, cfg.Queue.BulkOrderQueue)
+
+	eventsPublisher, err := queue.NewPublisher(context.Background(), cfg, cfg.Queue.OrderEventsQueue)
+	if err != nil {
+		log.Fatalf("Failed to initialize order events publisher: %v", err)
 	}
+	events := services.NewEventPublisher(eventsPublisher)
 
-	// Initialize repository
+	// Initialize file storage, picked per location by URI scheme
+	localStore, err := storage.NewLocalStore(cfg.Storage.LocalRoot)
+	if err != nil {
+		log.Fatalf("Failed to initialize local file storage: %v", err)
+	}
+	s3Region := cfg.Storage.S3Region
+	if s3Region == "" && cfg.SQS != nil {
+		s3Region = cfg.SQS.Region
+	}
+	httpStore := storage.NewHTTPStore(&nethttp.Client{Timeout: cfg.Storage.HTTPTimeout}, cfg.Storage.HTTPAllowedHosts)
+	fileStore := storage.NewRouter().
+		Register(storage.SchemeFile, localStore).
+		Register(storage.SchemeS3, storage.NewS3Store(storage.S3Config{Region: s3Region, Endpoint: cfg.Storage.S3Endpoint})).
+		Register(storage.SchemeHTTPS, httpStore)
+
+	// Initialize repositories
 	orderRepo, err := repositories.NewOrderRepository(db)
 	if err != nil {
 		log.Fatalf("Failed to initialize order repository: %v", err)
 	}
 
-	// Initialize service
-	orderService := services.NewOrderService(orderRepo, sqsPublisher)
+	shipmentRepo, err := repositories.NewShipmentRepository(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize shipment repository: %v", err)
+	}
+
+	returnRepo, err := repositories.NewReturnRepository(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize return repository: %v", err)
+	}
+
+	exportJobRepo, err := repositories.NewExportJobRepository(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize export job repository: %v", err)
+	}
+
+	bulkJobRepo, err := repositories.NewBulkJobRepository(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize bulk job repository: %v", err)
+	}
+
+	importTemplateRepo, err := repositories.NewImportTemplateRepository(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize import template repository: %v", err)
+	}
+
+	deadLetterRepo, err := repositories.NewDeadLetterRepository(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize dead letter repository: %v", err)
+	}
+
+	// Initialize services
+	orderService := services.NewOrderService(orderRepo)
+	templateService := services.NewImportTemplateService(importTemplateRepo)
+	// Stock is not weighed in allocation until an inventory service is integrated
+	hubs := make(allocation.StaticHubs, len(cfg.Allocation.Hubs))
+	for i, hub := range cfg.Allocation.Hubs {
+		hubs[i] = allocation.Hub{ID: hub.ID, Country: hub.Country, Zone: hub.Zone}
+	}
+	allocator := allocation.NewAllocator(hubs,
+		allocation.ProximityStrategy{Zone: allocation.PostalPrefixZone(int(cfg.Allocation.ZoneLength))},
+		allocation.PriorityStrategy{Priorities: cfg.Allocation.SellerPriorities},
+	)
+	bulkImporter := services.NewBulkImporter(orderRepo, bulkJobRepo, importTemplateRepo, allocator, fileStore, cfg.Bulk.ReportLocation, services.BulkImportOptions{
+		BatchSize:           int(cfg.Bulk.BatchSize),
+		Concurrency:         int(cfg.Bulk.Concurrency),
+		SellerConcurrency:   int(cfg.Bulk.SellerConcurrency),
+		SellerRowsPerMinute: int(cfg.Bulk.SellerRowsPerMinute),
+	})
+	var bulkSource queue.Source
+	if cfg.Queue.Publisher == configs.PublisherMock {
+		// Without a queue, bulk jobs go through an in-memory stand-in
+		bulkQueue := queue.NewLocalQueue(cfg.Queue.BulkOrderQueue, cfg.Queue.RetryDelay)
+		sqsPublisher = bulkQueue
+		bulkSource = bulkQueue
+	} else {
+		bulkSource, err = queue.NewSource(context.Background(), cfg, cfg.Queue.BulkOrderQueue)
+		if err != nil {
+			log.Fatalf("Failed to initialize bulk order consumer: %v", err)
+		}
+	}
+	deadLetterService := services.NewDeadLetterService(deadLetterRepo, map[string]services.SQSPublisher{
+		cfg.Queue.BulkOrderQueue:   sqsPublisher,
+		cfg.Queue.OrderEventsQueue: eventsPublisher,
+	})
+	consumer := queue.NewConsumer(cfg.Queue.BulkOrderQueue, bulkSource, bulkImporter.HandleMessage, deadLetterService, queue.ConsumerOptions{
+		MaxReceives: int(cfg.Queue.MaxReceives),
+		Workers:     int(cfg.Bulk.Workers),
+	})
+	go consumer.Run(context.Background())
+	log.Printf("Consuming bulk order jobs from %s", cfg.Queue.BulkOrderQueue)
+	bulkService := services.NewBulkOrderService(orderRepo, bulkJobRepo, importTemplateRepo, sqsPublisher, fileStore, cfg.Bulk.UploadLocation, services.BulkUploadLimits{
+		MaxBytes:    int64(cfg.Bulk.MaxUploadBytes),
+		PreviewRows: int(cfg.Bulk.PreviewRows),
+	})
+	shipmentService := services.NewShipmentService(orderRepo, shipmentRepo)
+	returnService := services.NewReturnService(orderRepo, returnRepo, events)
+	// Payment and stock holds are released by request until those services are integrated
+	holdService := services.NewHoldService(orderRepo, holds.NewEngine(holds.AddressRule{}), events)
+
+	slaService := services.NewSLAService(orderRepo, cfg.SLA, events)
+	exportService := services.NewExportService(orderRepo, exportJobRepo, fileStore, cfg.Export.Location)
+
+	// Background jobs
+	if cfg.Jobs.HoldReleaseInterval > 0 {
+		go holdService.RunReleaseJob(context.Background(), cfg.Jobs.HoldReleaseInterval)
+		log.Printf("Hold release job running every %s", cfg.Jobs.HoldReleaseInterval)
+	}
+	if cfg.Jobs.SLACheckInterval > 0 {
+		go slaService.RunBreachJob(context.Background(), cfg.Jobs.SLACheckInterval)
+		log.Printf("SLA breach job running every %s", cfg.Jobs.SLACheckInterval)
+	}
 
-	// Initialize handler
+	// Initialize handlers
 	orderHandler := http.NewOrderHandler(orderService)
+	shipmentHandler := http.NewShipmentHandler(shipmentService)
+	returnHandler := http.NewReturnHandler(returnService)
+	holdHandler := http.NewHoldHandler(holdService)
+	slaHandler := http.NewSLAHandler(slaService)
+	exportHandler := http.NewExportHandler(exportService)
+	bulkHandler := http.NewBulkHandler(bulkService)
+	templateHandler := http.NewImportTemplateHandler(templateService)
+	deadLetterHandler := http.NewDeadLetterHandler(deadLetterService)
 
 	// Setup routes
-	router := routes.SetupRoutes(orderHandler)
+	router := routes.SetupRoutes(orderHandler, shipmentHandler, returnHandler, holdHandler, slaHandler, exportHandler, bulkHandler, templateHandler, deadLetterHandler)
 
 	// Start server
 	log.Printf("Starting server on port %s", cfg.Server.Port)
@@ -0,0 +1,156 @@
+// Package allocation chooses the hub that should fulfil an order when the seller did not name one.
+package allocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"oms-service-goc/internals/models"
+)
+
+var ErrNoHubAvailable = errors.New("no hub can fulfil the order")
+
+// Hub is a fulfilment location that orders can be allocated to
+type Hub struct {
+	ID      string
+	Country string // ISO 3166-1 alpha-2
+	Zone    string // postal code zone, see PostalPrefixZone
+}
+
+// HubDirectory lists the hubs a tenant fulfils from
+type HubDirectory interface {
+	ListHubs(ctx context.Context, tenantID string) ([]Hub, error)
+}
+
+// StaticHubs is a HubDirectory that offers the same hubs to every tenant
+type StaticHubs []Hub
+
+func (h StaticHubs) ListHubs(ctx context.Context, tenantID string) ([]Hub, error) {
+	return h, nil
+}
+
+// Verdict is a strategy's opinion of one hub for one order. Ineligible hubs are dropped;
+// among eligible hubs a lower Rank is preferred.
+type Verdict struct {
+	Eligible bool
+	Rank     int
+	Reason   string
+}
+
+// Strategy judges candidate hubs for an order. Evaluate returns one verdict per hub, in the same order.
+type Strategy interface {
+	Name() string
+	Evaluate(ctx context.Context, order *models.Order, hubs []Hub) ([]Verdict, error)
+}
+
+// Allocation is the chosen hub and why it was chosen
+type Allocation struct {
+	HubID  string `json:"hub_id"`
+	Reason string `json:"reason"`
+}
+
+// Allocator runs its strategies over every candidate hub. A hub must be eligible under all
+// strategies; the remaining hubs are compared by rank strategy by strategy, so earlier
+// strategies take precedence and later ones break ties. A full tie goes to the lowest hub ID.
+type Allocator struct {
+	hubs       HubDirectory
+	strategies []Strategy
+}
+
+func NewAllocator(hubs HubDirectory, strategies ...Strategy) *Allocator {
+	return &Allocator{
+		hubs:       hubs,
+		strategies: strategies,
+	}
+}
+
+type candidate struct {
+	hub      Hub
+	verdicts []Verdict
+}
+
+// Allocate chooses a hub for the order without changing it
+func (a *Allocator) Allocate(ctx context.Context, order *models.Order) (*Allocation, error) {
+	hubs, err := a.hubs.ListHubs(ctx, order.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hubs: %w", err)
+	}
+	if len(hubs) == 0 {
+		return nil, fmt.Errorf("%w: tenant %s has no hubs", ErrNoHubAvailable, order.TenantID)
+	}
+
+	candidates := make([]*candidate, len(hubs))
+	for i, hub := range hubs {
+		candidates[i] = &candidate{hub: hub}
+	}
+
+	var rejections []string
+	for _, strategy := range a.strategies {
+		current := make([]Hub, len(candidates))
+		for i, c := range candidates {
+			current[i] = c.hub
+		}
+
+		verdicts, err := strategy.Evaluate(ctx, order, current)
+		if err != nil {
+			return nil, fmt.Errorf("%s strategy: %w", strategy.Name(), err)
+		}
+		if len(verdicts) != len(current) {
+			return nil, fmt.Errorf("%s strategy returned %d verdicts for %d hubs", strategy.Name(), len(verdicts), len(current))
+		}
+
+		eligible := candidates[:0]
+		for i, c := range candidates {
+			if !verdicts[i].Eligible {
+				rejections = append(rejections, fmt.Sprintf("%s: %s", c.hub.ID, verdicts[i].Reason))
+				continue
+			}
+			c.verdicts = append(c.verdicts, verdicts[i])
+			eligible = append(eligible, c)
+		}
+		candidates = eligible
+
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoHubAvailable, strings.Join(rejections, "; "))
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		for k := range a.strategies {
+			if candidates[i].verdicts[k].Rank != candidates[j].verdicts[k].Rank {
+				return candidates[i].verdicts[k].Rank < candidates[j].verdicts[k].Rank
+			}
+		}
+		return candidates[i].hub.ID < candidates[j].hub.ID
+	})
+
+	chosen := candidates[0]
+	reasons := make([]string, len(a.strategies))
+	for k, strategy := range a.strategies {
+		reasons[k] = fmt.Sprintf("%s: %s", strategy.Name(), chosen.verdicts[k].Reason)
+	}
+
+	return &Allocation{
+		HubID:  chosen.hub.ID,
+		Reason: strings.Join(reasons, "; "),
+	}, nil
+}
+
+// Assign allocates a hub when the order has none and records the reason on the order
+func (a *Allocator) Assign(ctx context.Context, order *models.Order) error {
+	if order.HubID != "" {
+		return nil
+	}
+
+	allocation, err := a.Allocate(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	order.HubID = allocation.HubID
+	order.AllocationReason = allocation.Reason
+	return nil
+}
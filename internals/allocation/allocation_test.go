@@ -0,0 +1,96 @@
+package allocation
+
+import (
+	"context"
+	"testing"
+
+	"oms-service-goc/internals/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInventory serves fixed stock levels keyed by hub ID then SKU code
+type fakeInventory map[string]map[string]int
+
+func (f fakeInventory) StockLevels(ctx context.Context, tenantID, sellerID string, skuCodes []string) (map[string]map[string]int, error) {
+	return f, nil
+}
+
+var testHubs = StaticHubs{
+	{ID: "blr-1", Country: "IN", Zone: "560"},
+	{ID: "del-1", Country: "IN", Zone: "110"},
+	{ID: "dxb-1", Country: "AE"},
+}
+
+func newAllocationOrder(postalCode string) *models.Order {
+	return &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller1",
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 2},
+			{SKUCode: "SKU002", Quantity: 1},
+		},
+		ShippingAddress: &models.Address{Country: "IN", PostalCode: postalCode},
+	}
+}
+
+func TestAllocator_StockFiltersHubs(t *testing.T) {
+	inventory := fakeInventory{
+		"blr-1": {"SKU001": 1, "SKU002": 5},
+		"del-1": {"SKU001": 2, "SKU002": 1},
+	}
+	allocator := NewAllocator(testHubs, StockStrategy{Inventory: inventory}, ProximityStrategy{Zone: PostalPrefixZone(3)})
+
+	allocation, err := allocator.Allocate(context.Background(), newAllocationOrder("560001"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "del-1", allocation.HubID)
+	assert.Equal(t, "stock: all 2 SKUs in stock; proximity: in shipping country IN", allocation.Reason)
+}
+
+func TestAllocator_ProximityBeforePriority(t *testing.T) {
+	inventory := fakeInventory{
+		"blr-1": {"SKU001": 10, "SKU002": 10},
+		"del-1": {"SKU001": 10, "SKU002": 10},
+		"dxb-1": {"SKU001": 10, "SKU002": 10},
+	}
+	priorities := PriorityStrategy{Priorities: map[string][]string{"seller1": {"dxb-1", "del-1"}}}
+
+	allocator := NewAllocator(testHubs, ProximityStrategy{Zone: PostalPrefixZone(3)}, priorities)
+	allocation, err := allocator.Allocate(context.Background(), newAllocationOrder("110 017"))
+	require.NoError(t, err)
+	assert.Equal(t, "del-1", allocation.HubID)
+	assert.Equal(t, "proximity: in shipping zone 110; priority: seller priority 2", allocation.Reason)
+
+	// With priority first the seller's list wins over distance
+	allocator = NewAllocator(testHubs, StockStrategy{Inventory: inventory}, priorities, ProximityStrategy{Zone: PostalPrefixZone(3)})
+	allocation, err = allocator.Allocate(context.Background(), newAllocationOrder("110017"))
+	require.NoError(t, err)
+	assert.Equal(t, "dxb-1", allocation.HubID)
+}
+
+func TestAllocator_NoHubAvailable(t *testing.T) {
+	allocator := NewAllocator(testHubs, StockStrategy{Inventory: fakeInventory{"blr-1": {"SKU001": 2}}})
+
+	_, err := allocator.Allocate(context.Background(), newAllocationOrder("560001"))
+
+	assert.ErrorIs(t, err, ErrNoHubAvailable)
+	assert.Contains(t, err.Error(), "blr-1: SKU002 short by 1")
+	assert.Contains(t, err.Error(), "dxb-1: SKU001 short by 2, SKU002 short by 1")
+}
+
+func TestAllocator_AssignKeepsSellerHub(t *testing.T) {
+	allocator := NewAllocator(testHubs, PriorityStrategy{})
+
+	order := newAllocationOrder("560001")
+	order.HubID = "own-hub"
+	require.NoError(t, allocator.Assign(context.Background(), order))
+	assert.Equal(t, "own-hub", order.HubID)
+	assert.Empty(t, order.AllocationReason)
+
+	order.HubID = ""
+	require.NoError(t, allocator.Assign(context.Background(), order))
+	assert.Equal(t, "blr-1", order.HubID)
+	assert.Equal(t, "priority: not on seller priority list", order.AllocationReason)
+}
@@ -0,0 +1,128 @@
+package allocation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oms-service-goc/internals/models"
+)
+
+// Inventory reports sellable stock. StockLevels returns quantities keyed by hub ID then SKU
+// code for the requested SKUs; hubs or SKUs that are missing have no stock.
+type Inventory interface {
+	StockLevels(ctx context.Context, tenantID, sellerID string, skuCodes []string) (map[string]map[string]int, error)
+}
+
+// StockStrategy only allows hubs that hold enough stock for every item on the order.
+// Hubs holding more spare stock after the order are preferred.
+type StockStrategy struct {
+	Inventory Inventory
+}
+
+func (s StockStrategy) Name() string { return "stock" }
+
+func (s StockStrategy) Evaluate(ctx context.Context, order *models.Order, hubs []Hub) ([]Verdict, error) {
+	skuCodes := make([]string, len(order.Items))
+	for i, item := range order.Items {
+		skuCodes[i] = item.SKUCode
+	}
+
+	levels, err := s.Inventory.StockLevels(ctx, order.TenantID, order.SellerID, skuCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stock levels: %w", err)
+	}
+
+	verdicts := make([]Verdict, len(hubs))
+	for i, hub := range hubs {
+		var shortages []string
+		spare := 0
+		for _, item := range order.Items {
+			available := levels[hub.ID][item.SKUCode]
+			if available < item.Quantity {
+				shortages = append(shortages, fmt.Sprintf("%s short by %d", item.SKUCode, item.Quantity-available))
+				continue
+			}
+			spare += available - item.Quantity
+		}
+
+		if len(shortages) > 0 {
+			verdicts[i] = Verdict{Reason: strings.Join(shortages, ", ")}
+			continue
+		}
+		// Negated so the hub left with the most stock ranks first
+		verdicts[i] = Verdict{Eligible: true, Rank: -spare, Reason: fmt.Sprintf("all %d SKUs in stock", len(order.Items))}
+	}
+	return verdicts, nil
+}
+
+// PriorityStrategy prefers hubs in the order each seller listed them. Hubs a seller did not
+// list stay eligible but rank after the listed ones.
+type PriorityStrategy struct {
+	Priorities map[string][]string // seller ID -> hub IDs, most preferred first
+}
+
+func (s PriorityStrategy) Name() string { return "priority" }
+
+func (s PriorityStrategy) Evaluate(ctx context.Context, order *models.Order, hubs []Hub) ([]Verdict, error) {
+	priorities := s.Priorities[order.SellerID]
+
+	verdicts := make([]Verdict, len(hubs))
+	for i, hub := range hubs {
+		verdicts[i] = Verdict{Eligible: true, Rank: len(priorities), Reason: "not on seller priority list"}
+		for position, hubID := range priorities {
+			if hubID == hub.ID {
+				verdicts[i] = Verdict{Eligible: true, Rank: position, Reason: fmt.Sprintf("seller priority %d", position+1)}
+				break
+			}
+		}
+	}
+	return verdicts, nil
+}
+
+// ZoneFunc maps a shipping country and postal code to a zone comparable with Hub.Zone
+type ZoneFunc func(country, postalCode string) string
+
+// PostalPrefixZone treats postal codes sharing their first n characters as one zone
+func PostalPrefixZone(n int) ZoneFunc {
+	return func(country, postalCode string) string {
+		postalCode = strings.ToUpper(strings.ReplaceAll(postalCode, " ", ""))
+		if len(postalCode) > n {
+			postalCode = postalCode[:n]
+		}
+		return postalCode
+	}
+}
+
+// ProximityStrategy prefers hubs in the shipping address's zone, then hubs in the same
+// country. Orders without a shipping postal code leave every hub equal.
+type ProximityStrategy struct {
+	Zone ZoneFunc
+}
+
+func (s ProximityStrategy) Name() string { return "proximity" }
+
+func (s ProximityStrategy) Evaluate(ctx context.Context, order *models.Order, hubs []Hub) ([]Verdict, error) {
+	verdicts := make([]Verdict, len(hubs))
+
+	address := order.ShippingAddress
+	if address == nil || address.PostalCode == "" {
+		for i := range verdicts {
+			verdicts[i] = Verdict{Eligible: true, Reason: "no shipping postal code"}
+		}
+		return verdicts, nil
+	}
+
+	zone := s.Zone(address.Country, address.PostalCode)
+	for i, hub := range hubs {
+		switch {
+		case strings.EqualFold(hub.Country, address.Country) && hub.Zone != "" && hub.Zone == zone:
+			verdicts[i] = Verdict{Eligible: true, Rank: 0, Reason: fmt.Sprintf("in shipping zone %s", zone)}
+		case strings.EqualFold(hub.Country, address.Country):
+			verdicts[i] = Verdict{Eligible: true, Rank: 1, Reason: fmt.Sprintf("in shipping country %s", address.Country)}
+		default:
+			verdicts[i] = Verdict{Eligible: true, Rank: 2, Reason: "outside shipping country"}
+		}
+	}
+	return verdicts, nil
+}
@@ -0,0 +1,405 @@
+package bulk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"oms-service-goc/internals/allocation"
+	"oms-service-goc/internals/models"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Columns every bulk file must have
+// hub_id is optional; orders without one are given a hub by the allocation engine
+var requiredColumns = []string{"tenant_id", "seller_id", "sku_code", "quantity"}
+
+// RowError describes why a row of a bulk file was rejected
+type RowError struct {
+	Line    int    `json:"line"` // line number in the file, the header is line 1
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("line %d, column %s: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ParsedRow is a decoded row together with its line number in the file
+type ParsedRow struct {
+	Line int
+	Row  models.OrderCSVRow
+}
+
+// Columns returns the row's non-empty values keyed by column name
+func (r ParsedRow) Columns() map[string]string {
+	columns := map[string]string{}
+	value := reflect.ValueOf(r.Row)
+	for column, index := range csvFields {
+		field := value.Field(index)
+		if field.IsZero() {
+			continue
+		}
+		columns[column] = fmt.Sprint(field.Interface())
+	}
+	return columns
+}
+
+// csvFields maps each csv tag on models.OrderCSVRow to its field index
+var csvFields = func() map[string]int {
+	fields := map[string]int{}
+	rowType := reflect.TypeOf(models.OrderCSVRow{})
+	for i := 0; i < rowType.NumField(); i++ {
+		if tag := rowType.Field(i).Tag.Get("csv"); tag != "" {
+			fields[tag] = i
+		}
+	}
+	return fields
+}()
+
+// ParseCSV decodes a bulk order file using the csv tags on models.OrderCSVRow as column names.
+// Column order does not matter and unknown columns are ignored. Rows that fail to decode are
+// reported as RowErrors and skipped; an error is only returned when the file itself is unusable.
+func ParseCSV(r io.Reader) ([]ParsedRow, []RowError, error) {
+	return parse(newCSVRecords(r), nil, 0)
+}
+
+// PreviewCSV checks the header and decodes at most limit rows, so an upload can be
+// rejected early without reading the whole file
+func PreviewCSV(r io.Reader, limit int) ([]ParsedRow, []RowError, error) {
+	return parse(newCSVRecords(r), nil, limit)
+}
+
+// recordReader yields the rows of a bulk file as text cells, whatever the file format.
+// Header returns the column names as written in the file, or nil when the reader lays every
+// row out in csvColumns order itself. Next returns io.EOF after the last row, and a RowError
+// for a row that cannot be read when the rest of the file still can.
+type recordReader interface {
+	Header() ([]string, error)
+	Next() (line int, record []string, err error)
+}
+
+type csvRecords struct {
+	reader *csv.Reader
+}
+
+func newCSVRecords(r io.Reader) *csvRecords {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &csvRecords{reader: reader}
+}
+
+func (c *csvRecords) Header() ([]string, error) {
+	return c.reader.Read()
+}
+
+func (c *csvRecords) Next() (int, []string, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, nil, err
+	}
+	line, _ := c.reader.FieldPos(0)
+	if err != nil {
+		return line, nil, RowError{Line: line, Message: err.Error()}
+	}
+	return line, record, nil
+}
+
+// parse decodes the records into rows, reading every row when limit is zero
+func parse(records recordReader, mapping *Mapping, limit int) ([]ParsedRow, []RowError, error) {
+	var rows []ParsedRow
+	rowErrors, err := scan(records, mapping, limit, func(row ParsedRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, rowErrors, nil
+}
+
+// scan decodes the records one at a time, handing each good row to fn before the next is
+// read. It stops at the first error fn returns and returns that error.
+func scan(records recordReader, mapping *Mapping, limit int, fn func(row ParsedRow) error) ([]RowError, error) {
+	header, err := records.Header()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file is empty")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := csvColumns
+	if header != nil {
+		columns = make([]string, len(header))
+		present := map[string]bool{}
+		for i, name := range header {
+			columns[i] = mapping.Column(normalizeColumn(name))
+			present[columns[i]] = true
+		}
+
+		var missing []string
+		for _, name := range requiredColumns {
+			if !present[name] && !mapping.hasDefault(name) {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	var rowErrors []RowError
+	for read := 0; limit == 0 || read < limit; read++ {
+		line, record, err := records.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowError RowError
+		if errors.As(err, &rowError) {
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row, errs := decodeRow(line, columns, record, mapping)
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		if err := fn(ParsedRow{Line: line, Row: row}); err != nil {
+			return nil, err
+		}
+	}
+
+	return rowErrors, nil
+}
+
+func decodeRow(line int, columns, record []string, mapping *Mapping) (models.OrderCSVRow, []RowError) {
+	var (
+		row  models.OrderCSVRow
+		errs []RowError
+	)
+	value := reflect.ValueOf(&row).Elem()
+
+	for i, raw := range record {
+		if i >= len(columns) {
+			break
+		}
+		index, ok := csvFields[columns[i]]
+		if !ok {
+			continue
+		}
+		if err := setField(value.Field(index), strings.TrimSpace(raw)); err != nil {
+			errs = append(errs, RowError{Line: line, Column: columns[i], Message: err.Error()})
+		}
+	}
+
+	if mapping != nil {
+		for column, constant := range mapping.defaults {
+			// Defaults were checked by NewMapping so they always decode
+			if field := value.Field(csvFields[column]); field.IsZero() && !hasColumnError(errs, column) {
+				setField(field, constant)
+			}
+		}
+	}
+
+	for _, name := range requiredColumns {
+		if value.Field(csvFields[name]).IsZero() && !hasColumnError(errs, name) {
+			errs = append(errs, RowError{Line: line, Column: name, Message: "value is required"})
+		}
+	}
+
+	return row, errs
+}
+
+func hasColumnError(errs []RowError, column string) bool {
+	for _, err := range errs {
+		if err.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderGroup is an order assembled from one or more rows of a bulk file
+type OrderGroup struct {
+	Lines []int
+	Order *models.Order
+}
+
+// GroupOrders combines rows into orders. Rows sharing tenant, seller, hub and order_ref
+// belong to the same order; a row without order_ref is an order on its own. Repeated SKUs
+// within an order are merged. Groups are returned in the order they first appear.
+func GroupOrders(rows []ParsedRow) []*OrderGroup {
+	var groups []*OrderGroup
+	byKey := map[string]*OrderGroup{}
+
+	for _, parsed := range rows {
+		key := GroupKey(parsed.Row)
+		group := byKey[key]
+		if group == nil {
+			group = newGroup(parsed.Row)
+			groups = append(groups, group)
+			if key != "" {
+				byKey[key] = group
+			}
+		}
+		group.add(parsed)
+	}
+
+	return groups
+}
+
+// GroupKey identifies the order a row belongs to. Rows without order_ref have no key, as
+// each is an order of its own.
+func GroupKey(row models.OrderCSVRow) string {
+	if row.OrderRef == "" {
+		return ""
+	}
+	return strings.Join([]string{row.TenantID, row.SellerID, row.HubID, row.OrderRef}, "\x00")
+}
+
+func newGroup(row models.OrderCSVRow) *OrderGroup {
+	return &OrderGroup{
+		Order: &models.Order{
+			TenantID: row.TenantID,
+			SellerID: row.SellerID,
+			HubID:    row.HubID,
+			OrderRef: row.OrderRef,
+		},
+	}
+}
+
+func (g *OrderGroup) add(parsed ParsedRow) {
+	row := parsed.Row
+	g.Lines = append(g.Lines, parsed.Line)
+	order := g.Order
+	if order.Customer == nil {
+		order.Customer = row.Customer()
+	}
+	if order.ShippingAddress == nil {
+		order.ShippingAddress = row.ShippingAddress()
+	}
+	if order.BillingAddress == nil {
+		order.BillingAddress = row.BillingAddress()
+	}
+	if order.Currency == "" {
+		order.Currency = row.Currency
+	}
+	if order.ShippingFee.IsZero() {
+		order.ShippingFee = row.ShippingFee
+	}
+	addItem(order, row)
+}
+
+// Grouper groups rows streamed in file order without holding the whole file. It is told the
+// line of the last row of every keyed order, found by a first pass over the file, so each
+// order is released as soon as its last row arrives.
+type Grouper struct {
+	lastLines map[string]int
+	open      map[string]*OrderGroup
+}
+
+// NewGrouper takes the last line of each GroupKey in the file
+func NewGrouper(lastLines map[string]int) *Grouper {
+	return &Grouper{
+		lastLines: lastLines,
+		open:      map[string]*OrderGroup{},
+	}
+}
+
+// Add adds a row and returns its order once the order is complete, or nil while more of
+// its rows are still to come
+func (g *Grouper) Add(parsed ParsedRow) *OrderGroup {
+	key := GroupKey(parsed.Row)
+	if key == "" {
+		group := newGroup(parsed.Row)
+		group.add(parsed)
+		return group
+	}
+
+	group := g.open[key]
+	if group == nil {
+		group = newGroup(parsed.Row)
+		g.open[key] = group
+	}
+	group.add(parsed)
+	if parsed.Line < g.lastLines[key] {
+		return nil
+	}
+	delete(g.open, key)
+	return group
+}
+
+// Flush returns the orders still waiting for rows, in the order they first appear. It is
+// only non-empty when the file changed between the two passes.
+func (g *Grouper) Flush() []*OrderGroup {
+	groups := make([]*OrderGroup, 0, len(g.open))
+	for _, group := range g.open {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(a, b int) bool { return groups[a].Lines[0] < groups[b].Lines[0] })
+	g.open = map[string]*OrderGroup{}
+	return groups
+}
+
+// addItem merges a repeated SKU into its existing item: quantities and line discounts add
+// up, and the unit price and tax rate of the first row that gave one are kept
+func addItem(order *models.Order, row models.OrderCSVRow) {
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.SKUCode != row.SKUCode {
+			continue
+		}
+		item.Quantity += row.Quantity
+		item.Discount = item.Discount.Add(row.Discount)
+		if item.UnitPrice.IsZero() {
+			item.UnitPrice = row.UnitPrice
+		}
+		if item.TaxRate.IsZero() {
+			item.TaxRate = row.TaxRate
+		}
+		return
+	}
+	order.Items = append(order.Items, models.OrderItem{
+		SKUCode:   row.SKUCode,
+		Quantity:  row.Quantity,
+		UnitPrice: row.UnitPrice,
+		Discount:  row.Discount,
+		TaxRate:   row.TaxRate,
+	})
+}
+
+// Allocate asks the allocator for a hub when the rows did not name one. Without an allocator,
+// or when it finds no hub, the order is rejected since it cannot be fulfilled from anywhere.
+func (g *OrderGroup) Allocate(ctx context.Context, allocator *allocation.Allocator) []RowError {
+	if g.Order.HubID != "" {
+		return nil
+	}
+	if allocator == nil {
+		return []RowError{{Line: g.Lines[0], Column: "hub_id", Message: "hub_id is required"}}
+	}
+	if err := allocator.Assign(ctx, g.Order); err != nil {
+		return []RowError{{Line: g.Lines[0], Column: "hub_id", Message: err.Error()}}
+	}
+	return nil
+}
+
+// Validate initialises the grouped order and reports validation failures against its first line
+func (g *OrderGroup) Validate(ctx context.Context) []RowError {
+	g.Order.Initialise(ctx)
+	if err := g.Order.Validate(ctx); err != nil {
+		return []RowError{{Line: g.Lines[0], Message: err.Error()}}
+	}
+	return nil
+}
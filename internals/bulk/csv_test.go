@@ -0,0 +1,170 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV_CustomerAndAddressColumns(t *testing.T) {
+	file := "tenant_id,seller_id,hub_id,order_ref,sku_code,quantity,customer_name,customer_email,shipping_address_line1,shipping_city,shipping_postal_code,shipping_country\n" +
+		"tenant1,seller1,hub1,A-1,SKU001,2,John Doe,john@example.com,1 Main St,Springfield,12345,us\n" +
+		"tenant1,seller1,hub1,A-1,SKU002,1,,,,,,\n" +
+		"tenant1,seller1,hub1,A-1,SKU001,3,,,,,,\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	require.Len(t, rows, 3)
+
+	groups := GroupOrders(rows)
+	require.Len(t, groups, 1)
+
+	order := groups[0].Order
+	assert.Equal(t, []int{2, 3, 4}, groups[0].Lines)
+	assert.Equal(t, "A-1", order.OrderRef)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, 5, order.Items[0].Quantity)
+	require.NotNil(t, order.Customer)
+	assert.Equal(t, "john@example.com", order.Customer.Email)
+	require.NotNil(t, order.ShippingAddress)
+	assert.Equal(t, "Springfield", order.ShippingAddress.City)
+	assert.Nil(t, order.BillingAddress)
+
+	assert.Empty(t, groups[0].Validate(context.Background()))
+	assert.Equal(t, "US", order.ShippingAddress.Country)
+}
+
+func TestParseCSV_PricingColumns(t *testing.T) {
+	file := "tenant_id,seller_id,hub_id,order_ref,sku_code,quantity,unit_price,discount,tax_rate,shipping_fee,currency\n" +
+		"tenant1,seller1,hub1,A-1,SKU001,2,10.00,1,18,5,usd\n" +
+		"tenant1,seller1,hub1,A-1,SKU002,1,4.50,,,,\n" +
+		"tenant1,seller1,hub1,A-1,SKU001,1,,0.5,,,\n" +
+		"tenant1,seller1,hub1,A-2,SKU001,1,ten,,,,\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, []RowError{{Line: 5, Column: "unit_price", Message: `"ten" is not a number`}}, rowErrors)
+
+	groups := GroupOrders(rows)
+	require.Len(t, groups, 1)
+	order := groups[0].Order
+	assert.Equal(t, "usd", order.Currency)
+	assert.Equal(t, "5", order.ShippingFee.String())
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, 3, order.Items[0].Quantity)
+	assert.Equal(t, "10", order.Items[0].UnitPrice.String())
+	assert.Equal(t, "1.5", order.Items[0].Discount.String())
+	assert.Equal(t, "18", order.Items[0].TaxRate.String())
+
+	require.Empty(t, groups[0].Validate(context.Background()))
+	assert.Equal(t, "USD", order.Currency)
+	assert.Equal(t, "34.5", order.Subtotal.String())
+	assert.Equal(t, "43.13", order.Total.String())
+}
+
+func TestParseCSV_RowErrors(t *testing.T) {
+	file := "sku_code,quantity,tenant_id,seller_id,hub_id\n" +
+		"SKU001,two,tenant1,seller1,hub1\n" +
+		"SKU002,1,tenant1,,hub1\n" +
+		"SKU003,1,tenant1,seller1,hub1\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+
+	require.Len(t, rows, 1)
+	assert.Equal(t, 4, rows[0].Line)
+	require.Len(t, rowErrors, 2)
+	assert.Equal(t, RowError{Line: 2, Column: "quantity", Message: `"two" is not a whole number`}, rowErrors[0])
+	assert.Equal(t, RowError{Line: 3, Column: "seller_id", Message: "value is required"}, rowErrors[1])
+}
+
+func TestParseCSV_MissingColumns(t *testing.T) {
+	_, _, err := ParseCSV(strings.NewReader("tenant_id,sku_code\n"))
+
+	assert.EqualError(t, err, "missing required columns: seller_id, quantity")
+}
+
+func TestOrderGroup_ValidateAddress(t *testing.T) {
+	file := "tenant_id,seller_id,hub_id,sku_code,quantity,customer_name,customer_email,shipping_address_line1,shipping_city,shipping_postal_code,shipping_country\n" +
+		"tenant1,seller1,hub1,SKU001,1,Jane,not-an-email,1 Main St,Springfield,ABC,US\n"
+
+	rows, _, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+
+	rowErrors := GroupOrders(rows)[0].Validate(context.Background())
+
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 2, rowErrors[0].Line)
+	assert.Contains(t, rowErrors[0].Message, "customer.email is not a valid email address")
+	assert.Contains(t, rowErrors[0].Message, `shipping_address.postal_code "ABC" is not valid for US`)
+}
+
+func TestPreviewCSV_StopsAfterLimit(t *testing.T) {
+	file := "tenant_id,seller_id,sku_code,quantity\n" +
+		"tenant1,seller1,SKU001,1\n" +
+		"tenant1,seller1,SKU002,2\n" +
+		"tenant1,seller1,SKU003,three\n"
+
+	rows, rowErrors, err := PreviewCSV(strings.NewReader(file), 2)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Empty(t, rowErrors)
+
+	_, _, err = PreviewCSV(strings.NewReader("tenant_id,seller_id\n"), 2)
+	assert.Error(t, err)
+}
+
+func TestGrouper_ReleasesOrdersAtTheirLastRow(t *testing.T) {
+	file := "tenant_id,seller_id,hub_id,order_ref,sku_code,quantity\n" +
+		"tenant1,seller1,hub1,A-1,SKU001,1\n" +
+		"tenant1,seller1,hub1,A-2,SKU002,2\n" +
+		"tenant1,seller1,hub1,,SKU003,3\n" +
+		"tenant1,seller1,hub1,A-1,SKU004,4\n" +
+		"tenant1,seller1,hub1,A-2,SKU005,x\n"
+
+	// First pass: where each order ends
+	lastLines := map[string]int{}
+	_, err := Scan(strings.NewReader(file), "orders.csv", ReadOptions{}, func(row ParsedRow) error {
+		if key := GroupKey(row.Row); key != "" {
+			lastLines[key] = row.Line
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	grouper := NewGrouper(lastLines)
+	var released [][]int
+	rowErrors, err := Scan(strings.NewReader(file), "orders.csv", ReadOptions{}, func(row ParsedRow) error {
+		if group := grouper.Add(row); group != nil {
+			released = append(released, group.Lines)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 6, rowErrors[0].Line)
+
+	// A-2 is complete at line 3 because its other row was rejected
+	assert.Equal(t, [][]int{{3}, {4}, {2, 5}}, released)
+	assert.Empty(t, grouper.Flush())
+}
+
+func TestScan_StopsOnHandlerError(t *testing.T) {
+	file := "tenant_id,seller_id,sku_code,quantity\n" +
+		"tenant1,seller1,SKU001,1\n" +
+		"tenant1,seller1,SKU002,2\n"
+
+	stop := errors.New("stop")
+	var lines []int
+	_, err := Scan(strings.NewReader(file), "orders.csv", ReadOptions{}, func(row ParsedRow) error {
+		lines = append(lines, row.Line)
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []int{2}, lines)
+}
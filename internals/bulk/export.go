@@ -0,0 +1,135 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"oms-service-goc/internals/models"
+	"reflect"
+)
+
+// ExportFormat is a file format orders can be exported in
+type ExportFormat string
+
+const (
+	FormatCSV    ExportFormat = "csv"
+	FormatNDJSON ExportFormat = "ndjson"
+)
+
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch format := ExportFormat(value); format {
+	case FormatCSV, FormatNDJSON:
+		return format, nil
+	case "":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, use csv or ndjson", value)
+	}
+}
+
+func (f ExportFormat) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+func (f ExportFormat) Extension() string {
+	return "." + string(f)
+}
+
+// OrderWriter writes orders one at a time so exports never hold more than one order in memory.
+// Close flushes buffered output; it does not close the underlying writer.
+type OrderWriter interface {
+	Write(order *models.Order) error
+	Close() error
+}
+
+func NewOrderWriter(format ExportFormat, w io.Writer) (OrderWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvOrderWriter{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonOrderWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// csvColumns are the csv tags of models.OrderCSVRow in field order
+var csvColumns = func() []string {
+	var columns []string
+	rowType := reflect.TypeOf(models.OrderCSVRow{})
+	for i := 0; i < rowType.NumField(); i++ {
+		if tag := rowType.Field(i).Tag.Get("csv"); tag != "" {
+			columns = append(columns, tag)
+		}
+	}
+	return columns
+}()
+
+// csvFlushEvery bounds how many rows are buffered before being written out
+const csvFlushEvery = 500
+
+// csvOrderWriter writes one row per item in the layout ParseCSV reads
+type csvOrderWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+	rows          int
+}
+
+func (w *csvOrderWriter) Write(order *models.Order) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvColumns); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+		w.headerWritten = true
+	}
+
+	record := make([]string, len(csvColumns))
+	for _, row := range models.NewOrderCSVRows(order) {
+		value := reflect.ValueOf(row)
+		for i, column := range csvColumns {
+			record[i] = formatField(value.Field(csvFields[column]))
+		}
+		if err := w.writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write order %s: %w", order.ID.Hex(), err)
+		}
+
+		w.rows++
+		if w.rows%csvFlushEvery == 0 {
+			w.writer.Flush()
+			if err := w.writer.Error(); err != nil {
+				return fmt.Errorf("failed to flush rows: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *csvOrderWriter) Close() error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvColumns); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// ndjsonOrderWriter writes each order as one JSON document per line
+type ndjsonOrderWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonOrderWriter) Write(order *models.Order) error {
+	if err := w.encoder.Encode(order); err != nil {
+		return fmt.Errorf("failed to write order %s: %w", order.ID.Hex(), err)
+	}
+	return nil
+}
+
+func (w *ndjsonOrderWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"oms-service-goc/internals/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func exportTestOrders() []*models.Order {
+	return []*models.Order{
+		{
+			ID:       bson.NewObjectID(),
+			TenantID: "tenant1",
+			SellerID: "seller1",
+			HubID:    "hub1",
+			Items: []models.OrderItem{
+				{SKUCode: "SKU001", Quantity: 2, UnitPrice: decimal.RequireFromString("12.5"), TaxRate: decimal.RequireFromString("5")},
+				{SKUCode: "SKU002", Quantity: 1, UnitPrice: decimal.RequireFromString("3"), Discount: decimal.RequireFromString("1")},
+			},
+			Currency:        "USD",
+			ShippingFee:     decimal.RequireFromString("4.99"),
+			Customer:        &models.Customer{Name: "Jane, Doe", Email: "jane@example.com"},
+			ShippingAddress: &models.Address{Line1: "1 Main St", City: "Springfield", PostalCode: "94105", Country: "US"},
+		},
+		{
+			ID:       bson.NewObjectID(),
+			TenantID: "tenant1",
+			SellerID: "seller1",
+			HubID:    "hub2",
+			OrderRef: "REF-2",
+			Items:    []models.OrderItem{{SKUCode: "SKU003", Quantity: 5}},
+		},
+	}
+}
+
+func TestCSVOrderWriter_RoundTripsThroughImport(t *testing.T) {
+	orders := exportTestOrders()
+
+	var buf bytes.Buffer
+	writer, err := NewOrderWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+	for _, order := range orders {
+		require.NoError(t, writer.Write(order))
+	}
+	require.NoError(t, writer.Close())
+
+	rows, rowErrors, err := ParseCSV(&buf)
+	require.NoError(t, err)
+	require.Empty(t, rowErrors)
+	require.Len(t, rows, 3)
+
+	groups := GroupOrders(rows)
+	require.Len(t, groups, 2)
+	assert.Equal(t, orders[0].ID.Hex(), groups[0].Order.OrderRef)
+	assert.Equal(t, orders[0].Items, groups[0].Order.Items)
+	assert.Equal(t, orders[0].Customer, groups[0].Order.Customer)
+	assert.Equal(t, orders[0].ShippingAddress, groups[0].Order.ShippingAddress)
+	assert.Equal(t, "USD", groups[0].Order.Currency)
+	assert.Equal(t, orders[0].ShippingFee, groups[0].Order.ShippingFee)
+	assert.Equal(t, "REF-2", groups[1].Order.OrderRef)
+	assert.Equal(t, "hub2", groups[1].Order.HubID)
+}
+
+func TestCSVOrderWriter_EmptyExportHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewOrderWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.True(t, strings.HasPrefix(buf.String(), "tenant_id,seller_id,hub_id,order_ref,sku_code,quantity,"))
+}
+
+func TestNDJSONOrderWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewOrderWriter(FormatNDJSON, &buf)
+	require.NoError(t, err)
+	for _, order := range exportTestOrders() {
+		require.NoError(t, writer.Write(order))
+	}
+	require.NoError(t, writer.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var decoded models.Order
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	assert.Equal(t, "REF-2", decoded.OrderRef)
+}
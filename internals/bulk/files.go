@@ -0,0 +1,65 @@
+package bulk
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"oms-service-goc/internals/storage"
+	"strconv"
+)
+
+// ReadFile parses the bulk order file at uri, which may be any location store can open.
+// Without a format in opts it is detected from the extension of uri and the content.
+func ReadFile(ctx context.Context, store storage.FileStore, uri string, opts ReadOptions) ([]ParsedRow, []RowError, error) {
+	file, err := store.Open(ctx, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return Parse(file, fileName(uri), opts)
+}
+
+// ScanFile streams the bulk order file at uri through fn like Scan
+func ScanFile(ctx context.Context, store storage.FileStore, uri string, opts ReadOptions, fn func(row ParsedRow) error) ([]RowError, error) {
+	file, err := store.Open(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Scan(file, fileName(uri), opts, fn)
+}
+
+// fileName is the path of uri, used to detect the format from its extension
+func fileName(uri string) string {
+	if parsed, err := storage.ParseLocation(uri); err == nil {
+		return parsed.Path
+	}
+	return uri
+}
+
+// WriteErrorReport writes the rejected rows to uri as a CSV with line, column and message
+// columns, so sellers can fix the file and upload it again
+func WriteErrorReport(ctx context.Context, store storage.FileStore, uri string, rowErrors []RowError) error {
+	file, err := store.Create(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"line", "column", "message"}); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write error report: %w", err)
+	}
+	for _, rowError := range rowErrors {
+		if err := writer.Write([]string{strconv.Itoa(rowError.Line), rowError.Column, rowError.Message}); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write error report: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write error report: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,44 @@
+package bulk
+
+import (
+	"context"
+	"io"
+	"oms-service-goc/internals/storage"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileAndWriteErrorReport(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+
+	upload := storage.LocalLocation(filepath.Join(root, "orders.csv"))
+	writer, err := store.Create(ctx, upload)
+	require.NoError(t, err)
+	_, err = io.WriteString(writer, "tenant_id,seller_id,sku_code,quantity\ntenant1,seller1,SKU001,two\ntenant1,seller1,SKU002,1\n")
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	rows, rowErrors, err := ReadFile(ctx, store, upload, ReadOptions{})
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+	require.Len(t, rowErrors, 1)
+
+	report := storage.LocalLocation(filepath.Join(root, "reports", "orders-errors.csv"))
+	require.NoError(t, WriteErrorReport(ctx, store, report, rowErrors))
+
+	reader, err := store.Open(ctx, report)
+	require.NoError(t, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "line,column,message\n2,quantity,\"\"\"two\"\" is not a whole number\"\n", string(content))
+
+	_, _, err = ReadFile(ctx, store, storage.LocalLocation(filepath.Join(root, "missing.csv")), ReadOptions{})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
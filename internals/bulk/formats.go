@@ -0,0 +1,342 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// InputFormat is a file format bulk orders can be read from. Every format maps onto the
+// csv tags of models.OrderCSVRow: spreadsheet headers and JSON keys use the same names.
+type InputFormat string
+
+const (
+	InputCSV    InputFormat = "csv"
+	InputXLSX   InputFormat = "xlsx"
+	InputJSON   InputFormat = "json"   // an array of objects
+	InputNDJSON InputFormat = "ndjson" // one object per line
+)
+
+func ParseInputFormat(value string) (InputFormat, error) {
+	switch format := InputFormat(strings.ToLower(value)); format {
+	case InputCSV, InputXLSX, InputJSON, InputNDJSON, "":
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown bulk file format %q, use csv, xlsx, json or ndjson", value)
+	}
+}
+
+func (f InputFormat) Extension() string {
+	return "." + string(f)
+}
+
+// FormatForExtension returns the format a file name's extension implies, or "" when it implies none
+func FormatForExtension(name string) InputFormat {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return InputCSV
+	case ".xlsx":
+		return InputXLSX
+	case ".json":
+		return InputJSON
+	case ".ndjson", ".jsonl":
+		return InputNDJSON
+	default:
+		return ""
+	}
+}
+
+// DetectFormat picks the format from the file name's extension, falling back to the first
+// bytes of the file: a zip header is a workbook, [ a JSON array, { NDJSON, anything else CSV
+func DetectFormat(name string, head []byte) InputFormat {
+	if format := FormatForExtension(name); format != "" {
+		return format
+	}
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return InputXLSX
+	}
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\ufeff")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return InputJSON
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return InputNDJSON
+	default:
+		return InputCSV
+	}
+}
+
+// ReadOptions controls how a bulk file is read. The zero value detects the format, reads
+// the first sheet of a workbook, reads every row and expects the standard column names.
+type ReadOptions struct {
+	Format  InputFormat
+	Sheet   string
+	Mapping *Mapping
+	Limit   int // stop after this many rows, 0 reads them all
+}
+
+// sniffBytes is how much of a file DetectFormat is shown
+const sniffBytes = 512
+
+// Parse decodes a bulk order file in any InputFormat. name is only used to detect the
+// format when opts.Format is empty. Errors are reported as in ParseCSV.
+func Parse(r io.Reader, name string, opts ReadOptions) ([]ParsedRow, []RowError, error) {
+	var rows []ParsedRow
+	rowErrors, err := Scan(r, name, opts, func(row ParsedRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, rowErrors, nil
+}
+
+// Scan is Parse for files too large to hold in memory: each good row is handed to fn as it
+// is read, and the file is only read as fast as fn returns. Scanning stops at the first
+// error fn returns, which is returned as is.
+func Scan(r io.Reader, name string, opts ReadOptions, fn func(row ParsedRow) error) ([]RowError, error) {
+	buffered := bufio.NewReader(r)
+	format := opts.Format
+	if format == "" {
+		head, _ := buffered.Peek(sniffBytes)
+		format = DetectFormat(name, head)
+	}
+
+	switch format {
+	case InputCSV:
+		return scan(newCSVRecords(buffered), opts.Mapping, opts.Limit, fn)
+	case InputXLSX:
+		records, err := newXLSXRecords(buffered, opts.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		defer records.Close()
+		return scan(records, opts.Mapping, opts.Limit, fn)
+	case InputJSON:
+		return scan(newJSONRecords(buffered, opts.Mapping), opts.Mapping, opts.Limit, fn)
+	case InputNDJSON:
+		return scan(newNDJSONRecords(buffered, opts.Mapping), opts.Mapping, opts.Limit, fn)
+	default:
+		return nil, fmt.Errorf("unknown bulk file format %q", format)
+	}
+}
+
+// xlsxRecords reads one sheet of a workbook; the line of a row is its row number in the sheet
+type xlsxRecords struct {
+	file *excelize.File
+	rows *excelize.Rows
+	line int
+}
+
+func newXLSXRecords(r io.Reader, sheet string) (*xlsxRecords, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+
+	if sheet == "" {
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			file.Close()
+			return nil, fmt.Errorf("workbook has no sheets")
+		}
+		sheet = sheets[0]
+	}
+	rows, err := file.Rows(sheet)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("sheet %q not found in workbook", sheet)
+	}
+	return &xlsxRecords{file: file, rows: rows}, nil
+}
+
+func (x *xlsxRecords) Header() ([]string, error) {
+	_, header, err := x.Next()
+	return header, err
+}
+
+func (x *xlsxRecords) Next() (int, []string, error) {
+	for x.rows.Next() {
+		x.line++
+		// Raw values keep numbers such as quantities free of the cell's display format
+		record, err := x.rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return x.line, nil, RowError{Line: x.line, Message: err.Error()}
+		}
+		// Formatted but empty rows are common at the end of a sheet
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		return x.line, record, nil
+	}
+	if err := x.rows.Error(); err != nil {
+		return 0, nil, fmt.Errorf("failed to read sheet: %w", err)
+	}
+	return 0, nil, io.EOF
+}
+
+func (x *xlsxRecords) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
+
+// objectRecord lays a JSON object out in csvColumns order. Keys are matched and mapped
+// like CSV headers.
+func objectRecord(line int, object map[string]any, mapping *Mapping) ([]string, error) {
+	values := make(map[string]string, len(object))
+	for key, value := range object {
+		key = mapping.Column(normalizeColumn(key))
+		switch v := value.(type) {
+		case nil:
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			if _, known := csvFields[key]; known {
+				return nil, RowError{Line: line, Column: key, Message: "value must be a string or number"}
+			}
+		}
+	}
+
+	record := make([]string, len(csvColumns))
+	for i, column := range csvColumns {
+		record[i] = values[column]
+	}
+	return record, nil
+}
+
+// jsonRecords reads an array of objects. The line of a row is the line its object starts on.
+type jsonRecords struct {
+	decoder  *json.Decoder
+	newlines *newlineIndex
+	mapping  *Mapping
+}
+
+func newJSONRecords(r io.Reader, mapping *Mapping) *jsonRecords {
+	newlines := &newlineIndex{reader: r}
+	decoder := json.NewDecoder(newlines)
+	decoder.UseNumber()
+	return &jsonRecords{decoder: decoder, newlines: newlines, mapping: mapping}
+}
+
+// Header consumes the opening bracket; each object names its own columns
+func (j *jsonRecords) Header() ([]string, error) {
+	token, err := j.decoder.Token()
+	if errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("JSON bulk files must be an array of objects")
+	}
+	return nil, nil
+}
+
+func (j *jsonRecords) Next() (int, []string, error) {
+	if !j.decoder.More() {
+		return 0, nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := j.decoder.Decode(&raw); err != nil {
+		return 0, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	line := j.newlines.line(j.decoder.InputOffset() - int64(len(raw)))
+
+	var object map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return line, nil, RowError{Line: line, Message: "element is not a JSON object"}
+	}
+	record, err := objectRecord(line, object, j.mapping)
+	return line, record, err
+}
+
+// newlineIndex remembers where the newlines are in what has been read through it
+type newlineIndex struct {
+	reader  io.Reader
+	offset  int64
+	offsets []int64
+}
+
+func (n *newlineIndex) Read(p []byte) (int, error) {
+	read, err := n.reader.Read(p)
+	for i, b := range p[:read] {
+		if b == '\n' {
+			n.offsets = append(n.offsets, n.offset+int64(i))
+		}
+	}
+	n.offset += int64(read)
+	return read, err
+}
+
+// line returns the 1-based line of a byte offset that has already been read
+func (n *newlineIndex) line(offset int64) int {
+	return 1 + sort.Search(len(n.offsets), func(i int) bool { return n.offsets[i] >= offset })
+}
+
+// ndjsonRecords reads one object per line; blank lines are skipped and a malformed line
+// only rejects that row
+type ndjsonRecords struct {
+	reader  *bufio.Reader
+	mapping *Mapping
+	line    int
+}
+
+func newNDJSONRecords(r io.Reader, mapping *Mapping) *ndjsonRecords {
+	return &ndjsonRecords{reader: bufio.NewReader(r), mapping: mapping}
+}
+
+// Header only checks the file is not empty; each line names its own columns
+func (n *ndjsonRecords) Header() ([]string, error) {
+	if _, err := n.reader.Peek(1); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (n *ndjsonRecords) Next() (int, []string, error) {
+	for {
+		data, err := n.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, nil, io.EOF
+			}
+			return 0, nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		n.line++
+
+		data = bytes.TrimSpace(data)
+		if n.line == 1 {
+			data = bytes.TrimPrefix(data, []byte("\ufeff"))
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		var object map[string]any
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&object); err != nil || object == nil {
+			return n.line, nil, RowError{Line: n.line, Message: "line is not a JSON object"}
+		}
+		record, err := objectRecord(n.line, object, n.mapping)
+		return n.line, record, err
+	}
+}
@@ -0,0 +1,128 @@
+package bulk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, InputCSV, DetectFormat("orders.CSV", []byte("[")))
+	assert.Equal(t, InputXLSX, DetectFormat("orders.xlsx", nil))
+	assert.Equal(t, InputNDJSON, DetectFormat("orders.jsonl", nil))
+	assert.Equal(t, InputXLSX, DetectFormat("upload", []byte("PK\x03\x04rest")))
+	assert.Equal(t, InputJSON, DetectFormat("upload", []byte("\n  [{\"tenant_id\":")))
+	assert.Equal(t, InputNDJSON, DetectFormat("upload", []byte("{\"tenant_id\":")))
+	assert.Equal(t, InputCSV, DetectFormat("upload", []byte("tenant_id,seller_id")))
+}
+
+func workbook(t *testing.T, sheet string, rows [][]any) []byte {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	if sheet != "Sheet1" {
+		_, err := file.NewSheet(sheet)
+		require.NoError(t, err)
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		require.NoError(t, err)
+		require.NoError(t, file.SetSheetRow(sheet, cell, &row))
+	}
+	buffer, err := file.WriteToBuffer()
+	require.NoError(t, err)
+	return buffer.Bytes()
+}
+
+func TestParse_XLSX(t *testing.T) {
+	data := workbook(t, "Sheet1", [][]any{
+		{"Tenant_ID", "seller_id", "sku_code", "quantity", "shipping_postal_code"},
+		{"tenant1", "seller1", "SKU001", 2, 12345},
+		{},
+		{"tenant1", "seller1", "SKU002", "two"},
+	})
+
+	rows, rowErrors, err := Parse(bytes.NewReader(data), "orders.xlsx", ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, 2, rows[0].Row.Quantity)
+	assert.Equal(t, "12345", rows[0].Row.ShippingPostalCode)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 4, rowErrors[0].Line)
+	assert.Equal(t, "quantity", rowErrors[0].Column)
+}
+
+func TestParse_XLSXNamedSheet(t *testing.T) {
+	data := workbook(t, "Orders", [][]any{
+		{"tenant_id", "seller_id", "sku_code", "quantity"},
+		{"tenant1", "seller1", "SKU001", 1},
+	})
+
+	// The default sheet is empty, so the named sheet has to be picked
+	_, _, err := Parse(bytes.NewReader(data), "upload", ReadOptions{})
+	assert.Error(t, err)
+
+	rows, rowErrors, err := Parse(bytes.NewReader(data), "upload", ReadOptions{Sheet: "Orders"})
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	assert.Len(t, rows, 1)
+
+	_, _, err = Parse(bytes.NewReader(data), "upload", ReadOptions{Sheet: "Missing"})
+	assert.ErrorContains(t, err, "Missing")
+}
+
+func TestParse_JSON(t *testing.T) {
+	file := `[
+  {"tenant_id": "tenant1", "seller_id": "seller1", "sku_code": "SKU001", "quantity": 2, "unknown": {"a": 1}},
+  {"tenant_id": "tenant1", "seller_id": "seller1", "sku_code": "SKU002"},
+  "not an object",
+  {
+    "tenant_id": "tenant1", "seller_id": "seller1", "sku_code": "SKU003", "quantity": "3",
+    "customer_email": {"nested": true}
+  }
+]`
+
+	rows, rowErrors, err := Parse(strings.NewReader(file), "orders.json", ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, 2, rows[0].Row.Quantity)
+
+	require.Len(t, rowErrors, 3)
+	assert.Equal(t, RowError{Line: 3, Column: "quantity", Message: "value is required"}, rowErrors[0])
+	assert.Equal(t, 4, rowErrors[1].Line)
+	assert.Equal(t, RowError{Line: 5, Column: "customer_email", Message: "value must be a string or number"}, rowErrors[2])
+
+	_, _, err = Parse(strings.NewReader(`{"tenant_id": "tenant1"}`), "orders.json", ReadOptions{})
+	assert.Error(t, err)
+
+	priced := `[{"tenant_id": "tenant1", "seller_id": "seller1", "sku_code": "SKU001", "quantity": 1, "unit_price": 12.5, "currency": "EUR"}]`
+	rows, rowErrors, err = Parse(strings.NewReader(priced), "orders.json", ReadOptions{})
+	require.NoError(t, err)
+	require.Empty(t, rowErrors)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "12.5", rows[0].Row.UnitPrice.String())
+	assert.Equal(t, "EUR", rows[0].Row.Currency)
+}
+
+func TestParse_NDJSON(t *testing.T) {
+	file := "{\"tenant_id\": \"tenant1\", \"seller_id\": \"seller1\", \"sku_code\": \"SKU001\", \"quantity\": 1}\n" +
+		"\n" +
+		"{broken\n" +
+		"{\"tenant_id\": \"tenant1\", \"seller_id\": \"seller1\", \"sku_code\": \"SKU002\", \"quantity\": 4}"
+
+	rows, rowErrors, err := Parse(strings.NewReader(file), "upload", ReadOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Equal(t, []RowError{{Line: 3, Message: "line is not a JSON object"}}, rowErrors)
+
+	rows, _, err = Parse(strings.NewReader(file), "upload", ReadOptions{Format: InputNDJSON, Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+}
@@ -0,0 +1,133 @@
+package bulk
+
+import (
+	"fmt"
+	"oms-service-goc/internals/models"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Mapping adapts a seller's own file layout: Columns renames the file's headers (or JSON
+// keys) to the csv tags of models.OrderCSVRow, and Defaults fills columns a row leaves empty
+// with a constant, such as a fixed hub_id. A nil Mapping reads files as they are.
+type Mapping struct {
+	columns  map[string]string
+	defaults map[string]string
+}
+
+// NewMapping checks that every target is a known column and every default decodes
+func NewMapping(columns, defaults map[string]string) (*Mapping, error) {
+	var problems []string
+	mapping := &Mapping{columns: map[string]string{}, defaults: map[string]string{}}
+
+	for header, column := range columns {
+		column = normalizeColumn(column)
+		if _, ok := csvFields[column]; !ok {
+			problems = append(problems, fmt.Sprintf("%q maps to unknown column %q", header, column))
+			continue
+		}
+		mapping.columns[normalizeColumn(header)] = column
+	}
+	for column, value := range defaults {
+		column = normalizeColumn(column)
+		if _, ok := csvFields[column]; !ok {
+			problems = append(problems, fmt.Sprintf("default for unknown column %q", column))
+			continue
+		}
+		var row models.OrderCSVRow
+		if err := setField(reflect.ValueOf(&row).Elem().Field(csvFields[column]), strings.TrimSpace(value)); err != nil {
+			problems = append(problems, fmt.Sprintf("default for %s: %v", column, err))
+			continue
+		}
+		mapping.defaults[column] = strings.TrimSpace(value)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("invalid column mapping: %s", strings.Join(problems, "; "))
+	}
+	return mapping, nil
+}
+
+// Column returns the column a normalized header is read into
+func (m *Mapping) Column(header string) string {
+	if m != nil {
+		if column, ok := m.columns[header]; ok {
+			return column
+		}
+	}
+	return header
+}
+
+// Default returns the constant rows missing the column get, or "" when there is none
+func (m *Mapping) Default(column string) string {
+	if m == nil {
+		return ""
+	}
+	return m.defaults[column]
+}
+
+// hasDefault reports whether rows missing the column get a constant value
+func (m *Mapping) hasDefault(column string) bool {
+	if m == nil {
+		return false
+	}
+	_, ok := m.defaults[column]
+	return ok
+}
+
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// setField stores a trimmed cell in a string, int or decimal field of models.OrderCSVRow
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == decimalType {
+		if raw == "" {
+			return nil
+		}
+		d, err := decimal.NewFromString(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		field.Set(reflect.ValueOf(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		if raw == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+		field.SetInt(int64(n))
+	}
+	return nil
+}
+
+// formatField renders a field of models.OrderCSVRow the way setField reads it back. Zero
+// amounts are left empty so unpriced orders export without pricing columns filled.
+func formatField(field reflect.Value) string {
+	switch {
+	case field.Type() == decimalType:
+		if d := field.Interface().(decimal.Decimal); !d.IsZero() {
+			return d.String()
+		}
+		return ""
+	case field.Kind() == reflect.Int:
+		return strconv.FormatInt(field.Int(), 10)
+	default:
+		return field.String()
+	}
+}
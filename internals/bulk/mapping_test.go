@@ -0,0 +1,51 @@
+package bulk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapping_RenamesHeadersAndFillsDefaults(t *testing.T) {
+	mapping, err := NewMapping(
+		map[string]string{"SKU": "sku_code", "Qty": "quantity", "Merchant": "seller_id"},
+		map[string]string{"tenant_id": "tenant1", "hub_id": "hub9"},
+	)
+	require.NoError(t, err)
+
+	file := "sku,qty,merchant,hub_id\n" +
+		"SKU001,2,seller1,\n" +
+		"SKU002,1,seller1,hub1\n"
+
+	rows, rowErrors, err := Parse(strings.NewReader(file), "orders.csv", ReadOptions{Mapping: mapping})
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "tenant1", rows[0].Row.TenantID)
+	assert.Equal(t, "SKU001", rows[0].Row.SKUCode)
+	assert.Equal(t, 2, rows[0].Row.Quantity)
+	assert.Equal(t, "hub9", rows[0].Row.HubID)
+	// A value in the file wins over the default
+	assert.Equal(t, "hub1", rows[1].Row.HubID)
+
+	rows, rowErrors, err = Parse(strings.NewReader(`[{"SKU": "SKU003", "Qty": 4, "Merchant": "seller1"}]`), "orders.json", ReadOptions{Mapping: mapping})
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "SKU003", rows[0].Row.SKUCode)
+	assert.Equal(t, "tenant1", rows[0].Row.TenantID)
+
+	// Without the mapping the same file is missing its required columns
+	_, _, err = Parse(strings.NewReader(file), "orders.csv", ReadOptions{})
+	assert.ErrorContains(t, err, "missing required columns")
+}
+
+func TestNewMapping_Invalid(t *testing.T) {
+	_, err := NewMapping(map[string]string{"SKU": "sku"}, map[string]string{"quantity": "many", "warehouse": "w1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"SKU" maps to unknown column "sku"`)
+	assert.Contains(t, err.Error(), "default for quantity")
+	assert.Contains(t, err.Error(), `default for unknown column "warehouse"`)
+}
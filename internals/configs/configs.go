@@ -1,65 +1,525 @@
 package configs
 
 import (
+	"flag"
+	"fmt"
+	"oms-service-goc/internals/storage"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/omniful/go_commons/db/nosql/mongodm"
 	"github.com/omniful/go_commons/sqs"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server  ServerConfig   `json:"server"`
-	MongoDB mongodm.Config `json:"mongodb"`
-	SQS     *sqs.Config    `json:"sqs"`
+	Server  ServerConfig  `json:"server" yaml:"server"`
+	MongoDB MongoConfig   `json:"mongodb" yaml:"mongodb"`
+	SQS     *sqs.Config   `json:"sqs" yaml:"sqs"`
+	Queue   QueueConfig   `json:"queue" yaml:"queue"`
+	Jobs    JobsConfig    `json:"jobs" yaml:"jobs"`
+	SLA     SLAConfig     `json:"sla" yaml:"sla"`
+	Export  ExportConfig  `json:"export" yaml:"export"`
+	Storage StorageConfig `json:"storage" yaml:"storage"`
+	Bulk    BulkConfig    `json:"bulk" yaml:"bulk"`
+
+	Allocation AllocationConfig `json:"allocation" yaml:"allocation"`
+}
+
+// BulkConfig limits direct bulk file uploads and says where the importer writes error reports
+type BulkConfig struct {
+	UploadLocation string `json:"upload_location" yaml:"upload_location"` // URI uploads are stored under, defaults to uploads in storage.local_root
+	ReportLocation string `json:"report_location" yaml:"report_location"` // URI error reports are written under, defaults to reports in storage.local_root
+	MaxUploadBytes uint64 `json:"max_upload_bytes" yaml:"max_upload_bytes"`
+	PreviewRows    uint64 `json:"preview_rows" yaml:"preview_rows"` // rows checked before an upload is accepted
+	BatchSize      uint64 `json:"batch_size" yaml:"batch_size"`     // orders the importer inserts per write
+	Concurrency    uint64 `json:"concurrency" yaml:"concurrency"`   // batches the importer writes at once per job
+	Workers        uint64 `json:"workers" yaml:"workers"`           // jobs imported at once, each from a different seller
+
+	SellerConcurrency   uint64 `json:"seller_concurrency" yaml:"seller_concurrency"`         // batches written at once per seller across their jobs
+	SellerRowsPerMinute uint64 `json:"seller_rows_per_minute" yaml:"seller_rows_per_minute"` // rows imported per minute per seller, 0 for no limit
+}
+
+type ExportConfig struct {
+	Location string `json:"location" yaml:"location"` // URI background exports are written under, defaults to exports in storage.local_root
+}
+
+// StorageConfig sets up the file stores behind file://, s3:// and https:// locations
+type StorageConfig struct {
+	LocalRoot  string `json:"local_root" yaml:"local_root"`   // file:// locations must be inside this directory
+	S3Region   string `json:"s3_region" yaml:"s3_region"`     // defaults to sqs.region
+	S3Endpoint string `json:"s3_endpoint" yaml:"s3_endpoint"` // set to use a local stand-in such as LocalStack
+
+	HTTPAllowedHosts []string      `json:"http_allowed_hosts" yaml:"http_allowed_hosts"` // hosts https:// locations may point at, "*.example.com" for subdomains
+	HTTPTimeout      time.Duration `json:"http_timeout" yaml:"http_timeout"`             // longest an https:// download may take
 }
 
 type ServerConfig struct {
-	Port string `json:"port"`
+	Port string `json:"port" yaml:"port"`
+}
+
+type MongoConfig struct {
+	Database        string        `json:"database" yaml:"database"`
+	URI             string        `json:"uri" yaml:"uri"`
+	DefaultTimeout  time.Duration `json:"default_timeout" yaml:"default_timeout"`
+	MaxPoolSize     uint64        `json:"max_pool_size" yaml:"max_pool_size"`
+	MinPoolSize     uint64        `json:"min_pool_size" yaml:"min_pool_size"`
+	MaxConnIdleTime time.Duration `json:"max_conn_idle_time" yaml:"max_conn_idle_time"`
+}
+
+// Mongodm converts the settings into the go_commons connection config
+func (m MongoConfig) Mongodm() mongodm.Config {
+	return mongodm.Config{
+		Database:        m.Database,
+		URI:             m.URI,
+		ReadPreference:  mongodm.ReadPrefPrimary,
+		DefaultTimeout:  m.DefaultTimeout,
+		MaxPoolSize:     m.MaxPoolSize,
+		MinPoolSize:     m.MinPoolSize,
+		MaxConnIdleTime: m.MaxConnIdleTime,
+	}
+}
+
+// PublisherMode selects which SQS publisher implementation is wired at startup
+type PublisherMode string
+
+const (
+	PublisherMock       PublisherMode = "mock"
+	PublisherLocalStack PublisherMode = "localstack"
+	PublisherAWS        PublisherMode = "aws"
+)
+
+type QueueConfig struct {
+	Publisher        PublisherMode `json:"publisher" yaml:"publisher"`
+	BulkOrderQueue   string        `json:"bulk_order_queue" yaml:"bulk_order_queue"`
+	OrderEventsQueue string        `json:"order_events_queue" yaml:"order_events_queue"`
+	MaxReceives      uint64        `json:"max_receives" yaml:"max_receives"` // receives before a failing message is dead-lettered
+	RetryDelay       time.Duration `json:"retry_delay" yaml:"retry_delay"`   // wait before a failed message is received again
+}
+
+// JobsConfig schedules the background jobs; a zero interval disables a job
+type JobsConfig struct {
+	HoldReleaseInterval time.Duration `json:"hold_release_interval" yaml:"hold_release_interval"`
+	SLACheckInterval    time.Duration `json:"sla_check_interval" yaml:"sla_check_interval"`
+}
+
+// AllocationConfig lists the hubs that bulk orders without a hub_id are allocated to. With no
+// hubs listed such orders are rejected.
+type AllocationConfig struct {
+	Hubs             []HubConfig         `json:"hubs" yaml:"hubs"`
+	SellerPriorities map[string][]string `json:"seller_priorities" yaml:"seller_priorities"` // seller ID -> hub IDs, most preferred first
+	ZoneLength       uint64              `json:"zone_length" yaml:"zone_length"`             // leading postal code characters that make up a zone
+}
+
+// HubConfig is a hub orders can be allocated to
+type HubConfig struct {
+	ID      string `json:"id" yaml:"id"`
+	Country string `json:"country" yaml:"country"` // ISO 3166-1 alpha-2
+	Zone    string `json:"zone" yaml:"zone"`       // the postal code prefix the hub serves
+}
+
+// SLAThresholds is how long an order may stay in each open status
+type SLAThresholds struct {
+	OnHold   time.Duration `json:"on_hold" yaml:"on_hold"`
+	NewOrder time.Duration `json:"new_order" yaml:"new_order"`
+}
+
+// SLAConfig holds the default thresholds and per-tenant overrides. Tenants only need to
+// list the thresholds they change.
+type SLAConfig struct {
+	Default SLAThresholds            `json:"default" yaml:"default"`
+	Tenants map[string]SLAThresholds `json:"tenants" yaml:"tenants"`
+}
+
+// Thresholds returns the tenant's thresholds with unset values taken from the default
+func (c SLAConfig) Thresholds(tenantID string) SLAThresholds {
+	thresholds := c.Default
+	if override, ok := c.Tenants[tenantID]; ok {
+		if override.OnHold > 0 {
+			thresholds.OnHold = override.OnHold
+		}
+		if override.NewOrder > 0 {
+			thresholds.NewOrder = override.NewOrder
+		}
+	}
+	return thresholds
+}
+
+// ValidationError lists every problem found in the configuration so they can all be fixed in one go
+type ValidationError struct {
+	Problems []string
 }
 
-func LoadConfig() *Config {
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// LoadConfig builds the configuration in layers, each overriding the previous one:
+// environment defaults, config file (-config flag or CONFIG_FILE, YAML or JSON),
+// environment variables and finally command line flags. The result is validated
+// and every missing or invalid setting is reported together.
+func LoadConfig(args []string) (*Config, error) {
 	env := os.Getenv("ENVIRONMENT")
 	if env == "" {
 		env = "local"
 	}
+	cfg := defaultConfig(env)
 
-	if env == "local" {
-		return &Config{
-			Server: ServerConfig{
-				Port: ":8080",
-			},
-			MongoDB: mongodm.Config{
-				Database: "oms_db",
-				URI:      "mongodb://localhost:27017",
-			},
-			SQS: &sqs.Config{
-				Account:  "000000000000",
-				Region:   "us-east-1",
-				Endpoint: "http://localhost:4566", // LocalStack endpoint
-			},
+	var problems []string
+
+	flags := flag.NewFlagSet("oms-service", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or JSON config file")
+	port := flags.String("port", "", "server listen address, e.g. :8080")
+	mongoURI := flags.String("mongodb-uri", "", "MongoDB connection string")
+	mongoDatabase := flags.String("mongodb-database", "", "MongoDB database name")
+	publisher := flags.String("sqs-publisher", "", "SQS publisher: mock, localstack or aws")
+	bulkOrderQueue := flags.String("bulk-order-queue", "", "FIFO queue for bulk order events")
+	orderEventsQueue := flags.String("order-events-queue", "", "FIFO queue for order domain events")
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, cfg); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	problems = append(problems, applyEnv(cfg)...)
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Server.Port = *port
+		case "mongodb-uri":
+			cfg.MongoDB.URI = *mongoURI
+		case "mongodb-database":
+			cfg.MongoDB.Database = *mongoDatabase
+		case "sqs-publisher":
+			cfg.Queue.Publisher = PublisherMode(*publisher)
+		case "bulk-order-queue":
+			cfg.Queue.BulkOrderQueue = *bulkOrderQueue
+		case "order-events-queue":
+			cfg.Queue.OrderEventsQueue = *orderEventsQueue
+		}
+	})
+
+	// Exports, uploads and error reports default to directories inside whichever local root was configured
+	if cfg.Storage.LocalRoot != "" {
+		if root, err := filepath.Abs(cfg.Storage.LocalRoot); err == nil {
+			if cfg.Export.Location == "" {
+				cfg.Export.Location = storage.Join(storage.LocalLocation(root), "exports")
+			}
+			if cfg.Bulk.UploadLocation == "" {
+				cfg.Bulk.UploadLocation = storage.Join(storage.LocalLocation(root), "uploads")
+			}
+			if cfg.Bulk.ReportLocation == "" {
+				cfg.Bulk.ReportLocation = storage.Join(storage.LocalLocation(root), "reports")
+			}
 		}
 	}
 
-	// Production: use environment variables
-	return &Config{
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+func defaultConfig(env string) *Config {
+	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", ":8080"),
+			Port: ":8080",
+		},
+		MongoDB: MongoConfig{
+			Database:        "oms_db",
+			DefaultTimeout:  10 * time.Second,
+			MaxPoolSize:     50,
+			MinPoolSize:     5,
+			MaxConnIdleTime: 5 * time.Minute,
+		},
+		SQS: &sqs.Config{},
+		Queue: QueueConfig{
+			Publisher:        PublisherAWS,
+			BulkOrderQueue:   "bulk-orders.fifo",
+			OrderEventsQueue: "order-events.fifo",
+			MaxReceives:      3,
+			RetryDelay:       10 * time.Second,
 		},
-		MongoDB: mongodm.Config{
-			Database: getEnv("MONGODB_DATABASE", "oms_db"),
-			URI:      os.Getenv("MONGODB_URI"),
+		Jobs: JobsConfig{
+			HoldReleaseInterval: time.Minute,
+			SLACheckInterval:    5 * time.Minute,
 		},
-		SQS: &sqs.Config{
-			Account:  os.Getenv("SQS_ACCOUNT"),
-			Region:   os.Getenv("SQS_REGION"),
-			Endpoint: os.Getenv("SQS_ENDPOINT"),
+		SLA: SLAConfig{
+			Default: SLAThresholds{
+				OnHold:   24 * time.Hour,
+				NewOrder: 24 * time.Hour,
+			},
+		},
+		Storage: StorageConfig{
+			LocalRoot:   filepath.Join(os.TempDir(), "oms"),
+			HTTPTimeout: 5 * time.Minute,
+		},
+		Bulk: BulkConfig{
+			MaxUploadBytes: 10 << 20,
+			PreviewRows:    100,
+			BatchSize:      500,
+			Concurrency:    4,
+			Workers:        4,
+
+			SellerConcurrency:   2,
+			SellerRowsPerMinute: 60000,
 		},
+		Allocation: AllocationConfig{
+			ZoneLength: 3,
+		},
+	}
+
+	if env == "local" {
+		cfg.MongoDB.URI = "mongodb://localhost:27017"
+		cfg.SQS = &sqs.Config{
+			Account:  "000000000000",
+			Region:   "us-east-1",
+			Endpoint: "http://localhost:4566", // LocalStack endpoint
+		}
+		cfg.Storage.S3Endpoint = "http://localhost:4566"
+		cfg.Queue.Publisher = PublisherMock
+	}
+
+	return cfg
+}
+
+// loadFile overlays the file onto cfg. YAML is a superset of JSON so one decoder handles both.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	if cfg.SQS == nil {
+		cfg.SQS = &sqs.Config{}
+	}
+	return nil
+}
+
+// applyEnv overlays environment variables onto cfg and reports values that fail to parse
+func applyEnv(cfg *Config) []string {
+	var problems []string
+
+	setString := func(key string, target *string) {
+		if value := os.Getenv(key); value != "" {
+			*target = value
+		}
+	}
+	setUint := func(key string, target *uint64) {
+		if value := os.Getenv(key); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a non-negative integer", key, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	setDuration := func(key string, target *time.Duration) {
+		if value := os.Getenv(key); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a duration (e.g. 10s)", key, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	setString("PORT", &cfg.Server.Port)
+	setString("MONGODB_URI", &cfg.MongoDB.URI)
+	setString("MONGODB_DATABASE", &cfg.MongoDB.Database)
+	setDuration("MONGODB_TIMEOUT", &cfg.MongoDB.DefaultTimeout)
+	setUint("MONGODB_MAX_POOL_SIZE", &cfg.MongoDB.MaxPoolSize)
+	setUint("MONGODB_MIN_POOL_SIZE", &cfg.MongoDB.MinPoolSize)
+	setDuration("MONGODB_MAX_CONN_IDLE_TIME", &cfg.MongoDB.MaxConnIdleTime)
+	setString("SQS_ACCOUNT", &cfg.SQS.Account)
+	setString("SQS_REGION", &cfg.SQS.Region)
+	setString("SQS_ENDPOINT", &cfg.SQS.Endpoint)
+
+	var publisher string
+	setString("SQS_PUBLISHER", &publisher)
+	if publisher != "" {
+		cfg.Queue.Publisher = PublisherMode(publisher)
+	}
+	setString("SQS_BULK_ORDER_QUEUE", &cfg.Queue.BulkOrderQueue)
+	setString("SQS_ORDER_EVENTS_QUEUE", &cfg.Queue.OrderEventsQueue)
+	setUint("SQS_MAX_RECEIVES", &cfg.Queue.MaxReceives)
+	setDuration("SQS_RETRY_DELAY", &cfg.Queue.RetryDelay)
+	setDuration("HOLD_RELEASE_INTERVAL", &cfg.Jobs.HoldReleaseInterval)
+	setDuration("SLA_CHECK_INTERVAL", &cfg.Jobs.SLACheckInterval)
+	setDuration("SLA_ON_HOLD", &cfg.SLA.Default.OnHold)
+	setDuration("SLA_NEW_ORDER", &cfg.SLA.Default.NewOrder)
+	setString("EXPORT_LOCATION", &cfg.Export.Location)
+	setString("STORAGE_LOCAL_ROOT", &cfg.Storage.LocalRoot)
+	setString("STORAGE_S3_REGION", &cfg.Storage.S3Region)
+	setString("STORAGE_S3_ENDPOINT", &cfg.Storage.S3Endpoint)
+	if value := os.Getenv("STORAGE_HTTP_ALLOWED_HOSTS"); value != "" {
+		cfg.Storage.HTTPAllowedHosts = nil
+		for _, host := range strings.Split(value, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				cfg.Storage.HTTPAllowedHosts = append(cfg.Storage.HTTPAllowedHosts, host)
+			}
+		}
+	}
+	setDuration("STORAGE_HTTP_TIMEOUT", &cfg.Storage.HTTPTimeout)
+	setString("BULK_UPLOAD_LOCATION", &cfg.Bulk.UploadLocation)
+	setString("BULK_REPORT_LOCATION", &cfg.Bulk.ReportLocation)
+	setUint("BULK_MAX_UPLOAD_BYTES", &cfg.Bulk.MaxUploadBytes)
+	setUint("BULK_PREVIEW_ROWS", &cfg.Bulk.PreviewRows)
+	setUint("BULK_BATCH_SIZE", &cfg.Bulk.BatchSize)
+	setUint("BULK_CONCURRENCY", &cfg.Bulk.Concurrency)
+	setUint("BULK_WORKERS", &cfg.Bulk.Workers)
+	setUint("BULK_SELLER_CONCURRENCY", &cfg.Bulk.SellerConcurrency)
+	setUint("BULK_SELLER_ROWS_PER_MINUTE", &cfg.Bulk.SellerRowsPerMinute)
+
+	return problems
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+
+	if c.Server.Port == "" {
+		problems = append(problems, "server.port is required")
+	} else if _, err := strconv.Atoi(c.Server.Port[strings.LastIndex(c.Server.Port, ":")+1:]); err != nil {
+		problems = append(problems, fmt.Sprintf("server.port %q must look like :8080", c.Server.Port))
+	}
+
+	if c.MongoDB.URI == "" {
+		problems = append(problems, "mongodb.uri is required (MONGODB_URI)")
+	} else if !strings.HasPrefix(c.MongoDB.URI, "mongodb://") && !strings.HasPrefix(c.MongoDB.URI, "mongodb+srv://") {
+		problems = append(problems, "mongodb.uri must start with mongodb:// or mongodb+srv://")
+	}
+	if c.MongoDB.Database == "" {
+		problems = append(problems, "mongodb.database is required (MONGODB_DATABASE)")
+	}
+	if c.MongoDB.DefaultTimeout <= 0 {
+		problems = append(problems, "mongodb.default_timeout must be positive")
+	}
+	if c.MongoDB.MaxPoolSize == 0 {
+		problems = append(problems, "mongodb.max_pool_size must be greater than zero")
+	}
+	if c.MongoDB.MinPoolSize > c.MongoDB.MaxPoolSize {
+		problems = append(problems, "mongodb.min_pool_size cannot exceed mongodb.max_pool_size")
 	}
+
+	if c.Jobs.HoldReleaseInterval < 0 {
+		problems = append(problems, "jobs.hold_release_interval cannot be negative")
+	}
+	if c.Jobs.SLACheckInterval < 0 {
+		problems = append(problems, "jobs.sla_check_interval cannot be negative")
+	}
+	if c.SLA.Default.OnHold <= 0 || c.SLA.Default.NewOrder <= 0 {
+		problems = append(problems, "sla.default thresholds must be positive (SLA_ON_HOLD, SLA_NEW_ORDER)")
+	}
+	if c.Storage.LocalRoot == "" {
+		problems = append(problems, "storage.local_root is required (STORAGE_LOCAL_ROOT)")
+	}
+	if c.Storage.HTTPTimeout <= 0 {
+		problems = append(problems, "storage.http_timeout must be greater than zero (STORAGE_HTTP_TIMEOUT)")
+	}
+	for _, host := range c.Storage.HTTPAllowedHosts {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			problems = append(problems, fmt.Sprintf("storage.http_allowed_hosts: %q must be a host name such as files.example.com or *.example.com (STORAGE_HTTP_ALLOWED_HOSTS)", host))
+		}
+	}
+	if c.Export.Location == "" {
+		problems = append(problems, "export.location is required (EXPORT_LOCATION)")
+	} else if _, err := storage.ParseLocation(c.Export.Location); err != nil {
+		problems = append(problems, fmt.Sprintf("export.location: %v", err))
+	}
+	if c.Bulk.UploadLocation == "" {
+		problems = append(problems, "bulk.upload_location is required (BULK_UPLOAD_LOCATION)")
+	} else if _, err := storage.ParseLocation(c.Bulk.UploadLocation); err != nil {
+		problems = append(problems, fmt.Sprintf("bulk.upload_location: %v", err))
+	}
+	if c.Bulk.ReportLocation == "" {
+		problems = append(problems, "bulk.report_location is required (BULK_REPORT_LOCATION)")
+	} else if _, err := storage.ParseLocation(c.Bulk.ReportLocation); err != nil {
+		problems = append(problems, fmt.Sprintf("bulk.report_location: %v", err))
+	}
+	if c.Bulk.MaxUploadBytes == 0 {
+		problems = append(problems, "bulk.max_upload_bytes must be greater than zero (BULK_MAX_UPLOAD_BYTES)")
+	}
+	if c.Bulk.PreviewRows == 0 {
+		problems = append(problems, "bulk.preview_rows must be greater than zero (BULK_PREVIEW_ROWS)")
+	}
+	if c.Bulk.BatchSize == 0 {
+		problems = append(problems, "bulk.batch_size must be greater than zero (BULK_BATCH_SIZE)")
+	}
+	if c.Bulk.Concurrency == 0 {
+		problems = append(problems, "bulk.concurrency must be greater than zero (BULK_CONCURRENCY)")
+	}
+	if c.Bulk.Workers == 0 {
+		problems = append(problems, "bulk.workers must be greater than zero (BULK_WORKERS)")
+	}
+	if c.Bulk.SellerConcurrency == 0 {
+		problems = append(problems, "bulk.seller_concurrency must be greater than zero (BULK_SELLER_CONCURRENCY)")
+	}
+	if c.Allocation.ZoneLength == 0 {
+		problems = append(problems, "allocation.zone_length must be greater than zero")
+	}
+	hubs := map[string]bool{}
+	for i, hub := range c.Allocation.Hubs {
+		switch {
+		case hub.ID == "":
+			problems = append(problems, fmt.Sprintf("allocation.hubs[%d].id is required", i))
+		case hubs[hub.ID]:
+			problems = append(problems, fmt.Sprintf("allocation.hubs[%d].id %s is listed twice", i, hub.ID))
+		}
+		hubs[hub.ID] = true
+	}
+	for tenantID, thresholds := range c.SLA.Tenants {
+		if thresholds.OnHold < 0 || thresholds.NewOrder < 0 {
+			problems = append(problems, fmt.Sprintf("sla.tenants.%s thresholds cannot be negative", tenantID))
+		}
+	}
+
+	if c.Queue.MaxReceives == 0 {
+		problems = append(problems, "queue.max_receives must be greater than zero (SQS_MAX_RECEIVES)")
+	}
+	if c.Queue.RetryDelay < 0 {
+		problems = append(problems, "queue.retry_delay cannot be negative (SQS_RETRY_DELAY)")
+	}
+
+	switch c.Queue.Publisher {
+	case PublisherMock:
+	case PublisherLocalStack, PublisherAWS:
+		problems = append(problems, validateFifoQueue("queue.bulk_order_queue", "SQS_BULK_ORDER_QUEUE", c.Queue.BulkOrderQueue)...)
+		problems = append(problems, validateFifoQueue("queue.order_events_queue", "SQS_ORDER_EVENTS_QUEUE", c.Queue.OrderEventsQueue)...)
+		if c.SQS.Region == "" {
+			problems = append(problems, "sqs.region is required (SQS_REGION)")
+		}
+		if c.SQS.Account == "" {
+			problems = append(problems, "sqs.account is required (SQS_ACCOUNT)")
+		}
+		if c.Queue.Publisher == PublisherLocalStack && c.SQS.Endpoint == "" {
+			problems = append(problems, "sqs.endpoint is required for the localstack publisher (SQS_ENDPOINT)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("queue.publisher %q must be one of mock, localstack, aws", c.Queue.Publisher))
+	}
+
+	return problems
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func validateFifoQueue(field, env, name string) []string {
+	if name == "" {
+		return []string{fmt.Sprintf("%s is required (%s)", field, env)}
+	}
+	if !strings.HasSuffix(name, ".fifo") {
+		return []string{fmt.Sprintf("%s must be a FIFO queue ending in .fifo", field)}
 	}
-	return defaultValue
+	return nil
 }
@@ -0,0 +1,120 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_LocalDefaults(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "local")
+
+	cfg, err := LoadConfig(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.Server.Port)
+	assert.Equal(t, "mongodb://localhost:27017", cfg.MongoDB.URI)
+	assert.Equal(t, PublisherMock, cfg.Queue.Publisher)
+	assert.Equal(t, uint64(3), cfg.Queue.MaxReceives)
+}
+
+func TestLoadConfig_FileEnvAndFlagPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+server:
+  port: ":9000"
+mongodb:
+  uri: mongodb://file-host:27017
+  database: from_file
+  max_pool_size: 20
+  default_timeout: 3s
+`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("ENVIRONMENT", "local")
+	t.Setenv("MONGODB_DATABASE", "from_env")
+
+	cfg, err := LoadConfig([]string{"-config", path, "-port", ":9100"})
+
+	require.NoError(t, err)
+	assert.Equal(t, ":9100", cfg.Server.Port)
+	assert.Equal(t, "mongodb://file-host:27017", cfg.MongoDB.URI)
+	assert.Equal(t, "from_env", cfg.MongoDB.Database)
+	assert.Equal(t, uint64(20), cfg.MongoDB.MaxPoolSize)
+	assert.Equal(t, 3*time.Second, cfg.MongoDB.DefaultTimeout)
+}
+
+func TestLoadConfig_ReportsEveryProblem(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("MONGODB_URI", "")
+	t.Setenv("MONGODB_MAX_POOL_SIZE", "lots")
+
+	_, err := LoadConfig(nil)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, err.Error(), "MONGODB_MAX_POOL_SIZE")
+	assert.Contains(t, err.Error(), "mongodb.uri is required")
+	assert.Contains(t, err.Error(), "sqs.region is required")
+	assert.Contains(t, err.Error(), "sqs.account is required")
+}
+
+func TestLoadConfig_TenantSLAThresholds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+sla:
+  tenants:
+    tenant1:
+      on_hold: 2h
+`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("ENVIRONMENT", "local")
+	t.Setenv("SLA_NEW_ORDER", "12h")
+
+	cfg, err := LoadConfig([]string{"-config", path})
+
+	require.NoError(t, err)
+	assert.Equal(t, SLAThresholds{OnHold: 2 * time.Hour, NewOrder: 12 * time.Hour}, cfg.SLA.Thresholds("tenant1"))
+	assert.Equal(t, SLAThresholds{OnHold: 24 * time.Hour, NewOrder: 12 * time.Hour}, cfg.SLA.Thresholds("tenant2"))
+}
+
+func TestLoadConfig_AllocationHubs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+allocation:
+  hubs:
+    - id: blr-1
+      country: IN
+      zone: "560"
+    - id: blr-1
+      country: IN
+  seller_priorities:
+    seller1: [blr-1]
+`), 0o600)
+	require.NoError(t, err)
+	t.Setenv("ENVIRONMENT", "local")
+
+	_, err = LoadConfig([]string{"-config", path})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "allocation.hubs[1].id blr-1 is listed twice")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+allocation:
+  hubs:
+    - id: blr-1
+      country: IN
+      zone: "560"
+  seller_priorities:
+    seller1: [blr-1]
+`), 0o600))
+	cfg, err := LoadConfig([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, []HubConfig{{ID: "blr-1", Country: "IN", Zone: "560"}}, cfg.Allocation.Hubs)
+	assert.Equal(t, []string{"blr-1"}, cfg.Allocation.SellerPriorities["seller1"])
+	assert.Equal(t, uint64(3), cfg.Allocation.ZoneLength)
+}
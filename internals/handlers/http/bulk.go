@@ -0,0 +1,340 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"oms-service-goc/internals/bulk"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead allows for the form fields and boundaries around the uploaded file
+const multipartOverhead = 1 << 20
+
+type BulkHandler struct {
+	bulkService *services.BulkOrderService
+}
+
+func NewBulkHandler(bulkService *services.BulkOrderService) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+func (h *BulkHandler) CreateBulkOrder(c *gin.Context) {
+
+	var request models.BulkOrderRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "Invalid request format : %v", err)
+		respondError(c, 400, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	job, err := h.bulkService.CreateBulkOrder(c.Request.Context(), &request)
+	if err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "failed to create bulk order: %v", err)
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to create bulk order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"success": true,
+		"message": "Bulk order request queued successfully",
+		"data": gin.H{
+			"job_id":    job.ID.Hex(),
+			"file_path": request.FilePath,
+			"user_id":   request.UserID,
+			"dry_run":   job.DryRun,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+// UploadBulkOrder takes the bulk file as the multipart field "file", with user_id, user_name,
+// format, sheet, template_id and dry_run as form fields. JSON and NDJSON can also be sent as the
+// request body, with the other fields in the query string.
+func (h *BulkHandler) UploadBulkOrder(c *gin.Context) {
+	upload, done, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+	defer done()
+
+	job, rowErrors, err := h.bulkService.UploadBulkOrder(c.Request.Context(), *upload)
+	if len(rowErrors) > 0 {
+		respondError(c, 422, gin.H{
+			"error":      "File has invalid rows",
+			"row_errors": rowErrors,
+		})
+		return
+	}
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to upload bulk order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"success": true,
+		"message": "Bulk order file uploaded and queued",
+		"data": gin.H{
+			"job_id": job.ID.Hex(),
+			"job":    job,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+// PreviewBulkOrder takes a file like UploadBulkOrder and shows how its first rows would be
+// read, typically to try out an import template before uploading
+func (h *BulkHandler) PreviewBulkOrder(c *gin.Context) {
+	upload, done, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+	defer done()
+
+	preview, err := h.bulkService.PreviewBulkOrder(c.Request.Context(), *upload)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to preview bulk order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rows := make([]gin.H, 0, len(preview.Rows))
+	for _, row := range preview.Rows {
+		rows = append(rows, gin.H{
+			"line":    row.Line,
+			"columns": row.Columns(),
+		})
+	}
+	orders := make([]gin.H, 0, len(preview.Orders))
+	for _, group := range preview.Orders {
+		orders = append(orders, gin.H{
+			"lines": group.Lines,
+			"order": group.Order,
+		})
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"format":     preview.Format,
+			"rows":       rows,
+			"row_errors": preview.RowErrors,
+			"orders":     orders,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+// readUpload reads the bulk file from a multipart form or, for JSON and NDJSON, from the
+// request body. It writes the error response itself when the file cannot be read; done
+// releases the file.
+func (h *BulkHandler) readUpload(c *gin.Context) (*services.BulkUpload, func(), bool) {
+	maxBytes := h.bulkService.Limits().MaxBytes
+	tooLarge := func(err error) bool {
+		var maxBytesErr *nethttp.MaxBytesError
+		if !errors.As(err, &maxBytesErr) {
+			return false
+		}
+		respondError(c, 413, gin.H{
+			"error":     "File is too large",
+			"max_bytes": maxBytes,
+		})
+		return true
+	}
+
+	switch c.ContentType() {
+	case "application/json", "application/x-ndjson":
+		body, err := io.ReadAll(nethttp.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			if !tooLarge(err) {
+				respondError(c, 400, gin.H{
+					"error":   "Failed to read request body",
+					"details": err.Error(),
+				})
+			}
+			return nil, nil, false
+		}
+
+		dryRun, ok := parseFlag(c, "dry_run", c.Query("dry_run"))
+		if !ok {
+			return nil, nil, false
+		}
+		format := bulk.InputJSON
+		if c.ContentType() == "application/x-ndjson" {
+			format = bulk.InputNDJSON
+		}
+		return &services.BulkUpload{
+			UserID:      c.Query("user_id"),
+			UserName:    c.Query("user_name"),
+			FileName:    "upload" + format.Extension(),
+			ContentType: c.ContentType(),
+			Format:      string(format),
+			TemplateID:  c.Query("template_id"),
+			DryRun:      dryRun,
+			TenantID:    c.Query("tenant_id"),
+			SellerID:    c.Query("seller_id"),
+			Size:        int64(len(body)),
+			File:        bytes.NewReader(body),
+		}, func() {}, true
+	}
+
+	c.Request.Body = nethttp.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		if !tooLarge(err) {
+			respondError(c, 400, gin.H{
+				"error":   "A multipart file field named file is required",
+				"details": err.Error(),
+			})
+		}
+		return nil, nil, false
+	}
+
+	dryRun, ok := parseFlag(c, "dry_run", c.PostForm("dry_run"))
+	if !ok {
+		return nil, nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "failed to open uploaded file: %v", err)
+		respondError(c, 400, gin.H{
+			"error": "Failed to read uploaded file",
+		})
+		return nil, nil, false
+	}
+
+	return &services.BulkUpload{
+		UserID:      c.PostForm("user_id"),
+		UserName:    c.PostForm("user_name"),
+		FileName:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Format:      c.PostForm("format"),
+		Sheet:       c.PostForm("sheet"),
+		TemplateID:  c.PostForm("template_id"),
+		DryRun:      dryRun,
+		TenantID:    c.PostForm("tenant_id"),
+		SellerID:    c.PostForm("seller_id"),
+		Size:        header.Size,
+		File:        file,
+	}, func() { file.Close() }, true
+}
+
+// parseFlag reads an optional boolean field such as dry_run, writing a 400 when it is not a boolean
+func parseFlag(c *gin.Context, name, value string) (bool, bool) {
+	if value == "" {
+		return false, true
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		respondError(c, 400, gin.H{
+			"error":   name + " must be true or false",
+			"details": err.Error(),
+		})
+		return false, false
+	}
+	return flag, true
+}
+
+func (h *BulkHandler) GetBulkJob(c *gin.Context) {
+	job, err := h.bulkService.GetBulkJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Bulk job not found",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"job": job,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+// DownloadErrorReport streams the CSV of rows the importer rejected
+func (h *BulkHandler) DownloadErrorReport(c *gin.Context) {
+	job, err := h.bulkService.GetBulkJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Bulk job not found",
+		})
+		return
+	}
+
+	file, err := h.bulkService.OpenErrorReport(c.Request.Context(), job)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":  "Error report is unavailable",
+			"status": job.Status,
+		})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.ID.Hex()+"-errors.csv"))
+	c.Header("Content-Type", "text/csv")
+	c.Status(200)
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "failed to stream error report of bulk job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// CancelBulkJob cancels a job, stopping its import at the next batch if it is running.
+// rollback=true, as a query parameter or form field, also cancels the orders it created.
+func (h *BulkHandler) CancelBulkJob(c *gin.Context) {
+	value := c.Query("rollback")
+	if value == "" {
+		value = c.PostForm("rollback")
+	}
+	rollback, ok := parseFlag(c, "rollback", value)
+	if !ok {
+		return
+	}
+
+	job, err := h.bulkService.CancelBulkJob(c.Request.Context(), c.Param("job_id"), rollback)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to cancel bulk job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// A running job is cancelled by its worker once it reaches the next batch
+	code, message := 200, "Bulk job cancelled successfully"
+	if job.Status != models.BulkJobCancelled {
+		code, message = 202, "Bulk job cancellation requested"
+	}
+	c.JSON(code, gin.H{
+		"success": true,
+		"message": message,
+		"data": gin.H{
+			"job": job,
+		},
+		"timestamp": time.Now(),
+	})
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService *services.DeadLetterService
+}
+
+func NewDeadLetterHandler(deadLetterService *services.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// ListDeadLetters lists messages waiting to be replayed, oldest first, filtered by queue.
+// include_replayed=true also lists the ones already replayed.
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	filter := repositories.DeadLetterFilter{
+		Queue: c.Query("queue"),
+	}
+	if value := c.Query("include_replayed"); value != "" {
+		includeReplayed, err := strconv.ParseBool(value)
+		if err != nil {
+			respondError(c, 400, gin.H{
+				"error":   "include_replayed must be true or false",
+				"details": err.Error(),
+			})
+			return
+		}
+		filter.IncludeReplayed = includeReplayed
+	}
+
+	entries, err := h.deadLetterService.ListDeadLetters(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch dead letters",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"dead_letters": entries,
+			"count":        len(entries),
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *DeadLetterHandler) GetDeadLetter(c *gin.Context) {
+	entry, err := h.deadLetterService.GetDeadLetter(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch dead letter",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"dead_letter": entry,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *DeadLetterHandler) ReplayDeadLetter(c *gin.Context) {
+	entry, err := h.deadLetterService.ReplayDeadLetter(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to replay dead letter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Dead letter replayed successfully",
+		"data": gin.H{
+			"dead_letter": entry,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+// ReplayDeadLetters replays every dead letter not replayed yet, optionally only those from queue
+func (h *DeadLetterHandler) ReplayDeadLetters(c *gin.Context) {
+	entries, err := h.deadLetterService.ReplayDeadLetters(c.Request.Context(), c.Query("queue"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":    "Failed to replay dead letters",
+			"details":  err.Error(),
+			"replayed": len(entries),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Dead letters replayed successfully",
+		"data": gin.H{
+			"dead_letters": entries,
+			"count":        len(entries),
+		},
+		"timestamp": time.Now(),
+	})
+}
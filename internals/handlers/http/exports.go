@@ -0,0 +1,151 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"oms-service-goc/internals/bulk"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportOrders streams matching orders as CSV or NDJSON. Once the first byte is sent the
+// status can no longer change, so failures part way through are only logged.
+func (h *ExportHandler) ExportOrders(c *gin.Context) {
+	var filter models.ExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		respondError(c, 400, gin.H{
+			"error":   "Invalid export filter",
+			"details": err.Error(),
+		})
+		return
+	}
+	format, err := bulk.ParseExportFormat(c.Query("format"))
+	if err != nil {
+		respondError(c, 400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := h.exportService.ValidateExport(filter); err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("orders-%s%s", time.Now().UTC().Format("20060102-150405"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(200)
+
+	written, err := h.exportService.Export(c.Request.Context(), filter, format, c.Writer)
+	if err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "order export aborted after %d orders: %v", written, err)
+		return
+	}
+	log.InfofWithContext(c.Request.Context(), "Exported %d orders as %s", written, format)
+}
+
+type StartExportRequest struct {
+	Format string              `json:"format"`
+	Filter models.ExportFilter `json:"filter"`
+}
+
+func (h *ExportHandler) StartExport(c *gin.Context) {
+	var request StartExportRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	format, err := bulk.ParseExportFormat(request.Format)
+	if err != nil {
+		respondError(c, 400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	job, err := h.exportService.StartExport(c.Request.Context(), request.Filter, format)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to start export",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"success": true,
+		"message": "Export started",
+		"data": gin.H{
+			"job_id": job.ID.Hex(),
+			"job":    job,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ExportHandler) GetExportJob(c *gin.Context) {
+	job, err := h.exportService.GetExportJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Export job not found",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"job": job,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	job, err := h.exportService.GetExportJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Export job not found",
+		})
+		return
+	}
+	if job.Status != models.ExportJobCompleted {
+		respondError(c, 409, gin.H{
+			"error":  "Export is not ready",
+			"status": job.Status,
+		})
+		return
+	}
+
+	file, err := h.exportService.OpenExport(c.Request.Context(), job)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Export file is unavailable",
+		})
+		return
+	}
+	defer file.Close()
+
+	format, _ := bulk.ParseExportFormat(job.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.ID.Hex()+format.Extension()))
+	c.Header("Content-Type", format.ContentType())
+	c.Status(200)
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "failed to stream export %s: %v", job.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldHandler struct {
+	holdService *services.HoldService
+}
+
+func NewHoldHandler(holdService *services.HoldService) *HoldHandler {
+	return &HoldHandler{
+		holdService: holdService,
+	}
+}
+
+type PlaceHoldRequest struct {
+	Reason models.HoldReason `json:"reason" binding:"required"`
+}
+
+func (h *HoldHandler) PlaceHold(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var request PlaceHoldRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	order, err := h.holdService.PlaceHold(c.Request.Context(), orderID, request.Reason)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to hold order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Order held successfully",
+		"data": gin.H{
+			"order": order,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *HoldHandler) ReleaseHold(c *gin.Context) {
+	orderID := c.Param("id")
+	reason := models.HoldReason(c.Param("reason"))
+
+	order, err := h.holdService.ReleaseHold(c.Request.Context(), orderID, reason)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to release hold",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Hold released successfully",
+		"data": gin.H{
+			"order": order,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *HoldHandler) EvaluateHolds(c *gin.Context) {
+	orderID := c.Param("id")
+
+	outcomes, err := h.holdService.EvaluateHolds(c.Request.Context(), orderID)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Unable to evaluate holds",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"holds":    outcomes,
+			"order_id": orderID,
+		},
+		"timestamp": time.Now(),
+	})
+}
@@ -1,13 +1,18 @@
 package http
 
 import (
+	"errors"
+	"oms-service-goc/internals/log"
 	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/requestid"
 	"oms-service-goc/internals/services"
+	"oms-service-goc/internals/storage"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	//"github.com/omniful/go_commons/http"
-	"github.com/omniful/go_commons/log"
+	"github.com/shopspring/decimal"
 )
 
 type OrderHandler struct {
@@ -20,11 +25,65 @@ func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
 	}
 }
 
+// respondError writes an error body tagged with the request ID so clients can quote it back to us.
+// Details only go out with 4xx responses; a server error's details can name databases, buckets
+// or hosts, so they are logged against the request ID instead.
+func respondError(c *gin.Context, code int, body gin.H) {
+	id := requestid.FromContext(c.Request.Context())
+	if details, ok := body["details"]; ok && code >= 500 {
+		log.ErrorfWithContext(c.Request.Context(), "request %s failed with %d: %v: %v", id, code, body["error"], details)
+		delete(body, "details")
+	}
+	body["request_id"] = id
+	c.JSON(code, body)
+}
+
+// bindJSON binds the request body and writes a 400 when it is malformed; it reports whether binding succeeded
+func bindJSON(c *gin.Context, request interface{}) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "Invalid request format: %v", err)
+		respondError(c, 400, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// errorStatus maps domain errors onto HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrOrderNotFound), errors.Is(err, repositories.ErrShipmentNotFound),
+		errors.Is(err, repositories.ErrReturnNotFound), errors.Is(err, repositories.ErrExportJobNotFound):
+		return 404
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, repositories.ErrBulkJobNotFound),
+		errors.Is(err, repositories.ErrImportTemplateNotFound), errors.Is(err, repositories.ErrDeadLetterNotFound):
+		return 404
+	case errors.Is(err, services.ErrUploadTooLarge):
+		return 413
+	case errors.Is(err, services.ErrUnsupportedUpload):
+		return 415
+	case errors.Is(err, models.ErrInvalidStatusTransition), errors.Is(err, repositories.ErrConcurrentModification),
+		errors.Is(err, models.ErrVersionConflict), errors.Is(err, repositories.ErrBulkJobCancelled):
+		return 409
+	case errors.Is(err, models.ErrInvalidOrder), errors.Is(err, models.ErrInvalidQuantity),
+		errors.Is(err, models.ErrInvalidShipment), errors.Is(err, models.ErrInvalidReturn),
+		errors.Is(err, services.ErrInvalidBulkUpdate), errors.Is(err, services.ErrInvalidExport),
+		errors.Is(err, storage.ErrInvalidLocation), errors.Is(err, storage.ErrUnsupportedScheme),
+		errors.Is(err, services.ErrInvalidUpload), errors.Is(err, services.ErrInvalidBulkOrder),
+		errors.Is(err, services.ErrInvalidImportTemplate), errors.Is(err, services.ErrInvalidReplay):
+		return 400
+	default:
+		return 500
+	}
+}
+
 // GET ORDER BY SELLER
 func (h *OrderHandler) GetOrderBySeller(c *gin.Context) {
 	sellerID := c.Query("seller_id")
 	if sellerID == "" {
-		c.JSON(400, gin.H{
+		respondError(c, 400, gin.H{
 			"error": "Seller_id is required",
 		})
 		return
@@ -33,7 +92,7 @@ func (h *OrderHandler) GetOrderBySeller(c *gin.Context) {
 	orders, err := h.orderService.GetOrdersBySellerID(c.Request.Context(), sellerID)
 	if err != nil {
 		log.ErrorfWithContext(c.Request.Context(), "failed to get orders by seller ID %s: %v", sellerID, err)
-		c.JSON(500, gin.H{
+		respondError(c, 500, gin.H{
 			"error": "Unable to fetch orders",
 		})
 		return
@@ -53,7 +112,7 @@ func (h *OrderHandler) GetOrderBySeller(c *gin.Context) {
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	orderID := c.Param("id")
 	if orderID == "" {
-		c.JSON(404, gin.H{
+		respondError(c, 404, gin.H{
 			"error": "Order ID is required",
 		})
 		return
@@ -62,7 +121,7 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 
 	if err != nil {
 		log.ErrorfWithContext(c.Request.Context(), "failed to get order by ID %s: %v", orderID, err)
-		c.JSON(400, gin.H{
+		respondError(c, 400, gin.H{
 			"error": "Unable to fetch order",
 		})
 		return
@@ -86,7 +145,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	orderID := c.Param("id")
 
 	if orderID == "" {
-		c.JSON(404, gin.H{
+		respondError(c, 404, gin.H{
 			"error": "Order ID is required",
 		})
 		return
@@ -96,7 +155,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&updateOrderRequest); err != nil {
 		log.ErrorfWithContext(c.Request.Context(), "Invalid request format: %v", err)
-		c.JSON(400, gin.H{
+		respondError(c, 400, gin.H{
 			"error":   "Invalid request format",
 			"details": err.Error(),
 		})
@@ -105,9 +164,10 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	err := h.orderService.UpdateOrderStatus(c.Request.Context(), orderID, updateOrderRequest.Status)
 	if err != nil {
-		log.ErrorfWithContext(c.Request.Context(), "failed to update order %w", err)
-		c.JSON(500, gin.H{
-			"error": "Failed to update order status",
+		log.ErrorfWithContext(c.Request.Context(), "failed to update order %s: %v", orderID, err)
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to update order status",
+			"details": err.Error(),
 		})
 		return
 	}
@@ -122,34 +182,201 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	})
 }
 
-func (h *OrderHandler) CreateBulkOrder(c *gin.Context) {
+type FulfilmentRequest struct {
+	Items []models.ItemQuantity `json:"items" binding:"required,min=1,dive"`
+}
 
-	var request models.BulkOrderRequest
+func (h *OrderHandler) RecordFulfilment(c *gin.Context) {
+	orderID := c.Param("id")
 
+	var request FulfilmentRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		log.ErrorfWithContext(c.Request.Context(), "Invalid request format : %v", err)
-		c.JSON(400, gin.H{
+		log.ErrorfWithContext(c.Request.Context(), "Invalid request format: %v", err)
+		respondError(c, 400, gin.H{
 			"error":   "Invalid request format",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	err := h.orderService.CreateBulkOrder(c.Request.Context(), &request)
+	order, err := h.orderService.RecordFulfilment(c.Request.Context(), orderID, request.Items)
 	if err != nil {
-		log.ErrorfWithContext(c.Request.Context(), "failed to create bulk order: %v", err)
-		c.JSON(500, gin.H{
-			"error": "Failed to create bulk order",
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to record fulfilment",
+			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(202, gin.H{
+	c.JSON(200, gin.H{
 		"success": true,
-		"message": "Bulk order request queued successfully",
+		"message": "Fulfilment recorded successfully",
 		"data": gin.H{
-			"file_path": request.FilePath,
-			"user_id":   request.UserID,
+			"order": order,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *OrderHandler) BulkUpdateStatus(c *gin.Context) {
+	var request services.BulkStatusRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	results, err := h.orderService.BulkUpdateStatus(c.Request.Context(), &request)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to update order statuses",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	items := make([]gin.H, len(results))
+	succeeded := 0
+	for i, result := range results {
+		item := gin.H{
+			"order_id": result.OrderID,
+			"success":  result.Err == nil,
+		}
+		if result.Err != nil {
+			code := errorStatus(result.Err)
+			item["error"] = result.Err.Error()
+			item["code"] = code
+			if code >= 500 {
+				log.ErrorfWithContext(c.Request.Context(), "failed to update status of order %s: %v", result.OrderID, result.Err)
+				item["error"] = "internal error"
+			}
+		} else {
+			item["status"] = result.Order.Status
+			succeeded++
+		}
+		items[i] = item
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Bulk status update processed",
+		"data": gin.H{
+			"results":   items,
+			"total":     len(results),
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+// EditOrderItem is an item added by an edit; pricing fields are optional
+type EditOrderItem struct {
+	SKUCode   string          `json:"sku_code" binding:"required"`
+	Quantity  int             `json:"quantity" binding:"required,gt=0"`
+	UnitPrice decimal.Decimal `json:"unit_price"`
+	Discount  decimal.Decimal `json:"discount"`
+	TaxRate   decimal.Decimal `json:"tax_rate"`
+}
+
+type EditOrderRequest struct {
+	Version    *int64                `json:"version" binding:"required"`
+	Currency   string                `json:"currency"`
+	Add        []EditOrderItem       `json:"add" binding:"dive"`
+	Remove     []string              `json:"remove" binding:"dive,required"`
+	Quantities []models.ItemQuantity `json:"quantities" binding:"dive"`
+}
+
+func (h *OrderHandler) EditOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var request EditOrderRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	edit := models.OrderEdit{
+		Version:    *request.Version,
+		Currency:   request.Currency,
+		Remove:     request.Remove,
+		Quantities: request.Quantities,
+	}
+	for _, item := range request.Add {
+		edit.Add = append(edit.Add, models.OrderItem{
+			SKUCode:   item.SKUCode,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Discount:  item.Discount,
+			TaxRate:   item.TaxRate,
+		})
+	}
+
+	order, err := h.orderService.EditOrder(c.Request.Context(), orderID, edit)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to edit order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Order edited successfully",
+		"data": gin.H{
+			"order": order,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+type SplitOrderRequest struct {
+	Assignments []models.HubAssignment `json:"assignments" binding:"required,min=2,dive"`
+}
+
+func (h *OrderHandler) SplitOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var request SplitOrderRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	parent, children, err := h.orderService.SplitOrder(c.Request.Context(), orderID, request.Assignments)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to split order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"message": "Order split successfully",
+		"data": gin.H{
+			"order":    parent,
+			"children": children,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *OrderHandler) GetChildOrders(c *gin.Context) {
+	orderID := c.Param("id")
+
+	children, err := h.orderService.GetChildOrders(c.Request.Context(), orderID)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch child orders",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"children": children,
+			"count":    len(children),
+			"order_id": orderID,
 		},
 		"timestamp": time.Now(),
 	})
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReturnHandler struct {
+	returnService *services.ReturnService
+}
+
+func NewReturnHandler(returnService *services.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+	}
+}
+
+func (h *ReturnHandler) CreateReturn(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var request services.CreateReturnRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	ret, err := h.returnService.CreateReturn(c.Request.Context(), orderID, &request)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to create return",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondReturn(c, 201, "Return requested successfully", ret)
+}
+
+func (h *ReturnHandler) GetReturnsByOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	returns, err := h.returnService.GetReturnsByOrderID(c.Request.Context(), orderID)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch returns",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"returns":  returns,
+			"count":    len(returns),
+			"order_id": orderID,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ReturnHandler) GetReturn(c *gin.Context) {
+	returnID := c.Param("id")
+
+	ret, err := h.returnService.GetReturn(c.Request.Context(), returnID)
+	if err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "failed to get return %s: %v", returnID, err)
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch return",
+		})
+		return
+	}
+
+	respondReturn(c, 200, "", ret)
+}
+
+func (h *ReturnHandler) Approve(c *gin.Context) {
+	ret, err := h.returnService.Approve(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to approve return",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondReturn(c, 200, "Return approved", ret)
+}
+
+type RejectReturnRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *ReturnHandler) Reject(c *gin.Context) {
+	var request RejectReturnRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	ret, err := h.returnService.Reject(c.Request.Context(), c.Param("id"), request.Reason)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to reject return",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondReturn(c, 200, "Return rejected", ret)
+}
+
+type ReceiveReturnRequest struct {
+	HubID string                `json:"hub_id" binding:"required"`
+	Items []models.ItemQuantity `json:"items" binding:"required,min=1"`
+}
+
+func (h *ReturnHandler) Receive(c *gin.Context) {
+	var request ReceiveReturnRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	ret, err := h.returnService.Receive(c.Request.Context(), c.Param("id"), request.HubID, request.Items)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to receive return",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondReturn(c, 200, "Return received", ret)
+}
+
+type RestockReturnRequest struct {
+	Items []models.ItemQuantity `json:"items" binding:"required"`
+}
+
+func (h *ReturnHandler) Restock(c *gin.Context) {
+	var request RestockReturnRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	ret, err := h.returnService.Restock(c.Request.Context(), c.Param("id"), request.Items)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to restock return",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondReturn(c, 200, "Return restocked", ret)
+}
+
+func respondReturn(c *gin.Context, code int, message string, ret *models.Return) {
+	body := gin.H{
+		"success": true,
+		"data": gin.H{
+			"return": ret,
+		},
+		"timestamp": time.Now(),
+	}
+	if message != "" {
+		body["message"] = message
+	}
+	c.JSON(code, body)
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShipmentHandler struct {
+	shipmentService *services.ShipmentService
+}
+
+func NewShipmentHandler(shipmentService *services.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{
+		shipmentService: shipmentService,
+	}
+}
+
+func (h *ShipmentHandler) CreateShipment(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var request services.CreateShipmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "Invalid request format: %v", err)
+		respondError(c, 400, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	shipment, err := h.shipmentService.CreateShipment(c.Request.Context(), orderID, &request)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to create shipment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"message": "Shipment created successfully",
+		"data": gin.H{
+			"shipment": shipment,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ShipmentHandler) GetShipmentsByOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	shipments, err := h.shipmentService.GetShipmentsByOrderID(c.Request.Context(), orderID)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch shipments",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shipments": shipments,
+			"count":     len(shipments),
+			"order_id":  orderID,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ShipmentHandler) GetShipment(c *gin.Context) {
+	shipmentID := c.Param("id")
+
+	shipment, err := h.shipmentService.GetShipment(c.Request.Context(), shipmentID)
+	if err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "failed to get shipment %s: %v", shipmentID, err)
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch shipment",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shipment": shipment,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+type AttachTrackingRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number" binding:"required"`
+	TrackingURL    string `json:"tracking_url"`
+}
+
+func (h *ShipmentHandler) AttachTracking(c *gin.Context) {
+	shipmentID := c.Param("id")
+
+	var request AttachTrackingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.ErrorfWithContext(c.Request.Context(), "Invalid request format: %v", err)
+		respondError(c, 400, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	shipment, err := h.shipmentService.AttachTracking(c.Request.Context(), shipmentID, request.Carrier, request.TrackingNumber, request.TrackingURL)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to attach tracking",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Tracking attached successfully",
+		"data": gin.H{
+			"shipment": shipment,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ShipmentHandler) MarkDelivered(c *gin.Context) {
+	shipmentID := c.Param("id")
+
+	shipment, err := h.shipmentService.MarkDelivered(c.Request.Context(), shipmentID)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to mark shipment delivered",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "Shipment marked delivered",
+		"data": gin.H{
+			"shipment": shipment,
+		},
+		"timestamp": time.Now(),
+	})
+}
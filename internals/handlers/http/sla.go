@@ -0,0 +1,57 @@
+package http
+
+import (
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SLAHandler struct {
+	slaService *services.SLAService
+}
+
+func NewSLAHandler(slaService *services.SLAService) *SLAHandler {
+	return &SLAHandler{
+		slaService: slaService,
+	}
+}
+
+// GetBreaches lists flagged orders, filtered by tenant_id, seller_id, hub_id, status and breached_after (RFC 3339)
+func (h *SLAHandler) GetBreaches(c *gin.Context) {
+	filters := repositories.OrderFilters{
+		TenantID: c.Query("tenant_id"),
+		SellerID: c.Query("seller_id"),
+		HubID:    c.Query("hub_id"),
+		Status:   c.Query("status"),
+	}
+	if value := c.Query("breached_after"); value != "" {
+		breachedAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, 400, gin.H{
+				"error":   "breached_after must be an RFC 3339 timestamp",
+				"details": err.Error(),
+			})
+			return
+		}
+		filters.BreachedAfter = &breachedAfter
+	}
+
+	orders, err := h.slaService.GetBreaches(c.Request.Context(), filters)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch SLA breaches",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"orders": orders,
+			"count":  len(orders),
+		},
+		"timestamp": time.Now(),
+	})
+}
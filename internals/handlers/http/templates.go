@@ -0,0 +1,141 @@
+package http
+
+import (
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportTemplateHandler struct {
+	templateService *services.ImportTemplateService
+}
+
+func NewImportTemplateHandler(templateService *services.ImportTemplateService) *ImportTemplateHandler {
+	return &ImportTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+type ImportTemplateRequest struct {
+	SellerID string                 `json:"seller_id"`
+	Name     string                 `json:"name" binding:"required"`
+	Columns  []models.ColumnMapping `json:"columns"`
+	Defaults map[string]string      `json:"defaults"`
+}
+
+func (r *ImportTemplateRequest) template() *models.ImportTemplate {
+	return &models.ImportTemplate{
+		SellerID: r.SellerID,
+		Name:     r.Name,
+		Columns:  r.Columns,
+		Defaults: r.Defaults,
+	}
+}
+
+func respondTemplate(c *gin.Context, code int, message string, template *models.ImportTemplate) {
+	body := gin.H{
+		"success": true,
+		"data": gin.H{
+			"template": template,
+		},
+		"timestamp": time.Now(),
+	}
+	if message != "" {
+		body["message"] = message
+	}
+	c.JSON(code, body)
+}
+
+func (h *ImportTemplateHandler) CreateTemplate(c *gin.Context) {
+	var request ImportTemplateRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(c.Request.Context(), request.template())
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to create import template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondTemplate(c, 201, "Import template created successfully", template)
+}
+
+func (h *ImportTemplateHandler) GetTemplatesBySeller(c *gin.Context) {
+	sellerID := c.Query("seller_id")
+	if sellerID == "" {
+		respondError(c, 400, gin.H{
+			"error": "seller_id is required",
+		})
+		return
+	}
+
+	templates, err := h.templateService.GetTemplatesBySellerID(c.Request.Context(), sellerID)
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Unable to fetch import templates",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"templates": templates,
+			"count":     len(templates),
+			"seller_id": sellerID,
+		},
+		"timestamp": time.Now(),
+	})
+}
+
+func (h *ImportTemplateHandler) GetTemplate(c *gin.Context) {
+	template, err := h.templateService.GetTemplate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error": "Import template not found",
+		})
+		return
+	}
+
+	respondTemplate(c, 200, "", template)
+}
+
+func (h *ImportTemplateHandler) UpdateTemplate(c *gin.Context) {
+	var request ImportTemplateRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(c.Request.Context(), c.Param("id"), request.template())
+	if err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to update import template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondTemplate(c, 200, "Import template updated successfully", template)
+}
+
+func (h *ImportTemplateHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.templateService.DeleteTemplate(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, errorStatus(err), gin.H{
+			"error":   "Failed to delete import template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success":   true,
+		"message":   "Import template deleted successfully",
+		"timestamp": time.Now(),
+	})
+}
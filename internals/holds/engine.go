@@ -0,0 +1,64 @@
+package holds
+
+import (
+	"context"
+	"fmt"
+
+	"oms-service-goc/internals/models"
+)
+
+// Outcome is the result of evaluating one hold reason
+type Outcome struct {
+	Reason    models.HoldReason `json:"reason"`
+	Satisfied bool              `json:"satisfied"`
+	Detail    string            `json:"detail"`
+}
+
+// Engine evaluates the release rules for an order's outstanding hold reasons. Reasons
+// without a rule, such as fraud_review, are only cleared by request.
+type Engine struct {
+	rules map[models.HoldReason]Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	engine := &Engine{rules: map[models.HoldReason]Rule{}}
+	for _, rule := range rules {
+		engine.rules[rule.Reason()] = rule
+	}
+	return engine
+}
+
+// Evaluate returns one outcome per hold reason on the order
+func (e *Engine) Evaluate(ctx context.Context, order *models.Order) ([]Outcome, error) {
+	outcomes := make([]Outcome, 0, len(order.HoldReasons))
+	for _, reason := range order.HoldReasons {
+		rule, ok := e.rules[reason]
+		if !ok {
+			outcomes = append(outcomes, Outcome{Reason: reason, Detail: "requires manual release"})
+			continue
+		}
+
+		satisfied, detail, err := rule.Satisfied(ctx, order)
+		if err != nil {
+			return nil, fmt.Errorf("%s rule: %w", reason, err)
+		}
+		outcomes = append(outcomes, Outcome{Reason: reason, Satisfied: satisfied, Detail: detail})
+	}
+	return outcomes, nil
+}
+
+// Clearable returns the hold reasons whose rules are satisfied
+func (e *Engine) Clearable(ctx context.Context, order *models.Order) ([]models.HoldReason, error) {
+	outcomes, err := e.Evaluate(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var reasons []models.HoldReason
+	for _, outcome := range outcomes {
+		if outcome.Satisfied {
+			reasons = append(reasons, outcome.Reason)
+		}
+	}
+	return reasons, nil
+}
@@ -0,0 +1,68 @@
+package holds
+
+import (
+	"context"
+	"testing"
+
+	"oms-service-goc/internals/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePayments bool
+
+func (f fakePayments) IsPaid(ctx context.Context, order *models.Order) (bool, error) {
+	return bool(f), nil
+}
+
+type fakeInventory map[string]map[string]int
+
+func (f fakeInventory) StockLevels(ctx context.Context, tenantID, sellerID string, skuCodes []string) (map[string]map[string]int, error) {
+	return f, nil
+}
+
+func newHeldOrder(reasons ...models.HoldReason) *models.Order {
+	return &models.Order{
+		HubID:       "hub1",
+		Status:      models.OrderStatusOnHold,
+		HoldReasons: reasons,
+		Items:       []models.OrderItem{{SKUCode: "SKU001", Quantity: 2}},
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine := NewEngine(
+		PaymentRule{Payments: fakePayments(true)},
+		StockRule{Inventory: fakeInventory{"hub1": {"SKU001": 1}}},
+		AddressRule{},
+	)
+	order := newHeldOrder(models.HoldReasonPaymentPending, models.HoldReasonStockShort, models.HoldReasonFraudReview)
+
+	outcomes, err := engine.Evaluate(context.Background(), order)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Outcome{
+		{Reason: models.HoldReasonPaymentPending, Satisfied: true, Detail: "payment received"},
+		{Reason: models.HoldReasonStockShort, Detail: "SKU001 short by 1"},
+		{Reason: models.HoldReasonFraudReview, Detail: "requires manual release"},
+	}, outcomes)
+}
+
+func TestEngine_ClearableReleasesOrder(t *testing.T) {
+	engine := NewEngine(
+		StockRule{Inventory: fakeInventory{"hub1": {"SKU001": 5}}},
+		AddressRule{},
+	)
+	order := newHeldOrder(models.HoldReasonStockShort, models.HoldReasonAddressVerification)
+	order.ShippingAddress = &models.Address{Country: "US", PostalCode: "94105"}
+
+	reasons, err := engine.Clearable(context.Background(), order)
+	require.NoError(t, err)
+	assert.Equal(t, []models.HoldReason{models.HoldReasonStockShort, models.HoldReasonAddressVerification}, reasons)
+
+	released, err := order.ClearHolds(reasons...)
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, models.OrderStatusNewOrder, order.Status)
+}
@@ -0,0 +1,102 @@
+// Package holds decides when the reasons an order is on hold have been resolved.
+package holds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oms-service-goc/internals/allocation"
+	"oms-service-goc/internals/models"
+)
+
+// Rule checks whether one hold reason can be cleared. The returned string explains the
+// outcome and is logged with the release.
+type Rule interface {
+	Reason() models.HoldReason
+	Satisfied(ctx context.Context, order *models.Order) (bool, string, error)
+}
+
+// PaymentChecker reports whether an order has been paid for
+type PaymentChecker interface {
+	IsPaid(ctx context.Context, order *models.Order) (bool, error)
+}
+
+// PaymentRule clears payment_pending once the payment checker confirms payment
+type PaymentRule struct {
+	Payments PaymentChecker
+}
+
+func (r PaymentRule) Reason() models.HoldReason { return models.HoldReasonPaymentPending }
+
+func (r PaymentRule) Satisfied(ctx context.Context, order *models.Order) (bool, string, error) {
+	paid, err := r.Payments.IsPaid(ctx, order)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check payment: %w", err)
+	}
+	if !paid {
+		return false, "payment not received", nil
+	}
+	return true, "payment received", nil
+}
+
+// StockRule clears stock_short once the order's hub holds enough stock for every item
+type StockRule struct {
+	Inventory allocation.Inventory
+}
+
+func (r StockRule) Reason() models.HoldReason { return models.HoldReasonStockShort }
+
+func (r StockRule) Satisfied(ctx context.Context, order *models.Order) (bool, string, error) {
+	skuCodes := make([]string, len(order.Items))
+	for i, item := range order.Items {
+		skuCodes[i] = item.SKUCode
+	}
+
+	levels, err := r.Inventory.StockLevels(ctx, order.TenantID, order.SellerID, skuCodes)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read stock levels: %w", err)
+	}
+
+	var shortages []string
+	for _, item := range order.Items {
+		if available := levels[order.HubID][item.SKUCode]; available < item.Quantity {
+			shortages = append(shortages, fmt.Sprintf("%s short by %d", item.SKUCode, item.Quantity-available))
+		}
+	}
+	if len(shortages) > 0 {
+		return false, strings.Join(shortages, ", "), nil
+	}
+	return true, fmt.Sprintf("stock available at %s", order.HubID), nil
+}
+
+// AddressVerifier confirms that a shipping address is deliverable
+type AddressVerifier interface {
+	Verify(ctx context.Context, address *models.Address) (bool, error)
+}
+
+// AddressRule clears address_verification once the order has a shipping address that
+// the verifier accepts. Without a verifier a complete, well-formed address is enough.
+type AddressRule struct {
+	Verifier AddressVerifier
+}
+
+func (r AddressRule) Reason() models.HoldReason { return models.HoldReasonAddressVerification }
+
+func (r AddressRule) Satisfied(ctx context.Context, order *models.Order) (bool, string, error) {
+	if order.ShippingAddress == nil {
+		return false, "no shipping address", nil
+	}
+	if r.Verifier == nil {
+		return true, "shipping address is complete", nil
+	}
+
+	verified, err := r.Verifier.Verify(ctx, order.ShippingAddress)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to verify address: %w", err)
+	}
+	if !verified {
+		return false, "shipping address not verified", nil
+	}
+	return true, "shipping address verified", nil
+}
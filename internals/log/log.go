@@ -0,0 +1,36 @@
+// Package log wraps the go_commons context logger so every line logged with a context also
+// carries the request ID in its text, whatever the context key the underlying logger reads.
+package log
+
+import (
+	"context"
+	"oms-service-goc/internals/requestid"
+	"strings"
+
+	commonslog "github.com/omniful/go_commons/log"
+)
+
+// InfofWithContext logs an info line led by the request ID in ctx
+func InfofWithContext(ctx context.Context, format string, args ...interface{}) {
+	commonslog.InfofWithContext(ctx, withRequestID(ctx, format), args...)
+}
+
+// WarnfWithContext logs a warning led by the request ID in ctx
+func WarnfWithContext(ctx context.Context, format string, args ...interface{}) {
+	commonslog.WarnfWithContext(ctx, withRequestID(ctx, format), args...)
+}
+
+// ErrorfWithContext logs an error led by the request ID in ctx
+func ErrorfWithContext(ctx context.Context, format string, args ...interface{}) {
+	commonslog.ErrorfWithContext(ctx, withRequestID(ctx, format), args...)
+}
+
+// withRequestID puts the request ID in ctx, if any, at the start of a log line's format so
+// the line can be found by the ID a client was given
+func withRequestID(ctx context.Context, format string) string {
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return format
+	}
+	return "[request_id=" + strings.ReplaceAll(id, "%", "%%") + "] " + format
+}
@@ -0,0 +1,22 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"oms-service-goc/internals/requestid"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestID_LeadsTheLine(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "req-123")
+	line := fmt.Sprintf(withRequestID(ctx, "failed to update order %s: %v"), "o1", "boom")
+	assert.Equal(t, "[request_id=req-123] failed to update order o1: boom", line)
+
+	// An ID is never read as a format verb
+	ctx = requestid.NewContext(context.Background(), "req-%s")
+	assert.Equal(t, "[request_id=req-%s] done o1", fmt.Sprintf(withRequestID(ctx, "done %s"), "o1"))
+
+	assert.Equal(t, "done", withRequestID(context.Background(), "done"))
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"oms-service-goc/internals/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID reads the X-Request-ID header (or generates one when it is absent or not a valid
+// ID), stores it in the request context and echoes it back in the response headers
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestid.Header)
+		if !requestid.Valid(requestID) {
+			requestID = requestid.New()
+		}
+
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), requestID))
+		c.Header(requestid.Header, requestID)
+
+		c.Next()
+	}
+}
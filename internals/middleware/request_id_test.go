@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oms-service-goc/internals/requestid"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(seen *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/ping", func(c *gin.Context) {
+		*seen = requestid.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRequestID_PropagatesIncomingHeader(t *testing.T) {
+	var seen string
+	router := setupRouter(&seen)
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(requestid.Header, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-123", seen)
+	assert.Equal(t, "req-123", w.Header().Get(requestid.Header))
+}
+
+func TestRequestID_GeneratesWhenMissing(t *testing.T) {
+	var seen string
+	router := setupRouter(&seen)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	assert.NotEmpty(t, seen)
+	assert.Equal(t, seen, w.Header().Get(requestid.Header))
+}
+
+func TestRequestID_ReplacesInvalidHeader(t *testing.T) {
+	for _, incoming := range []string{strings.Repeat("a", requestid.MaxLength+1), "req 123", "req\x1b[31m", "req-%s"} {
+		var seen string
+		router := setupRouter(&seen)
+
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(requestid.Header, incoming)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.NotEqual(t, incoming, seen)
+		assert.True(t, requestid.Valid(seen))
+		assert.Equal(t, seen, w.Header().Get(requestid.Header))
+	}
+}
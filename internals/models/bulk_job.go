@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type BulkJobStatus string
+
+const (
+	BulkJobQueued    BulkJobStatus = "queued"
+	BulkJobRunning   BulkJobStatus = "running"
+	BulkJobCompleted BulkJobStatus = "completed"
+	BulkJobFailed    BulkJobStatus = "failed"
+	BulkJobCancelled BulkJobStatus = "cancelled"
+)
+
+// BulkJob tracks a bulk order file from the request that queued it until the worker is done
+type BulkJob struct {
+	ID          bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Status      BulkJobStatus   `bson:"status" json:"status"`
+	FilePath    string          `bson:"file_path" json:"file_path"`
+	FileName    string          `bson:"file_name,omitempty" json:"file_name,omitempty"` // original name of an uploaded file
+	Format      string          `bson:"format,omitempty" json:"format,omitempty"`
+	Sheet       string          `bson:"sheet,omitempty" json:"sheet,omitempty"`
+	TemplateID  string          `bson:"template_id,omitempty" json:"template_id,omitempty"`
+	DryRun      bool            `bson:"dry_run,omitempty" json:"dry_run,omitempty"`     // validate the file without creating orders
+	TenantID    string          `bson:"tenant_id,omitempty" json:"tenant_id,omitempty"` // with SellerID, whose file it is; its message group and row quota are keyed by them
+	SellerID    string          `bson:"seller_id,omitempty" json:"seller_id,omitempty"`
+	UserID      string          `bson:"user_id" json:"user_id"`
+	UserName    string          `bson:"user_name" json:"user_name"`
+	Error       string          `bson:"error,omitempty" json:"error,omitempty"`
+	Checkpoint  int             `bson:"checkpoint,omitempty" json:"checkpoint,omitempty"` // every order ending at or before this line of the file has been written
+	Progress    *BulkJobSummary `bson:"progress,omitempty" json:"progress,omitempty"`     // what became of the orders up to Checkpoint, which a resumed import does not read again
+	Summary     *BulkJobSummary `bson:"summary,omitempty" json:"summary,omitempty"`
+	ErrorReport string          `bson:"error_report,omitempty" json:"error_report,omitempty"` // URI of the CSV listing rejected rows
+	RequestID   string          `bson:"request_id,omitempty" json:"request_id,omitempty"`
+
+	CancelRequested bool             `bson:"cancel_requested,omitempty" json:"cancel_requested,omitempty"` // the worker stops at the next batch and cancels the job
+	Rollback        bool             `bson:"rollback,omitempty" json:"rollback,omitempty"`                 // cancel the orders the job created along with it
+	RolledBack      *BulkJobRollback `bson:"rolled_back,omitempty" json:"rolled_back,omitempty"`
+	CancelledAt     *time.Time       `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// BulkJobRollback counts the orders of a cancelled job that were cancelled with it. Failed
+// orders had already moved on, for example by being shipped, and were left as they were.
+type BulkJobRollback struct {
+	Cancelled int `bson:"cancelled" json:"cancelled"`
+	Failed    int `bson:"failed" json:"failed"`
+}
+
+// BulkJobSummary counts what the importer made of a file. In a dry run Created is the number
+// of orders that would have been created.
+type BulkJobSummary struct {
+	Rows       int `bson:"rows" json:"rows"`             // data rows read, including rejected ones
+	RowErrors  int `bson:"row_errors" json:"row_errors"` // entries in the error report
+	Orders     int `bson:"orders" json:"orders"`         // orders the rows were grouped into
+	Created    int `bson:"created" json:"created"`
+	Rejected   int `bson:"rejected" json:"rejected"`     // orders that failed validation or were duplicates
+	Duplicates int `bson:"duplicates" json:"duplicates"` // orders whose order_ref was already used
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+type Customer struct {
+	Name  string `bson:"name" json:"name"`
+	Email string `bson:"email,omitempty" json:"email,omitempty"`
+	Phone string `bson:"phone,omitempty" json:"phone,omitempty"`
+}
+
+type Address struct {
+	Name       string `bson:"name,omitempty" json:"name,omitempty"`
+	Line1      string `bson:"line1" json:"line1"`
+	Line2      string `bson:"line2,omitempty" json:"line2,omitempty"`
+	City       string `bson:"city" json:"city"`
+	State      string `bson:"state,omitempty" json:"state,omitempty"`
+	PostalCode string `bson:"postal_code" json:"postal_code"`
+	Country    string `bson:"country" json:"country"` // ISO 3166-1 alpha-2
+	Phone      string `bson:"phone,omitempty" json:"phone,omitempty"`
+}
+
+// String masks personal data so a customer can be logged safely with %v
+func (c Customer) String() string {
+	return fmt.Sprintf("{Name:%s Email:%s Phone:%s}", MaskText(c.Name), MaskEmail(c.Email), MaskPhone(c.Phone))
+}
+
+// String masks personal data so an address can be logged safely with %v.
+// City and country are kept as they are useful when debugging routing.
+func (a Address) String() string {
+	return fmt.Sprintf("{Name:%s Line1:%s Line2:%s City:%s State:%s PostalCode:%s Country:%s Phone:%s}",
+		MaskText(a.Name), MaskText(a.Line1), MaskText(a.Line2), a.City, a.State, MaskText(a.PostalCode), a.Country, MaskPhone(a.Phone))
+}
+
+func (c *Customer) validate() []error {
+	var problems []error
+	if strings.TrimSpace(c.Name) == "" {
+		problems = append(problems, errors.New("customer.name is required"))
+	}
+	if c.Email != "" && !isValidEmail(c.Email) {
+		problems = append(problems, errors.New("customer.email is not a valid email address"))
+	}
+	return problems
+}
+
+// Normalise trims fields and lower-cases the email
+func (c *Customer) Normalise() {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Email = strings.ToLower(strings.TrimSpace(c.Email))
+	c.Phone = strings.TrimSpace(c.Phone)
+}
+
+// Normalise trims fields and upper-cases the country and postal code
+func (a *Address) Normalise() {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.State = strings.TrimSpace(a.State)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+	a.Phone = strings.TrimSpace(a.Phone)
+}
+
+func (a *Address) validate(field string) []error {
+	var problems []error
+	if a.Line1 == "" {
+		problems = append(problems, fmt.Errorf("%s.line1 is required", field))
+	}
+	if a.City == "" {
+		problems = append(problems, fmt.Errorf("%s.city is required", field))
+	}
+	if a.Country == "" {
+		problems = append(problems, fmt.Errorf("%s.country is required", field))
+	} else if !IsValidCountry(a.Country) {
+		problems = append(problems, fmt.Errorf("%s.country %q is not a valid ISO 3166-1 alpha-2 code", field, a.Country))
+	}
+	if a.PostalCode == "" {
+		if !countriesWithoutPostalCodes[a.Country] {
+			problems = append(problems, fmt.Errorf("%s.postal_code is required", field))
+		}
+	} else if !IsValidPostalCode(a.Country, a.PostalCode) {
+		problems = append(problems, fmt.Errorf("%s.postal_code %q is not valid for %s", field, a.PostalCode, a.Country))
+	}
+	return problems
+}
+
+func isValidEmail(email string) bool {
+	parsed, err := mail.ParseAddress(email)
+	return err == nil && parsed.Address == email
+}
+
+// Postal code formats for the countries we ship to most; others fall back to genericPostalCode
+var postalCodePatterns = map[string]*regexp.Regexp{
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"SA": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+}
+
+var genericPostalCode = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,8}[A-Z0-9]$`)
+
+// Countries that do not use postal codes, so the field may be left empty
+var countriesWithoutPostalCodes = map[string]bool{
+	"AE": true, "AG": true, "AO": true, "BS": true, "BZ": true, "HK": true, "IE": true,
+	"JM": true, "KI": true, "QA": true, "TV": true, "YE": true,
+}
+
+// IsValidPostalCode checks a postal code against the country's format
+func IsValidPostalCode(country, postalCode string) bool {
+	if pattern, ok := postalCodePatterns[country]; ok {
+		return pattern.MatchString(postalCode)
+	}
+	return genericPostalCode.MatchString(postalCode)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DeadLetter is a queue message that failed every time it was received and was set aside
+// so it stops being retried. It keeps everything needed to publish the message again.
+type DeadLetter struct {
+	ID              bson.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Queue           string            `bson:"queue" json:"queue"`
+	GroupID         string            `bson:"group_id" json:"group_id"`
+	DeduplicationID string            `bson:"deduplication_id" json:"deduplication_id"`
+	Body            string            `bson:"body" json:"body"`
+	Attributes      map[string]string `bson:"attributes,omitempty" json:"attributes,omitempty"`
+	Reason          string            `bson:"reason" json:"reason"` // error from the last receive
+	ReceiveCount    int               `bson:"receive_count" json:"receive_count"`
+	JobID           string            `bson:"job_id,omitempty" json:"job_id,omitempty"` // the job the message is for, when it names one
+	RequestID       string            `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	FailedAt        time.Time         `bson:"failed_at" json:"failed_at"`
+	Replays         int               `bson:"replays" json:"replays"`
+	ReplayedAt      *time.Time        `bson:"replayed_at,omitempty" json:"replayed_at,omitempty"`
+}
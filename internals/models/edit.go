@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrVersionConflict = errors.New("order version does not match, reload the order and retry")
+
+// editableStatuses are the statuses in which the items of an order may still change
+var editableStatuses = map[OrderStatus]bool{
+	OrderStatusOnHold: true,
+}
+
+// OrderEdit changes the items of an order. Version must be the version the caller last read.
+// Currency sets the currency of an order that has none, so priced items can be added to it.
+type OrderEdit struct {
+	Version    int64          `json:"version"`
+	Currency   string         `json:"currency,omitempty"`
+	Add        []OrderItem    `json:"add,omitempty"`
+	Remove     []string       `json:"remove,omitempty"`
+	Quantities []ItemQuantity `json:"quantities,omitempty"`
+}
+
+// ItemChange is the before and after quantity of one SKU; zero means the SKU was absent
+type ItemChange struct {
+	SKUCode      string `bson:"sku_code" json:"sku_code"`
+	FromQuantity int    `bson:"from_quantity" json:"from_quantity"`
+	ToQuantity   int    `bson:"to_quantity" json:"to_quantity"`
+}
+
+// HistoryEntry records one change made to an order
+type HistoryEntry struct {
+	At            time.Time       `bson:"at" json:"at"`
+	Action        string          `bson:"action" json:"action"`
+	Version       int64           `bson:"version" json:"version"` // version the change produced
+	Items         []ItemChange    `bson:"items,omitempty" json:"items,omitempty"`
+	PreviousTotal decimal.Decimal `bson:"previous_total" json:"previous_total"`
+	Total         decimal.Decimal `bson:"total" json:"total"`
+}
+
+const HistoryActionItemsEdited = "items_edited"
+
+// ApplyEdit adds, removes and re-quantifies items, recomputes totals and appends the diff
+// to the order's history. The order is left unchanged if the edit is rejected. The caller
+// bumps Version when the result is stored, so the entry records Version+1.
+func (o *Order) ApplyEdit(ctx context.Context, edit OrderEdit, at time.Time) error {
+	if edit.Version != o.Version {
+		return fmt.Errorf("%w: order is at version %d, edit was based on %d", ErrVersionConflict, o.Version, edit.Version)
+	}
+	if o.IsSplit() {
+		return ErrOrderIsSplit
+	}
+	if !editableStatuses[o.Status] {
+		return fmt.Errorf("%w: items cannot be edited in %s", ErrInvalidStatusTransition, o.Status)
+	}
+	if len(edit.Add) == 0 && len(edit.Remove) == 0 && len(edit.Quantities) == 0 {
+		return fmt.Errorf("%w: edit contains no changes", ErrInvalidOrder)
+	}
+
+	edited := *o
+	edited.Items = append([]OrderItem(nil), o.Items...)
+
+	if currency := strings.ToUpper(strings.TrimSpace(edit.Currency)); currency != "" && currency != o.Currency {
+		if o.Currency != "" {
+			return fmt.Errorf("%w: order is priced in %s, its currency cannot change", ErrInvalidOrder, o.Currency)
+		}
+		edited.Currency = currency
+	}
+
+	for _, skuCode := range edit.Remove {
+		i := edited.itemIndex(skuCode)
+		if i < 0 {
+			return fmt.Errorf("%w: %s is not on the order", ErrInvalidQuantity, skuCode)
+		}
+		edited.Items = append(edited.Items[:i], edited.Items[i+1:]...)
+	}
+	for _, change := range edit.Quantities {
+		i := edited.itemIndex(change.SKUCode)
+		if i < 0 {
+			return fmt.Errorf("%w: %s is not on the order", ErrInvalidQuantity, change.SKUCode)
+		}
+		if change.Quantity <= 0 {
+			return fmt.Errorf("%w: %s quantity must be positive, remove the item instead", ErrInvalidQuantity, change.SKUCode)
+		}
+		edited.Items[i].Quantity = change.Quantity
+	}
+	for _, item := range edit.Add {
+		if edited.itemIndex(item.SKUCode) >= 0 {
+			return fmt.Errorf("%w: %s is already on the order, change its quantity instead", ErrInvalidQuantity, item.SKUCode)
+		}
+		edited.Items = append(edited.Items, OrderItem{
+			SKUCode:   item.SKUCode,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Discount:  item.Discount,
+			TaxRate:   item.TaxRate,
+		})
+	}
+
+	if len(edited.Items) == 0 {
+		return fmt.Errorf("%w: an order needs at least one item, cancel it instead", ErrInvalidOrder)
+	}
+
+	edited.Initialise(ctx)
+	if err := edited.Validate(ctx); err != nil {
+		return err
+	}
+
+	changes := diffItems(o.Items, edited.Items)
+	if len(changes) == 0 {
+		return fmt.Errorf("%w: edit contains no changes", ErrInvalidOrder)
+	}
+	edited.History = append(append([]HistoryEntry(nil), o.History...), HistoryEntry{
+		At:            at,
+		Action:        HistoryActionItemsEdited,
+		Version:       o.Version + 1,
+		Items:         changes,
+		PreviousTotal: o.Total,
+		Total:         edited.Total,
+	})
+
+	*o = edited
+	return nil
+}
+
+// diffItems lists the SKUs whose quantity differs, in the order they appear before then after
+func diffItems(before, after []OrderItem) []ItemChange {
+	quantities := map[string]int{}
+	for _, item := range after {
+		quantities[item.SKUCode] = item.Quantity
+	}
+
+	var changes []ItemChange
+	seen := map[string]bool{}
+	for _, item := range before {
+		seen[item.SKUCode] = true
+		if quantities[item.SKUCode] != item.Quantity {
+			changes = append(changes, ItemChange{SKUCode: item.SKUCode, FromQuantity: item.Quantity, ToQuantity: quantities[item.SKUCode]})
+		}
+	}
+	for _, item := range after {
+		if !seen[item.SKUCode] {
+			changes = append(changes, ItemChange{SKUCode: item.SKUCode, ToQuantity: item.Quantity})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEditableOrder() *Order {
+	order := &Order{
+		Status:   OrderStatusOnHold,
+		Currency: "USD",
+		Version:  2,
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 1, UnitPrice: decimal.RequireFromString("10")},
+			{SKUCode: "SKU002", Quantity: 2, UnitPrice: decimal.RequireFromString("5")},
+		},
+	}
+	order.Initialise(context.Background())
+	return order
+}
+
+func TestOrder_ApplyEdit_RecordsDiff(t *testing.T) {
+	order := newEditableOrder()
+
+	err := order.ApplyEdit(context.Background(), OrderEdit{
+		Version:    2,
+		Add:        []OrderItem{{SKUCode: "SKU003", Quantity: 1, UnitPrice: decimal.RequireFromString("7.50")}},
+		Remove:     []string{"SKU002"},
+		Quantities: []ItemQuantity{{SKUCode: "SKU001", Quantity: 3}},
+	}, time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, "37.5", order.Total.String())
+	require.Len(t, order.History, 1)
+	entry := order.History[0]
+	assert.Equal(t, int64(3), entry.Version)
+	assert.Equal(t, "20", entry.PreviousTotal.String())
+	assert.Equal(t, []ItemChange{
+		{SKUCode: "SKU001", FromQuantity: 1, ToQuantity: 3},
+		{SKUCode: "SKU002", FromQuantity: 2, ToQuantity: 0},
+		{SKUCode: "SKU003", FromQuantity: 0, ToQuantity: 1},
+	}, entry.Items)
+}
+
+func TestOrder_ApplyEdit_Rejections(t *testing.T) {
+	order := newEditableOrder()
+
+	assert.ErrorIs(t, order.ApplyEdit(context.Background(), OrderEdit{Version: 1, Remove: []string{"SKU002"}}, time.Now()), ErrVersionConflict)
+	assert.ErrorIs(t, order.ApplyEdit(context.Background(), OrderEdit{Version: 2, Remove: []string{"SKU001", "SKU002"}}, time.Now()), ErrInvalidOrder)
+	assert.ErrorIs(t, order.ApplyEdit(context.Background(), OrderEdit{Version: 2, Add: []OrderItem{{SKUCode: "SKU001", Quantity: 1}}}, time.Now()), ErrInvalidQuantity)
+
+	// A rejected edit leaves the order untouched
+	assert.Len(t, order.Items, 2)
+	assert.Empty(t, order.History)
+
+	order.Status = OrderStatusNewOrder
+	assert.ErrorIs(t, order.ApplyEdit(context.Background(), OrderEdit{Version: 2, Remove: []string{"SKU002"}}, time.Now()), ErrInvalidStatusTransition)
+}
+
+func TestOrder_ApplyEdit_Currency(t *testing.T) {
+	order := &Order{Status: OrderStatusOnHold, Items: []OrderItem{{SKUCode: "SKU001", Quantity: 1}}}
+	order.Initialise(context.Background())
+	priced := []OrderItem{{SKUCode: "SKU002", Quantity: 2, UnitPrice: decimal.RequireFromString("7.50")}}
+
+	err := order.ApplyEdit(context.Background(), OrderEdit{Add: priced}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidOrder)
+	assert.Contains(t, err.Error(), "currency is required for priced orders")
+
+	require.NoError(t, order.ApplyEdit(context.Background(), OrderEdit{Currency: "eur", Add: priced}, time.Now()))
+	assert.Equal(t, "EUR", order.Currency)
+	assert.Equal(t, "15", order.Total.String())
+
+	// Once set, the currency stays: the prices on the order are in it
+	order.Version++
+	err = order.ApplyEdit(context.Background(), OrderEdit{Version: order.Version, Currency: "USD", Remove: []string{"SKU001"}}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidOrder)
+	assert.Equal(t, "EUR", order.Currency)
+	assert.NoError(t, order.ApplyEdit(context.Background(), OrderEdit{Version: order.Version, Currency: "eur", Remove: []string{"SKU001"}}, time.Now()))
+}
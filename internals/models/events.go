@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Event types published on the order events queue
+const (
+	EventReturnRequested = "return.requested"
+	EventReturnApproved  = "return.approved"
+	EventReturnRejected  = "return.rejected"
+	EventReturnReceived  = "return.received"
+	EventReturnRestocked = "return.restocked"
+
+	EventOrderHeld     = "order.held"
+	EventOrderReleased = "order.released"
+
+	EventOrderSLABreached = "order.sla_breached"
+)
+
+// Event is the envelope for every domain event published by the service
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OrderID    string      `json:"order_id"`
+	TenantID   string      `json:"tenant_id"`
+	SellerID   string      `json:"seller_id"`
+	RequestID  string      `json:"request_id,omitempty"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ExportJobStatus string
+
+const (
+	ExportJobPending   ExportJobStatus = "pending"
+	ExportJobRunning   ExportJobStatus = "running"
+	ExportJobCompleted ExportJobStatus = "completed"
+	ExportJobFailed    ExportJobStatus = "failed"
+)
+
+// ExportFilter selects the orders to export; From and To bound created_at
+type ExportFilter struct {
+	TenantID string     `bson:"tenant_id,omitempty" json:"tenant_id,omitempty" form:"tenant_id"`
+	SellerID string     `bson:"seller_id,omitempty" json:"seller_id,omitempty" form:"seller_id"`
+	HubID    string     `bson:"hub_id,omitempty" json:"hub_id,omitempty" form:"hub_id"`
+	Status   string     `bson:"status,omitempty" json:"status,omitempty" form:"status"`
+	From     *time.Time `bson:"from,omitempty" json:"from,omitempty" form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `bson:"to,omitempty" json:"to,omitempty" form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// ExportJob tracks an export written to a file in the background
+type ExportJob struct {
+	ID          bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Format      string          `bson:"format" json:"format"`
+	Filter      ExportFilter    `bson:"filter" json:"filter"`
+	Status      ExportJobStatus `bson:"status" json:"status"`
+	Location    string          `bson:"location,omitempty" json:"-"` // where the file is written
+	Orders      int             `bson:"orders" json:"orders"`        // orders written so far
+	Error       string          `bson:"error,omitempty" json:"error,omitempty"`
+	RequestID   string          `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	CreatedAt   time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time      `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HoldReason explains why an order is on_hold. An order is released to new_order once
+// every reason has been cleared, either by the release rules or by request.
+type HoldReason string
+
+const (
+	HoldReasonPaymentPending      HoldReason = "payment_pending"
+	HoldReasonStockShort          HoldReason = "stock_short"
+	HoldReasonAddressVerification HoldReason = "address_verification"
+	HoldReasonFraudReview         HoldReason = "fraud_review"
+)
+
+var holdReasons = map[HoldReason]bool{
+	HoldReasonPaymentPending:      true,
+	HoldReasonStockShort:          true,
+	HoldReasonAddressVerification: true,
+	HoldReasonFraudReview:         true,
+}
+
+func IsValidHoldReason(reason HoldReason) bool {
+	return holdReasons[reason]
+}
+
+// HasHold reports whether reason is among the order's outstanding holds
+func (o *Order) HasHold(reason HoldReason) bool {
+	for _, held := range o.HoldReasons {
+		if held == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// PlaceHold adds a hold reason, moving a new_order back to on_hold
+func (o *Order) PlaceHold(reason HoldReason) error {
+	if !IsValidHoldReason(reason) {
+		return fmt.Errorf("%w: unknown hold reason %q", ErrInvalidOrder, reason)
+	}
+	if o.IsSplit() {
+		return ErrOrderIsSplit
+	}
+	if o.Status != OrderStatusOnHold && o.Status != OrderStatusNewOrder {
+		return fmt.Errorf("%w: cannot hold an order in %s", ErrInvalidStatusTransition, o.Status)
+	}
+	if !o.HasHold(reason) {
+		o.HoldReasons = append(o.HoldReasons, reason)
+	}
+	o.Status = OrderStatusOnHold
+	return nil
+}
+
+// ClearHolds removes the given reasons. When the last reason is cleared the order is
+// released to new_order and true is returned.
+func (o *Order) ClearHolds(reasons ...HoldReason) (bool, error) {
+	if o.IsSplit() {
+		return false, ErrOrderIsSplit
+	}
+	if o.Status != OrderStatusOnHold {
+		return false, fmt.Errorf("%w: order is %s, not on hold", ErrInvalidStatusTransition, o.Status)
+	}
+
+	remaining := o.HoldReasons[:0]
+	cleared := 0
+	for _, held := range o.HoldReasons {
+		if containsHoldReason(reasons, held) {
+			cleared++
+			continue
+		}
+		remaining = append(remaining, held)
+	}
+	if cleared == 0 {
+		return false, fmt.Errorf("%w: order is not held for %s", ErrInvalidStatusTransition, joinHoldReasons(reasons))
+	}
+
+	o.HoldReasons = remaining
+	if len(o.HoldReasons) > 0 {
+		return false, nil
+	}
+	o.HoldReasons = nil
+	o.Status = OrderStatusNewOrder
+	return true, nil
+}
+
+func (o *Order) validateHolds() []error {
+	var problems []error
+	for _, reason := range o.HoldReasons {
+		if !IsValidHoldReason(reason) {
+			problems = append(problems, fmt.Errorf("hold_reasons: %q is not a known hold reason", reason))
+		}
+	}
+	if len(o.HoldReasons) > 0 && o.Status != OrderStatusOnHold {
+		problems = append(problems, fmt.Errorf("hold_reasons are only allowed on on_hold orders, not %s", o.Status))
+	}
+	return problems
+}
+
+func containsHoldReason(reasons []HoldReason, reason HoldReason) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func joinHoldReasons(reasons []HoldReason) string {
+	names := make([]string, len(reasons))
+	for i, reason := range reasons {
+		names[i] = string(reason)
+	}
+	return strings.Join(names, ", ")
+}
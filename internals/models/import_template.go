@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ColumnMapping reads the file column Header into the bulk column Column, e.g. "Qty" into quantity
+type ColumnMapping struct {
+	Header string `bson:"header" json:"header"`
+	Column string `bson:"column" json:"column"`
+}
+
+// ImportTemplate describes a seller's own bulk file layout. Defaults holds constant values,
+// keyed by bulk column, for rows that leave the column empty.
+type ImportTemplate struct {
+	ID        bson.ObjectID     `bson:"_id,omitempty" json:"id"`
+	SellerID  string            `bson:"seller_id" json:"seller_id"`
+	Name      string            `bson:"name" json:"name"`
+	Columns   []ColumnMapping   `bson:"columns,omitempty" json:"columns,omitempty"`
+	Defaults  map[string]string `bson:"defaults,omitempty" json:"defaults,omitempty"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time         `bson:"updated_at" json:"updated_at"`
+}
@@ -2,11 +2,17 @@ package models
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// ErrInvalidOrder wraps every validation failure so callers can map it to a client error
+var ErrInvalidOrder = errors.New("invalid order")
+
 type OrderStatus string
 
 const (
@@ -15,19 +21,68 @@ const (
 )
 
 type Order struct {
-	ID        bson.ObjectID `bson:"_id,omitempty"  json:"id,omitempty"`
-	TenantID  string        `bson:"tenant_id" json:"tenant_id"`
-	SellerID  string        `bson:"seller_id" json:"seller_id"`
-	HubID     string        `bson:"hub_id" json:"hub_id"`
-	Status    OrderStatus   `bson:"status" json:"status"`
-	Items     []OrderItem   `bson:"items,omitempty" json:"items,omitempty"`
-	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+	ID       bson.ObjectID `bson:"_id,omitempty"  json:"id,omitempty"`
+	TenantID string        `bson:"tenant_id" json:"tenant_id"`
+	SellerID string        `bson:"seller_id" json:"seller_id"`
+	HubID    string        `bson:"hub_id" json:"hub_id"`
+	Status   OrderStatus   `bson:"status" json:"status"`
+	Items    []OrderItem   `bson:"items,omitempty" json:"items,omitempty"`
+	OrderRef string        `bson:"order_ref,omitempty" json:"order_ref,omitempty"` // seller's own order reference
+
+	// Outstanding reasons an on_hold order is held; none means it was held by hand
+	HoldReasons []HoldReason `bson:"hold_reasons,omitempty" json:"hold_reasons,omitempty"`
+
+	// Set when the order sat in its current status longer than the tenant's SLA; cleared on the next status change
+	SLABreachedAt *time.Time `bson:"sla_breached_at,omitempty" json:"sla_breached_at,omitempty"`
+
+	// Why the allocation engine chose HubID, empty when the seller named the hub
+	AllocationReason string `bson:"allocation_reason,omitempty" json:"allocation_reason,omitempty"`
+
+	// The bulk job that created the order, if any
+	BulkJobID string `bson:"bulk_job_id,omitempty" json:"bulk_job_id,omitempty"`
+
+	// Hub split links - a split parent's status is aggregated from its children
+	ParentOrderID *bson.ObjectID  `bson:"parent_order_id,omitempty" json:"parent_order_id,omitempty"`
+	ChildOrderIDs []bson.ObjectID `bson:"child_order_ids,omitempty" json:"child_order_ids,omitempty"`
+
+	Customer        *Customer `bson:"customer,omitempty" json:"customer,omitempty"`
+	ShippingAddress *Address  `bson:"shipping_address,omitempty" json:"shipping_address,omitempty"`
+	BillingAddress  *Address  `bson:"billing_address,omitempty" json:"billing_address,omitempty"`
+
+	// Pricing - Subtotal, DiscountTotal, TaxTotal and Total are computed by CalculateTotals
+	Currency      string          `bson:"currency,omitempty" json:"currency,omitempty"`
+	Subtotal      decimal.Decimal `bson:"subtotal" json:"subtotal"`
+	DiscountTotal decimal.Decimal `bson:"discount_total" json:"discount_total"`
+	TaxTotal      decimal.Decimal `bson:"tax_total" json:"tax_total"`
+	ShippingFee   decimal.Decimal `bson:"shipping_fee" json:"shipping_fee"`
+	Total         decimal.Decimal `bson:"total" json:"total"`
+
+	// Version increases with every stored change; edits must name the version they were based on
+	Version int64          `bson:"version" json:"version"`
+	History []HistoryEntry `bson:"history,omitempty" json:"history,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
 type OrderItem struct {
 	SKUCode  string `bson:"sku_code" json:"sku_code"`
 	Quantity int    `bson:"quantity" json:"quantity"`
+
+	// UnitPrice, Discount (for the whole line) and TaxRate (percent) are inputs;
+	// TaxAmount and LineTotal are computed by Order.CalculateTotals
+	UnitPrice decimal.Decimal `bson:"unit_price" json:"unit_price"`
+	Discount  decimal.Decimal `bson:"discount" json:"discount"`
+	TaxRate   decimal.Decimal `bson:"tax_rate" json:"tax_rate"`
+	TaxAmount decimal.Decimal `bson:"tax_amount" json:"tax_amount"`
+	LineTotal decimal.Decimal `bson:"line_total" json:"line_total"`
+
+	// Fulfilment tracking - Status is derived from the quantities by Order.RefreshStatus
+	Status            ItemStatus `bson:"status,omitempty" json:"status,omitempty"`
+	FulfilledQuantity int        `bson:"fulfilled_quantity" json:"fulfilled_quantity"`
+	DeliveredQuantity int        `bson:"delivered_quantity" json:"delivered_quantity"`
+	CancelledQuantity int        `bson:"cancelled_quantity" json:"cancelled_quantity"`
+	ReturnedQuantity  int        `bson:"returned_quantity" json:"returned_quantity"` // claimed by returns that were not rejected
 }
 
 func (o *Order) GetID() bson.ObjectID     { return o.ID }
@@ -39,9 +94,47 @@ func (o *Order) Initialise(ctx context.Context) {
 	if o.Status == "" {
 		o.Status = OrderStatusOnHold
 	}
+	if o.Customer != nil {
+		o.Customer.Normalise()
+	}
+	if o.ShippingAddress != nil {
+		o.ShippingAddress.Normalise()
+	}
+	if o.BillingAddress != nil {
+		o.BillingAddress.Normalise()
+	}
+	o.CalculateTotals()
+	o.refreshItemStatuses()
 }
 
+// Validate reports every problem with the order at once, wrapped in ErrInvalidOrder
 func (o *Order) Validate(ctx context.Context) error {
+	var problems []error
+
+	for i, item := range o.Items {
+		if item.SKUCode == "" {
+			problems = append(problems, fmt.Errorf("items[%d].sku_code is required", i))
+		}
+		if item.Quantity <= 0 {
+			problems = append(problems, fmt.Errorf("items[%d].quantity must be positive", i))
+		}
+	}
+	problems = append(problems, o.validatePricing()...)
+	problems = append(problems, o.validateHolds()...)
+
+	if o.Customer != nil {
+		problems = append(problems, o.Customer.validate()...)
+	}
+	if o.ShippingAddress != nil {
+		problems = append(problems, o.ShippingAddress.validate("shipping_address")...)
+	}
+	if o.BillingAddress != nil {
+		problems = append(problems, o.BillingAddress.validate("billing_address")...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, errors.Join(problems...))
+	}
 	return nil
 }
 
@@ -72,21 +165,144 @@ type OrderCSVRow struct {
 	TenantID string `csv:"tenant_id"`
 	SellerID string `csv:"seller_id"`
 	HubID    string `csv:"hub_id"`
+	OrderRef string `csv:"order_ref"`
 	SKUCode  string `csv:"sku_code"`
 	Quantity int    `csv:"quantity"`
+
+	// Item pricing; shipping_fee and currency belong to the order and are read from the
+	// first row of the order that fills them
+	UnitPrice   decimal.Decimal `csv:"unit_price"`
+	Discount    decimal.Decimal `csv:"discount"`
+	TaxRate     decimal.Decimal `csv:"tax_rate"`
+	ShippingFee decimal.Decimal `csv:"shipping_fee"`
+	Currency    string          `csv:"currency"`
+
+	CustomerName  string `csv:"customer_name"`
+	CustomerEmail string `csv:"customer_email"`
+	CustomerPhone string `csv:"customer_phone"`
+
+	ShippingName       string `csv:"shipping_name"`
+	ShippingLine1      string `csv:"shipping_address_line1"`
+	ShippingLine2      string `csv:"shipping_address_line2"`
+	ShippingCity       string `csv:"shipping_city"`
+	ShippingState      string `csv:"shipping_state"`
+	ShippingPostalCode string `csv:"shipping_postal_code"`
+	ShippingCountry    string `csv:"shipping_country"`
+	ShippingPhone      string `csv:"shipping_phone"`
+
+	BillingName       string `csv:"billing_name"`
+	BillingLine1      string `csv:"billing_address_line1"`
+	BillingLine2      string `csv:"billing_address_line2"`
+	BillingCity       string `csv:"billing_city"`
+	BillingState      string `csv:"billing_state"`
+	BillingPostalCode string `csv:"billing_postal_code"`
+	BillingCountry    string `csv:"billing_country"`
+	BillingPhone      string `csv:"billing_phone"`
+}
+
+// Customer returns the row's customer, or nil when no customer columns are filled
+func (r OrderCSVRow) Customer() *Customer {
+	if r.CustomerName == "" && r.CustomerEmail == "" && r.CustomerPhone == "" {
+		return nil
+	}
+	return &Customer{Name: r.CustomerName, Email: r.CustomerEmail, Phone: r.CustomerPhone}
+}
+
+// ShippingAddress returns the row's shipping address, or nil when no shipping columns are filled
+func (r OrderCSVRow) ShippingAddress() *Address {
+	address := Address{
+		Name: r.ShippingName, Line1: r.ShippingLine1, Line2: r.ShippingLine2, City: r.ShippingCity,
+		State: r.ShippingState, PostalCode: r.ShippingPostalCode, Country: r.ShippingCountry, Phone: r.ShippingPhone,
+	}
+	if address == (Address{}) {
+		return nil
+	}
+	return &address
+}
+
+// BillingAddress returns the row's billing address, or nil when no billing columns are filled
+func (r OrderCSVRow) BillingAddress() *Address {
+	address := Address{
+		Name: r.BillingName, Line1: r.BillingLine1, Line2: r.BillingLine2, City: r.BillingCity,
+		State: r.BillingState, PostalCode: r.BillingPostalCode, Country: r.BillingCountry, Phone: r.BillingPhone,
+	}
+	if address == (Address{}) {
+		return nil
+	}
+	return &address
+}
+
+// NewOrderCSVRows flattens an order into one row per item in the bulk import layout. The
+// order ID stands in for a missing order_ref so multi-item orders group together again
+// when the rows are re-imported.
+func NewOrderCSVRows(order *Order) []OrderCSVRow {
+	base := OrderCSVRow{
+		TenantID: order.TenantID,
+		SellerID: order.SellerID,
+		HubID:    order.HubID,
+		OrderRef: order.OrderRef,
+
+		ShippingFee: order.ShippingFee,
+		Currency:    order.Currency,
+	}
+	if base.OrderRef == "" {
+		base.OrderRef = order.ID.Hex()
+	}
+	if c := order.Customer; c != nil {
+		base.CustomerName, base.CustomerEmail, base.CustomerPhone = c.Name, c.Email, c.Phone
+	}
+	if a := order.ShippingAddress; a != nil {
+		base.ShippingName, base.ShippingLine1, base.ShippingLine2 = a.Name, a.Line1, a.Line2
+		base.ShippingCity, base.ShippingState, base.ShippingPostalCode = a.City, a.State, a.PostalCode
+		base.ShippingCountry, base.ShippingPhone = a.Country, a.Phone
+	}
+	if a := order.BillingAddress; a != nil {
+		base.BillingName, base.BillingLine1, base.BillingLine2 = a.Name, a.Line1, a.Line2
+		base.BillingCity, base.BillingState, base.BillingPostalCode = a.City, a.State, a.PostalCode
+		base.BillingCountry, base.BillingPhone = a.Country, a.Phone
+	}
+
+	rows := make([]OrderCSVRow, len(order.Items))
+	for i, item := range order.Items {
+		rows[i] = base
+		rows[i].SKUCode = item.SKUCode
+		rows[i].Quantity = item.Quantity
+		rows[i].UnitPrice, rows[i].Discount, rows[i].TaxRate = item.UnitPrice, item.Discount, item.TaxRate
+	}
+	return rows
+}
+
+// String masks personal data so rows can be logged safely with %v
+func (r OrderCSVRow) String() string {
+	return fmt.Sprintf("{TenantID:%s SellerID:%s HubID:%s OrderRef:%s SKUCode:%s Quantity:%d Customer:%v Shipping:%v Billing:%v}",
+		r.TenantID, r.SellerID, r.HubID, r.OrderRef, r.SKUCode, r.Quantity, r.Customer(), r.ShippingAddress(), r.BillingAddress())
 }
 
 type BulkOrderRequest struct {
-	FilePath string `json:"file_path"`
-	UserID   string `json:"user_id"`
-	UserName string `json:"user_name"`
+	FilePath   string `json:"file_path"`
+	UserID     string `json:"user_id"`
+	UserName   string `json:"user_name"`
+	Format     string `json:"format,omitempty"`      // csv, xlsx, json or ndjson; detected from the file when empty
+	Sheet      string `json:"sheet,omitempty"`       // workbook sheet to read, the first one when empty
+	TemplateID string `json:"template_id,omitempty"` // import template mapping the file's own headers
+	DryRun     bool   `json:"dry_run,omitempty"`     // check the file and report without creating orders
+	TenantID   string `json:"tenant_id,omitempty"`   // whose file it is, for fair scheduling; taken from the template when empty
+	SellerID   string `json:"seller_id,omitempty"`
 }
 
-// SQS Event Models - filepath, Userid, username
+// SQS Event Models - jobid, filepath, Userid, username, requestid
 type CreateBulkOrderEvent struct {
-	FilePath string `json:"file_path"`
-	UserID   string `json:"user_id"`
-	UserName string `json:"user_name"`
+	JobID      string `json:"job_id"`
+	FilePath   string `json:"file_path"`
+	UserID     string `json:"user_id"`
+	UserName   string `json:"user_name"`
+	Format     string `json:"format,omitempty"`
+	Sheet      string `json:"sheet,omitempty"`
+	TemplateID string `json:"template_id,omitempty"`
+	DryRun     bool   `json:"dry_run,omitempty"`
+	TenantID   string `json:"tenant_id,omitempty"`
+	SellerID   string `json:"seller_id,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
 }
 
 // Kafka Event models - orderid, tenantid, sellerid, hubid, items, createdat
@@ -0,0 +1,31 @@
+package models
+
+import "strings"
+
+// MaskText keeps the first character of each word and hides the rest, e.g. "John Doe" -> "J*** D**"
+func MaskText(value string) string {
+	words := strings.Fields(value)
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskEmail keeps the first character of the local part and the domain, e.g. "john@example.com" -> "j***@example.com"
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return MaskText(email)
+	}
+	return MaskText(email[:at]) + email[at:]
+}
+
+// MaskPhone keeps only the last four digits, e.g. "+971501234567" -> "*********4567"
+func MaskPhone(phone string) string {
+	runes := []rune(phone)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+}
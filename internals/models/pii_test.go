@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasking(t *testing.T) {
+	assert.Equal(t, "J*** D**", MaskText("John Doe"))
+	assert.Equal(t, "j***@example.com", MaskEmail("john@example.com"))
+	assert.Equal(t, "*********4567", MaskPhone("+971501234567"))
+}
+
+func TestOrder_LoggingMasksPII(t *testing.T) {
+	order := Order{
+		Customer:        &Customer{Name: "John Doe", Email: "john@example.com"},
+		ShippingAddress: &Address{Line1: "221B Baker Street", City: "London", PostalCode: "NW1 6XE", Country: "GB"},
+	}
+
+	logged := fmt.Sprintf("%v", order)
+
+	assert.NotContains(t, logged, "John Doe")
+	assert.NotContains(t, logged, "john@example.com")
+	assert.NotContains(t, logged, "Baker")
+	assert.NotContains(t, logged, "NW1 6XE")
+	assert.Contains(t, logged, "London")
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// CalculateTotals recomputes every derived amount on the order from unit prices,
+// discounts, tax rates and the shipping fee. Amounts sent by clients are never
+// trusted; this always runs before an order is persisted.
+func (o *Order) CalculateTotals() {
+	o.Currency = strings.ToUpper(strings.TrimSpace(o.Currency))
+	places := CurrencyMinorUnits(o.Currency)
+
+	subtotal := decimal.Zero
+	discountTotal := decimal.Zero
+	taxTotal := decimal.Zero
+
+	for i := range o.Items {
+		item := &o.Items[i]
+		lineSubtotal := item.UnitPrice.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		taxable := lineSubtotal.Sub(item.Discount)
+
+		item.TaxAmount = taxable.Mul(item.TaxRate).Div(hundred).Round(places)
+		item.LineTotal = taxable.Add(item.TaxAmount).Round(places)
+
+		subtotal = subtotal.Add(lineSubtotal)
+		discountTotal = discountTotal.Add(item.Discount)
+		taxTotal = taxTotal.Add(item.TaxAmount)
+	}
+
+	o.Subtotal = subtotal.Round(places)
+	o.DiscountTotal = discountTotal.Round(places)
+	o.TaxTotal = taxTotal.Round(places)
+	o.Total = o.Subtotal.Sub(o.DiscountTotal).Add(o.TaxTotal).Add(o.ShippingFee).Round(places)
+}
+
+// validatePricing checks the client supplied pricing inputs
+func (o *Order) validatePricing() []error {
+	var problems []error
+
+	priced := o.ShippingFee.IsPositive()
+	for i, item := range o.Items {
+		if item.UnitPrice.IsNegative() {
+			problems = append(problems, fmt.Errorf("items[%d].unit_price cannot be negative", i))
+		}
+		if item.Discount.IsNegative() {
+			problems = append(problems, fmt.Errorf("items[%d].discount cannot be negative", i))
+		}
+		if item.Discount.GreaterThan(item.UnitPrice.Mul(decimal.NewFromInt(int64(item.Quantity)))) {
+			problems = append(problems, fmt.Errorf("items[%d].discount exceeds the line amount", i))
+		}
+		if item.TaxRate.IsNegative() || item.TaxRate.GreaterThan(hundred) {
+			problems = append(problems, fmt.Errorf("items[%d].tax_rate must be between 0 and 100", i))
+		}
+		if item.UnitPrice.IsPositive() {
+			priced = true
+		}
+	}
+
+	if o.ShippingFee.IsNegative() {
+		problems = append(problems, errors.New("shipping_fee cannot be negative"))
+	}
+
+	// Unpriced orders (e.g. stock transfers) may omit the currency, priced ones may not
+	if o.Currency == "" {
+		if priced {
+			problems = append(problems, errors.New("currency is required for priced orders"))
+		}
+	} else if !IsValidCurrency(o.Currency) {
+		problems = append(problems, fmt.Errorf("currency %q is not a valid ISO 4217 code", o.Currency))
+	}
+
+	return problems
+}
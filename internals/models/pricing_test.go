@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_CalculateTotals(t *testing.T) {
+	order := &Order{
+		Currency:    "usd",
+		ShippingFee: decimal.RequireFromString("5.00"),
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 3, UnitPrice: decimal.RequireFromString("19.99"), Discount: decimal.RequireFromString("2.97"), TaxRate: decimal.RequireFromString("10")},
+			{SKUCode: "SKU002", Quantity: 1, UnitPrice: decimal.RequireFromString("0.10")},
+		},
+		// Client supplied totals must be ignored
+		Total: decimal.RequireFromString("1.00"),
+	}
+
+	order.CalculateTotals()
+
+	assert.Equal(t, "USD", order.Currency)
+	assert.Equal(t, "5.7", order.Items[0].TaxAmount.String())
+	assert.Equal(t, "62.7", order.Items[0].LineTotal.String())
+	assert.Equal(t, "60.07", order.Subtotal.String())
+	assert.Equal(t, "2.97", order.DiscountTotal.String())
+	assert.Equal(t, "5.7", order.TaxTotal.String())
+	assert.Equal(t, "67.8", order.Total.String())
+}
+
+func TestOrder_CalculateTotals_RoundsToCurrencyMinorUnits(t *testing.T) {
+	order := &Order{
+		Currency: "JPY",
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 1, UnitPrice: decimal.RequireFromString("999"), TaxRate: decimal.RequireFromString("8")},
+		},
+	}
+
+	order.CalculateTotals()
+
+	assert.Equal(t, "80", order.TaxTotal.String())
+	assert.Equal(t, "1079", order.Total.String())
+}
+
+func TestOrder_Validate_Pricing(t *testing.T) {
+	order := &Order{
+		Currency: "ABC",
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 1, UnitPrice: decimal.RequireFromString("-1")},
+			{SKUCode: "SKU002", Quantity: 1, UnitPrice: decimal.RequireFromString("5"), Discount: decimal.RequireFromString("6"), TaxRate: decimal.RequireFromString("150")},
+		},
+	}
+
+	err := order.Validate(context.Background())
+
+	assert.True(t, errors.Is(err, ErrInvalidOrder))
+	assert.Contains(t, err.Error(), "items[0].unit_price cannot be negative")
+	assert.Contains(t, err.Error(), "items[1].discount exceeds the line amount")
+	assert.Contains(t, err.Error(), "items[1].tax_rate must be between 0 and 100")
+	assert.Contains(t, err.Error(), `currency "ABC" is not a valid ISO 4217 code`)
+}
+
+func TestOrder_Validate_UnpricedOrderNeedsNoCurrency(t *testing.T) {
+	order := &Order{
+		Items: []OrderItem{{SKUCode: "SKU001", Quantity: 10}},
+	}
+
+	assert.NoError(t, order.Validate(context.Background()))
+}
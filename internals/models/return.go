@@ -0,0 +1,210 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusRestocked ReturnStatus = "restocked"
+)
+
+type ReturnReason string
+
+const (
+	ReturnReasonDamaged        ReturnReason = "damaged"
+	ReturnReasonDefective      ReturnReason = "defective"
+	ReturnReasonWrongItem      ReturnReason = "wrong_item"
+	ReturnReasonNotAsDescribed ReturnReason = "not_as_described"
+	ReturnReasonSizeOrFit      ReturnReason = "size_or_fit"
+	ReturnReasonNoLongerNeeded ReturnReason = "no_longer_needed"
+	ReturnReasonOther          ReturnReason = "other"
+)
+
+var validReturnReasons = map[ReturnReason]bool{
+	ReturnReasonDamaged: true, ReturnReasonDefective: true, ReturnReasonWrongItem: true,
+	ReturnReasonNotAsDescribed: true, ReturnReasonSizeOrFit: true, ReturnReasonNoLongerNeeded: true,
+	ReturnReasonOther: true,
+}
+
+var ErrInvalidReturn = errors.New("invalid return")
+
+// Return (RMA) records items a customer sends back from a delivered order
+type Return struct {
+	ID              bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	OrderID         bson.ObjectID `bson:"order_id" json:"order_id"`
+	TenantID        string        `bson:"tenant_id" json:"tenant_id"`
+	SellerID        string        `bson:"seller_id" json:"seller_id"`
+	HubID           string        `bson:"hub_id" json:"hub_id"`                                         // hub the order shipped from
+	ReceivingHubID  string        `bson:"receiving_hub_id,omitempty" json:"receiving_hub_id,omitempty"` // hub the goods came back to
+	Status          ReturnStatus  `bson:"status" json:"status"`
+	Items           []ReturnItem  `bson:"items" json:"items"`
+	Notes           string        `bson:"notes,omitempty" json:"notes,omitempty"`
+	RejectionReason string        `bson:"rejection_reason,omitempty" json:"rejection_reason,omitempty"`
+	ApprovedAt      *time.Time    `bson:"approved_at,omitempty" json:"approved_at,omitempty"`
+	ReceivedAt      *time.Time    `bson:"received_at,omitempty" json:"received_at,omitempty"`
+	RestockedAt     *time.Time    `bson:"restocked_at,omitempty" json:"restocked_at,omitempty"`
+	CreatedAt       time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type ReturnItem struct {
+	SKUCode           string       `bson:"sku_code" json:"sku_code" binding:"required"`
+	Quantity          int          `bson:"quantity" json:"quantity" binding:"required"`
+	Reason            ReturnReason `bson:"reason" json:"reason" binding:"required"`
+	ReceivedQuantity  int          `bson:"received_quantity" json:"received_quantity"`
+	RestockedQuantity int          `bson:"restocked_quantity" json:"restocked_quantity"`
+}
+
+func (r *Return) GetID() bson.ObjectID     { return r.ID }
+func (r *Return) SetID(id bson.ObjectID)   { r.ID = id }
+func (r *Return) SetCreatedAt(t time.Time) { r.CreatedAt = t }
+func (r *Return) SetUpdatedAt(t time.Time) { r.UpdatedAt = t }
+
+func (r *Return) Initialise(ctx context.Context) {
+	if r.Status == "" {
+		r.Status = ReturnStatusRequested
+	}
+}
+
+func (r *Return) Validate(ctx context.Context) error {
+	var problems []error
+	if r.OrderID.IsZero() {
+		problems = append(problems, errors.New("order_id is required"))
+	}
+	if len(r.Items) == 0 {
+		problems = append(problems, errors.New("at least one item is required"))
+	}
+	for i, item := range r.Items {
+		if item.Quantity <= 0 {
+			problems = append(problems, fmt.Errorf("items[%d].quantity must be positive", i))
+		}
+		if !validReturnReasons[item.Reason] {
+			problems = append(problems, fmt.Errorf("items[%d].reason %q is not a valid reason code", i, item.Reason))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidReturn, errors.Join(problems...))
+	}
+	return nil
+}
+
+// Quantities returns the requested quantity per SKU, as used against the order
+func (r *Return) Quantities() []ItemQuantity {
+	quantities := make([]ItemQuantity, len(r.Items))
+	for i, item := range r.Items {
+		quantities[i] = ItemQuantity{SKUCode: item.SKUCode, Quantity: item.Quantity}
+	}
+	return quantities
+}
+
+func (r *Return) Approve(at time.Time) error {
+	if r.Status != ReturnStatusRequested {
+		return fmt.Errorf("%w: cannot approve a return in %s", ErrInvalidStatusTransition, r.Status)
+	}
+	r.Status = ReturnStatusApproved
+	r.ApprovedAt = &at
+	return nil
+}
+
+func (r *Return) Reject(reason string) error {
+	if r.Status != ReturnStatusRequested {
+		return fmt.Errorf("%w: cannot reject a return in %s", ErrInvalidStatusTransition, r.Status)
+	}
+	r.Status = ReturnStatusRejected
+	r.RejectionReason = reason
+	return nil
+}
+
+// RevertRejection reopens a rejected return, for when its quantities could not be given back
+// to the order
+func (r *Return) RevertRejection() error {
+	if r.Status != ReturnStatusRejected {
+		return fmt.Errorf("%w: cannot reopen a return in %s", ErrInvalidStatusTransition, r.Status)
+	}
+	r.Status = ReturnStatusRequested
+	r.RejectionReason = ""
+	return nil
+}
+
+// Receive records what actually arrived at hubID, which may differ from the order's hub.
+// Received quantities cannot exceed what was approved for return.
+func (r *Return) Receive(hubID string, quantities []ItemQuantity, at time.Time) error {
+	if r.Status != ReturnStatusApproved {
+		return fmt.Errorf("%w: cannot receive a return in %s", ErrInvalidStatusTransition, r.Status)
+	}
+	if hubID == "" {
+		return fmt.Errorf("%w: hub_id is required", ErrInvalidReturn)
+	}
+
+	received, err := r.resolve(quantities, func(item ReturnItem) int { return item.Quantity }, "approved")
+	if err != nil {
+		return err
+	}
+	for index, quantity := range received {
+		r.Items[index].ReceivedQuantity = quantity
+	}
+
+	r.ReceivingHubID = hubID
+	r.Status = ReturnStatusReceived
+	r.ReceivedAt = &at
+	return nil
+}
+
+// Restock puts received goods back into sellable stock at the receiving hub.
+// Quantities not restocked (e.g. damaged goods) are simply left out.
+func (r *Return) Restock(quantities []ItemQuantity, at time.Time) error {
+	if r.Status != ReturnStatusReceived {
+		return fmt.Errorf("%w: cannot restock a return in %s", ErrInvalidStatusTransition, r.Status)
+	}
+
+	restocked, err := r.resolve(quantities, func(item ReturnItem) int { return item.ReceivedQuantity }, "received")
+	if err != nil {
+		return err
+	}
+	for index, quantity := range restocked {
+		r.Items[index].RestockedQuantity = quantity
+	}
+
+	r.Status = ReturnStatusRestocked
+	r.RestockedAt = &at
+	return nil
+}
+
+func (r *Return) resolve(quantities []ItemQuantity, limit func(ReturnItem) int, label string) (map[int]int, error) {
+	resolved := map[int]int{}
+	for _, q := range quantities {
+		index := -1
+		for i := range r.Items {
+			if r.Items[i].SKUCode == q.SKUCode {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s is not on the return", ErrInvalidQuantity, q.SKUCode)
+		}
+		if q.Quantity < 0 {
+			return nil, fmt.Errorf("%w: %s quantity cannot be negative", ErrInvalidQuantity, q.SKUCode)
+		}
+		resolved[index] += q.Quantity
+	}
+
+	for index, quantity := range resolved {
+		if available := limit(r.Items[index]); quantity > available {
+			return nil, fmt.Errorf("%w: %s has %d %s, %d given", ErrInvalidQuantity, r.Items[index].SKUCode, available, label, quantity)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deliveredOrder() *Order {
+	return &Order{
+		Status: OrderStatusDelivered,
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 3, FulfilledQuantity: 3, DeliveredQuantity: 3},
+		},
+	}
+}
+
+func TestOrder_RecordReturn_LimitedToDelivered(t *testing.T) {
+	order := deliveredOrder()
+
+	require.NoError(t, order.RecordReturn([]ItemQuantity{{SKUCode: "SKU001", Quantity: 2}}))
+	assert.Equal(t, 2, order.Items[0].ReturnedQuantity)
+
+	err := order.RecordReturn([]ItemQuantity{{SKUCode: "SKU001", Quantity: 2}})
+	assert.True(t, errors.Is(err, ErrInvalidQuantity))
+
+	require.NoError(t, order.ReleaseReturn([]ItemQuantity{{SKUCode: "SKU001", Quantity: 2}}))
+	assert.Equal(t, 0, order.Items[0].ReturnedQuantity)
+}
+
+func TestOrder_RecordReturn_RequiresDeliveredOrder(t *testing.T) {
+	order := deliveredOrder()
+	order.Status = OrderStatusShipped
+
+	err := order.RecordReturn([]ItemQuantity{{SKUCode: "SKU001", Quantity: 1}})
+
+	assert.True(t, errors.Is(err, ErrInvalidStatusTransition))
+}
+
+func TestReturn_Lifecycle(t *testing.T) {
+	ret := &Return{
+		Status: ReturnStatusRequested,
+		Items:  []ReturnItem{{SKUCode: "SKU001", Quantity: 2, Reason: ReturnReasonDamaged}},
+	}
+	now := time.Now()
+
+	assert.True(t, errors.Is(ret.Receive("hub2", nil, now), ErrInvalidStatusTransition))
+	require.NoError(t, ret.Approve(now))
+
+	err := ret.Receive("hub2", []ItemQuantity{{SKUCode: "SKU001", Quantity: 3}}, now)
+	assert.True(t, errors.Is(err, ErrInvalidQuantity))
+
+	require.NoError(t, ret.Receive("hub2", []ItemQuantity{{SKUCode: "SKU001", Quantity: 2}}, now))
+	assert.Equal(t, "hub2", ret.ReceivingHubID)
+
+	require.NoError(t, ret.Restock([]ItemQuantity{{SKUCode: "SKU001", Quantity: 1}}, now))
+	assert.Equal(t, ReturnStatusRestocked, ret.Status)
+	assert.Equal(t, 1, ret.Items[0].RestockedQuantity)
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ShipmentStatus string
+
+const (
+	ShipmentStatusCreated   ShipmentStatus = "created"
+	ShipmentStatusInTransit ShipmentStatus = "in_transit"
+	ShipmentStatusDelivered ShipmentStatus = "delivered"
+)
+
+var ErrInvalidShipment = errors.New("invalid shipment")
+
+// Shipment is one parcel sent for an order. An order may ship in several parcels.
+type Shipment struct {
+	ID             bson.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	OrderID        bson.ObjectID  `bson:"order_id" json:"order_id"`
+	TenantID       string         `bson:"tenant_id" json:"tenant_id"`
+	SellerID       string         `bson:"seller_id" json:"seller_id"`
+	HubID          string         `bson:"hub_id" json:"hub_id"`
+	Status         ShipmentStatus `bson:"status" json:"status"`
+	Items          []ItemQuantity `bson:"items" json:"items"`
+	Carrier        string         `bson:"carrier,omitempty" json:"carrier,omitempty"`
+	TrackingNumber string         `bson:"tracking_number,omitempty" json:"tracking_number,omitempty"` // carrier AWB
+	TrackingURL    string         `bson:"tracking_url,omitempty" json:"tracking_url,omitempty"`
+	Package        *Package       `bson:"package,omitempty" json:"package,omitempty"`
+	ShippedAt      *time.Time     `bson:"shipped_at,omitempty" json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time     `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+type Package struct {
+	WeightKg float64 `bson:"weight_kg" json:"weight_kg"`
+	LengthCm float64 `bson:"length_cm,omitempty" json:"length_cm,omitempty"`
+	WidthCm  float64 `bson:"width_cm,omitempty" json:"width_cm,omitempty"`
+	HeightCm float64 `bson:"height_cm,omitempty" json:"height_cm,omitempty"`
+}
+
+func (s *Shipment) GetID() bson.ObjectID     { return s.ID }
+func (s *Shipment) SetID(id bson.ObjectID)   { s.ID = id }
+func (s *Shipment) SetCreatedAt(t time.Time) { s.CreatedAt = t }
+func (s *Shipment) SetUpdatedAt(t time.Time) { s.UpdatedAt = t }
+
+func (s *Shipment) Initialise(ctx context.Context) {
+	if s.Status == "" {
+		s.Status = ShipmentStatusCreated
+	}
+}
+
+func (s *Shipment) Validate(ctx context.Context) error {
+	var problems []error
+	if s.OrderID.IsZero() {
+		problems = append(problems, errors.New("order_id is required"))
+	}
+	if len(s.Items) == 0 {
+		problems = append(problems, errors.New("at least one item is required"))
+	}
+	for i, item := range s.Items {
+		if item.Quantity <= 0 {
+			problems = append(problems, fmt.Errorf("items[%d].quantity must be positive", i))
+		}
+	}
+	if s.Package != nil && s.Package.WeightKg < 0 {
+		problems = append(problems, errors.New("package.weight_kg cannot be negative"))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidShipment, errors.Join(problems...))
+	}
+	return nil
+}
+
+// AttachTracking records the carrier's AWB and puts the shipment in transit
+func (s *Shipment) AttachTracking(carrier, trackingNumber, trackingURL string, at time.Time) error {
+	if s.Status == ShipmentStatusDelivered {
+		return fmt.Errorf("%w: shipment is already delivered", ErrInvalidStatusTransition)
+	}
+	if trackingNumber == "" {
+		return fmt.Errorf("%w: tracking_number is required", ErrInvalidShipment)
+	}
+
+	if carrier != "" {
+		s.Carrier = carrier
+	}
+	s.TrackingNumber = trackingNumber
+	s.TrackingURL = trackingURL
+	if s.ShippedAt == nil {
+		s.ShippedAt = &at
+	}
+	s.Status = ShipmentStatusInTransit
+	return nil
+}
+
+// MarkDelivered closes the shipment
+func (s *Shipment) MarkDelivered(at time.Time) error {
+	if s.Status == ShipmentStatusDelivered {
+		return fmt.Errorf("%w: shipment is already delivered", ErrInvalidStatusTransition)
+	}
+	s.DeliveredAt = &at
+	s.Status = ShipmentStatusDelivered
+	return nil
+}
+
+// RevertDelivery reopens a delivered shipment, for when the delivery could not be recorded on
+// its order. It goes back in transit if it had tracking and to created otherwise.
+func (s *Shipment) RevertDelivery() error {
+	if s.Status != ShipmentStatusDelivered {
+		return fmt.Errorf("%w: shipment is not delivered", ErrInvalidStatusTransition)
+	}
+	s.DeliveredAt = nil
+	s.Status = ShipmentStatusCreated
+	if s.TrackingNumber != "" {
+		s.Status = ShipmentStatusInTransit
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrOrderIsSplit = fmt.Errorf("%w: order has been split, act on its child orders", ErrInvalidStatusTransition)
+
+// HubAssignment sends a quantity of one SKU to a hub when splitting an order
+type HubAssignment struct {
+	SKUCode  string `json:"sku_code" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
+	HubID    string `json:"hub_id" binding:"required"`
+}
+
+// IsSplit reports whether the order has been split into child orders
+func (o *Order) IsSplit() bool {
+	return len(o.ChildOrderIDs) > 0
+}
+
+// Split builds one child order per hub from the assignments. Every unit of every item
+// must be assigned exactly once so no quantity is lost. Unit prices and tax rates are
+// copied; line discounts are shared out by quantity with any rounding remainder going
+// to the last child. Each child is held for the parent's hold reasons, so a split never
+// skips a hold. The children are not persisted and the parent is not changed.
+func (o *Order) Split(assignments []HubAssignment) ([]*Order, error) {
+	if o.Status != OrderStatusOnHold && o.Status != OrderStatusNewOrder {
+		return nil, fmt.Errorf("%w: cannot split an order in %s", ErrInvalidStatusTransition, o.Status)
+	}
+	if o.IsSplit() {
+		return nil, ErrOrderIsSplit
+	}
+	if o.ParentOrderID != nil {
+		return nil, fmt.Errorf("%w: child orders cannot be split again", ErrInvalidStatusTransition)
+	}
+
+	assigned := map[string]int{}
+	byHub := map[string][]HubAssignment{}
+	for _, assignment := range assignments {
+		if assignment.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: %s quantity must be positive", ErrInvalidQuantity, assignment.SKUCode)
+		}
+		if o.itemIndex(assignment.SKUCode) < 0 {
+			return nil, fmt.Errorf("%w: %s is not on the order", ErrInvalidQuantity, assignment.SKUCode)
+		}
+		assigned[assignment.SKUCode] += assignment.Quantity
+		byHub[assignment.HubID] = append(byHub[assignment.HubID], assignment)
+	}
+	for _, item := range o.Items {
+		if assigned[item.SKUCode] != item.Quantity {
+			return nil, fmt.Errorf("%w: %s has %d ordered but %d assigned", ErrInvalidQuantity, item.SKUCode, item.Quantity, assigned[item.SKUCode])
+		}
+	}
+	if len(byHub) < 2 {
+		return nil, fmt.Errorf("%w: a split needs at least two hubs", ErrInvalidQuantity)
+	}
+
+	hubs := make([]string, 0, len(byHub))
+	for hubID := range byHub {
+		hubs = append(hubs, hubID)
+	}
+	sort.Strings(hubs)
+
+	places := CurrencyMinorUnits(o.Currency)
+	remainingDiscount := map[string]decimal.Decimal{}
+	remainingQuantity := map[string]int{}
+	for _, item := range o.Items {
+		remainingDiscount[item.SKUCode] = item.Discount
+		remainingQuantity[item.SKUCode] = item.Quantity
+	}
+
+	parentID := o.ID
+	children := make([]*Order, 0, len(hubs))
+	for _, hubID := range hubs {
+		child := &Order{
+			TenantID:        o.TenantID,
+			SellerID:        o.SellerID,
+			HubID:           hubID,
+			Status:          o.Status,
+			OrderRef:        o.OrderRef,
+			ParentOrderID:   &parentID,
+			Customer:        o.Customer,
+			ShippingAddress: o.ShippingAddress,
+			BillingAddress:  o.BillingAddress,
+			Currency:        o.Currency,
+			HoldReasons:     append([]HoldReason(nil), o.HoldReasons...),
+		}
+
+		for _, assignment := range byHub[hubID] {
+			item := o.Items[o.itemIndex(assignment.SKUCode)]
+
+			// The last share of a SKU takes whatever discount is left so the parts add up exactly
+			remainingQuantity[item.SKUCode] -= assignment.Quantity
+			discount := remainingDiscount[item.SKUCode]
+			if remainingQuantity[item.SKUCode] > 0 {
+				discount = item.Discount.Mul(decimal.NewFromInt(int64(assignment.Quantity))).
+					Div(decimal.NewFromInt(int64(item.Quantity))).Round(places)
+			}
+			remainingDiscount[item.SKUCode] = remainingDiscount[item.SKUCode].Sub(discount)
+
+			child.Items = append(child.Items, OrderItem{
+				SKUCode:   item.SKUCode,
+				Quantity:  assignment.Quantity,
+				UnitPrice: item.UnitPrice,
+				Discount:  discount,
+				TaxRate:   item.TaxRate,
+			})
+		}
+		children = append(children, child)
+	}
+
+	return children, nil
+}
+
+// MarkSplit links the persisted children to the parent and takes its status from them. The
+// parent's holds have moved to the children and are released there.
+func (o *Order) MarkSplit(children []*Order) {
+	o.ChildOrderIDs = make([]bson.ObjectID, len(children))
+	for i, child := range children {
+		o.ChildOrderIDs[i] = child.ID
+	}
+	o.HoldReasons = nil
+	o.Status = AggregateStatus(children)
+}
+
+// AggregateStatus derives a parent order's status from its children
+func AggregateStatus(children []*Order) OrderStatus {
+	counts := map[OrderStatus]int{}
+	for _, child := range children {
+		counts[child.Status]++
+	}
+	total := len(children)
+	cancelled := counts[OrderStatusCancelled]
+	delivered := counts[OrderStatusDelivered]
+	shipped := counts[OrderStatusShipped]
+
+	switch {
+	case total == 0:
+		return OrderStatusOnHold
+	case cancelled == total:
+		return OrderStatusCancelled
+	case delivered+cancelled == total:
+		return OrderStatusDelivered
+	case shipped+delivered+cancelled == total:
+		return OrderStatusShipped
+	case shipped+delivered+counts[OrderStatusPartiallyShipped] > 0:
+		return OrderStatusPartiallyShipped
+	case counts[OrderStatusNewOrder] > 0:
+		return OrderStatusNewOrder
+	default:
+		return OrderStatusOnHold
+	}
+}
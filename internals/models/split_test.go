@@ -0,0 +1,96 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestOrder_Split_SharesDiscountByQuantity(t *testing.T) {
+	order := &Order{
+		ID:       bson.NewObjectID(),
+		HubID:    "hub1",
+		Status:   OrderStatusOnHold,
+		Currency: "USD",
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 3, UnitPrice: decimal.RequireFromString("10"), Discount: decimal.RequireFromString("1.00")},
+			{SKUCode: "SKU002", Quantity: 1, UnitPrice: decimal.RequireFromString("5")},
+		},
+	}
+
+	children, err := order.Split([]HubAssignment{
+		{SKUCode: "SKU001", Quantity: 1, HubID: "hub1"},
+		{SKUCode: "SKU001", Quantity: 2, HubID: "hub2"},
+		{SKUCode: "SKU002", Quantity: 1, HubID: "hub2"},
+	})
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+
+	assert.Equal(t, "hub1", children[0].HubID)
+	assert.Equal(t, "0.33", children[0].Items[0].Discount.StringFixed(2))
+	assert.Equal(t, "hub2", children[1].HubID)
+	assert.Equal(t, "0.67", children[1].Items[0].Discount.StringFixed(2))
+	assert.Equal(t, order.ID, *children[1].ParentOrderID)
+
+	children[0].ID, children[1].ID = bson.NewObjectID(), bson.NewObjectID()
+	order.MarkSplit(children)
+	assert.True(t, order.IsSplit())
+	assert.ErrorIs(t, order.RecordFulfilment([]ItemQuantity{{SKUCode: "SKU002", Quantity: 1}}), ErrOrderIsSplit)
+}
+
+func TestOrder_Split_ChildrenKeepTheHolds(t *testing.T) {
+	order := newTestOrder(OrderStatusNewOrder)
+	require.NoError(t, order.PlaceHold(HoldReasonFraudReview))
+
+	children, err := order.Split([]HubAssignment{
+		{SKUCode: "SKU001", Quantity: 3, HubID: "hub1"},
+		{SKUCode: "SKU002", Quantity: 2, HubID: "hub2"},
+	})
+	require.NoError(t, err)
+	for _, child := range children {
+		assert.Equal(t, OrderStatusOnHold, child.Status)
+		assert.Equal(t, []HoldReason{HoldReasonFraudReview}, child.HoldReasons)
+		assert.ErrorIs(t, child.TransitionTo(OrderStatusNewOrder), ErrInvalidStatusTransition)
+	}
+
+	// The holds are released on the children, never on the parent
+	children[0].ID, children[1].ID = bson.NewObjectID(), bson.NewObjectID()
+	order.MarkSplit(children)
+	assert.Empty(t, order.HoldReasons)
+	assert.Equal(t, OrderStatusOnHold, order.Status)
+	_, err = order.ClearHolds(HoldReasonFraudReview)
+	assert.ErrorIs(t, err, ErrOrderIsSplit)
+
+	released, err := children[0].ClearHolds(HoldReasonFraudReview)
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, []HoldReason{HoldReasonFraudReview}, children[1].HoldReasons)
+}
+
+func TestOrder_Split_RequiresEveryUnitAssigned(t *testing.T) {
+	order := newTestOrder(OrderStatusNewOrder)
+
+	_, err := order.Split([]HubAssignment{
+		{SKUCode: "SKU001", Quantity: 3, HubID: "hub1"},
+		{SKUCode: "SKU002", Quantity: 1, HubID: "hub2"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	_, err = order.Split([]HubAssignment{
+		{SKUCode: "SKU001", Quantity: 3, HubID: "hub1"},
+		{SKUCode: "SKU002", Quantity: 2, HubID: "hub1"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+}
+
+func TestAggregateStatus(t *testing.T) {
+	child := func(status OrderStatus) *Order { return &Order{Status: status} }
+
+	assert.Equal(t, OrderStatusPartiallyShipped, AggregateStatus([]*Order{child(OrderStatusShipped), child(OrderStatusNewOrder)}))
+	assert.Equal(t, OrderStatusShipped, AggregateStatus([]*Order{child(OrderStatusShipped), child(OrderStatusCancelled)}))
+	assert.Equal(t, OrderStatusDelivered, AggregateStatus([]*Order{child(OrderStatusDelivered), child(OrderStatusCancelled)}))
+	assert.Equal(t, OrderStatusCancelled, AggregateStatus([]*Order{child(OrderStatusCancelled), child(OrderStatusCancelled)}))
+}
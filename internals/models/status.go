@@ -0,0 +1,260 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	OrderStatusPartiallyShipped OrderStatus = "partially_shipped"
+	OrderStatusShipped          OrderStatus = "shipped"
+	OrderStatusDelivered        OrderStatus = "delivered"
+	OrderStatusCancelled        OrderStatus = "cancelled"
+)
+
+type ItemStatus string
+
+const (
+	ItemStatusPending            ItemStatus = "pending"
+	ItemStatusPartiallyFulfilled ItemStatus = "partially_fulfilled"
+	ItemStatusFulfilled          ItemStatus = "fulfilled"
+	ItemStatusDelivered          ItemStatus = "delivered"
+	ItemStatusCancelled          ItemStatus = "cancelled"
+	ItemStatusReturned           ItemStatus = "returned"
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrInvalidQuantity         = errors.New("invalid item quantity")
+)
+
+// Status changes that may be requested directly. Shipping statuses are never set
+// by hand; they are derived from item quantities by RefreshStatus.
+var manualTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusOnHold:   {OrderStatusNewOrder, OrderStatusCancelled},
+	OrderStatusNewOrder: {OrderStatusOnHold, OrderStatusCancelled},
+}
+
+// CanTransitionTo reports whether the order may be moved to status by request
+func (o *Order) CanTransitionTo(status OrderStatus) bool {
+	for _, allowed := range manualTransitions[o.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo applies a requested status change. Cancelling cancels every item.
+func (o *Order) TransitionTo(status OrderStatus) error {
+	if o.IsSplit() {
+		return ErrOrderIsSplit
+	}
+	if !o.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, o.Status, status)
+	}
+	if status == OrderStatusNewOrder && len(o.HoldReasons) > 0 {
+		return fmt.Errorf("%w: order is still held for %s", ErrInvalidStatusTransition, joinHoldReasons(o.HoldReasons))
+	}
+	if status == OrderStatusCancelled {
+		o.HoldReasons = nil
+		for i := range o.Items {
+			o.Items[i].CancelledQuantity = o.Items[i].Quantity - o.Items[i].FulfilledQuantity
+		}
+		o.refreshItemStatuses()
+	}
+	o.Status = status
+	return nil
+}
+
+// ItemQuantity is a quantity of one SKU, used when fulfilling, cancelling or returning part of an order
+type ItemQuantity struct {
+	SKUCode  string `json:"sku_code" bson:"sku_code" binding:"required"`
+	Quantity int    `json:"quantity" bson:"quantity" binding:"required"`
+}
+
+// OpenQuantity is what is still left to fulfil on the item
+func (i OrderItem) OpenQuantity() int {
+	return i.Quantity - i.CancelledQuantity - i.FulfilledQuantity
+}
+
+// RecordFulfilment marks quantities of the given SKUs as fulfilled and re-derives
+// item and order statuses. Nothing is changed if any quantity is invalid.
+func (o *Order) RecordFulfilment(quantities []ItemQuantity) error {
+	if o.IsSplit() {
+		return ErrOrderIsSplit
+	}
+	if o.Status != OrderStatusNewOrder && o.Status != OrderStatusPartiallyShipped {
+		return fmt.Errorf("%w: cannot fulfil an order in %s", ErrInvalidStatusTransition, o.Status)
+	}
+
+	requested, err := o.resolveQuantities(quantities, OrderItem.OpenQuantity, "open")
+	if err != nil {
+		return err
+	}
+	for index, quantity := range requested {
+		o.Items[index].FulfilledQuantity += quantity
+	}
+
+	o.RefreshStatus()
+	return nil
+}
+
+// UndeliveredQuantity is what has been fulfilled but not yet delivered
+func (i OrderItem) UndeliveredQuantity() int {
+	return i.FulfilledQuantity - i.DeliveredQuantity
+}
+
+// RecordDelivery marks fulfilled quantities as delivered and re-derives statuses
+func (o *Order) RecordDelivery(quantities []ItemQuantity) error {
+	if o.IsSplit() {
+		return ErrOrderIsSplit
+	}
+	if o.Status != OrderStatusPartiallyShipped && o.Status != OrderStatusShipped {
+		return fmt.Errorf("%w: cannot deliver an order in %s", ErrInvalidStatusTransition, o.Status)
+	}
+
+	requested, err := o.resolveQuantities(quantities, OrderItem.UndeliveredQuantity, "undelivered")
+	if err != nil {
+		return err
+	}
+	for index, quantity := range requested {
+		o.Items[index].DeliveredQuantity += quantity
+	}
+
+	o.RefreshStatus()
+	return nil
+}
+
+// ReturnableQuantity is what has been delivered and is not already covered by a return
+func (i OrderItem) ReturnableQuantity() int {
+	return i.DeliveredQuantity - i.ReturnedQuantity
+}
+
+// RecordReturn claims delivered quantities for a return request
+func (o *Order) RecordReturn(quantities []ItemQuantity) error {
+	if o.IsSplit() {
+		return ErrOrderIsSplit
+	}
+	if o.Status != OrderStatusDelivered {
+		return fmt.Errorf("%w: only delivered orders can be returned, order is %s", ErrInvalidStatusTransition, o.Status)
+	}
+
+	requested, err := o.resolveQuantities(quantities, OrderItem.ReturnableQuantity, "returnable")
+	if err != nil {
+		return err
+	}
+	for index, quantity := range requested {
+		o.Items[index].ReturnedQuantity += quantity
+	}
+
+	o.refreshItemStatuses()
+	return nil
+}
+
+// ReleaseReturn gives back quantities claimed by a return that was rejected
+func (o *Order) ReleaseReturn(quantities []ItemQuantity) error {
+	requested, err := o.resolveQuantities(quantities, func(item OrderItem) int { return item.ReturnedQuantity }, "returned")
+	if err != nil {
+		return err
+	}
+	for index, quantity := range requested {
+		o.Items[index].ReturnedQuantity -= quantity
+	}
+
+	o.refreshItemStatuses()
+	return nil
+}
+
+// resolveQuantities maps each SKU to its item index and checks the total requested
+// per SKU against the limit returned by available
+func (o *Order) resolveQuantities(quantities []ItemQuantity, available func(OrderItem) int, label string) (map[int]int, error) {
+	if len(quantities) == 0 {
+		return nil, fmt.Errorf("%w: no items given", ErrInvalidQuantity)
+	}
+
+	requested := map[int]int{}
+	for _, q := range quantities {
+		if q.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: %s quantity must be positive", ErrInvalidQuantity, q.SKUCode)
+		}
+		index := o.itemIndex(q.SKUCode)
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s is not on the order", ErrInvalidQuantity, q.SKUCode)
+		}
+		requested[index] += q.Quantity
+	}
+
+	for index, quantity := range requested {
+		if limit := available(o.Items[index]); quantity > limit {
+			return nil, fmt.Errorf("%w: %s has %d %s, %d requested", ErrInvalidQuantity, o.Items[index].SKUCode, limit, label, quantity)
+		}
+	}
+	return requested, nil
+}
+
+func (o *Order) itemIndex(skuCode string) int {
+	for i := range o.Items {
+		if o.Items[i].SKUCode == skuCode {
+			return i
+		}
+	}
+	return -1
+}
+
+// RefreshStatus derives every item status and then the order status from item quantities.
+// Orders that have not started shipping keep their current (manual) status.
+func (o *Order) RefreshStatus() {
+	o.refreshItemStatuses()
+
+	if len(o.Items) == 0 {
+		return
+	}
+
+	allCancelled, allDone, allDelivered, anyFulfilled := true, true, true, false
+	for _, item := range o.Items {
+		if item.UndeliveredQuantity() > 0 {
+			allDelivered = false
+		}
+		if item.Status != ItemStatusCancelled {
+			allCancelled = false
+		}
+		if item.OpenQuantity() > 0 {
+			allDone = false
+		}
+		if item.FulfilledQuantity > 0 {
+			anyFulfilled = true
+		}
+	}
+
+	switch {
+	case allCancelled:
+		o.Status = OrderStatusCancelled
+	case anyFulfilled && allDone && allDelivered:
+		o.Status = OrderStatusDelivered
+	case anyFulfilled && allDone:
+		o.Status = OrderStatusShipped
+	case anyFulfilled:
+		o.Status = OrderStatusPartiallyShipped
+	}
+}
+
+func (o *Order) refreshItemStatuses() {
+	for i := range o.Items {
+		item := &o.Items[i]
+		switch {
+		case item.CancelledQuantity >= item.Quantity:
+			item.Status = ItemStatusCancelled
+		case item.ReturnedQuantity > 0 && item.ReturnedQuantity >= item.FulfilledQuantity:
+			item.Status = ItemStatusReturned
+		case item.OpenQuantity() <= 0 && item.FulfilledQuantity > 0 && item.UndeliveredQuantity() <= 0:
+			item.Status = ItemStatusDelivered
+		case item.OpenQuantity() <= 0:
+			item.Status = ItemStatusFulfilled
+		case item.FulfilledQuantity > 0:
+			item.Status = ItemStatusPartiallyFulfilled
+		default:
+			item.Status = ItemStatusPending
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOrder(status OrderStatus) *Order {
+	return &Order{
+		Status: status,
+		Items: []OrderItem{
+			{SKUCode: "SKU001", Quantity: 3},
+			{SKUCode: "SKU002", Quantity: 2},
+		},
+	}
+}
+
+func TestOrder_RecordFulfilment_PartialThenFull(t *testing.T) {
+	order := newTestOrder(OrderStatusNewOrder)
+
+	require.NoError(t, order.RecordFulfilment([]ItemQuantity{{SKUCode: "SKU001", Quantity: 3}}))
+	assert.Equal(t, OrderStatusPartiallyShipped, order.Status)
+	assert.Equal(t, ItemStatusFulfilled, order.Items[0].Status)
+	assert.Equal(t, ItemStatusPending, order.Items[1].Status)
+
+	require.NoError(t, order.RecordFulfilment([]ItemQuantity{{SKUCode: "SKU002", Quantity: 1}}))
+	assert.Equal(t, ItemStatusPartiallyFulfilled, order.Items[1].Status)
+
+	require.NoError(t, order.RecordFulfilment([]ItemQuantity{{SKUCode: "SKU002", Quantity: 1}}))
+	assert.Equal(t, OrderStatusShipped, order.Status)
+}
+
+func TestOrder_RecordFulfilment_RejectsOverFulfilment(t *testing.T) {
+	order := newTestOrder(OrderStatusNewOrder)
+
+	err := order.RecordFulfilment([]ItemQuantity{
+		{SKUCode: "SKU001", Quantity: 2},
+		{SKUCode: "SKU001", Quantity: 2},
+	})
+
+	assert.True(t, errors.Is(err, ErrInvalidQuantity))
+	assert.Equal(t, 0, order.Items[0].FulfilledQuantity)
+	assert.Equal(t, OrderStatusNewOrder, order.Status)
+}
+
+func TestOrder_RecordFulfilment_RequiresReleasedOrder(t *testing.T) {
+	order := newTestOrder(OrderStatusOnHold)
+
+	err := order.RecordFulfilment([]ItemQuantity{{SKUCode: "SKU001", Quantity: 1}})
+
+	assert.True(t, errors.Is(err, ErrInvalidStatusTransition))
+}
+
+func TestOrder_TransitionTo(t *testing.T) {
+	order := newTestOrder(OrderStatusOnHold)
+
+	assert.True(t, errors.Is(order.TransitionTo(OrderStatusShipped), ErrInvalidStatusTransition))
+
+	require.NoError(t, order.TransitionTo(OrderStatusCancelled))
+	assert.Equal(t, OrderStatusCancelled, order.Status)
+	assert.Equal(t, 3, order.Items[0].CancelledQuantity)
+	assert.Equal(t, ItemStatusCancelled, order.Items[1].Status)
+}
+
+func TestOrder_RecordDelivery(t *testing.T) {
+	order := newTestOrder(OrderStatusNewOrder)
+	require.NoError(t, order.RecordFulfilment([]ItemQuantity{
+		{SKUCode: "SKU001", Quantity: 3},
+		{SKUCode: "SKU002", Quantity: 2},
+	}))
+	require.Equal(t, OrderStatusShipped, order.Status)
+
+	require.NoError(t, order.RecordDelivery([]ItemQuantity{{SKUCode: "SKU001", Quantity: 3}}))
+	assert.Equal(t, OrderStatusShipped, order.Status)
+	assert.Equal(t, ItemStatusDelivered, order.Items[0].Status)
+
+	assert.True(t, errors.Is(order.RecordDelivery([]ItemQuantity{{SKUCode: "SKU001", Quantity: 1}}), ErrInvalidQuantity))
+
+	require.NoError(t, order.RecordDelivery([]ItemQuantity{{SKUCode: "SKU002", Quantity: 2}}))
+	assert.Equal(t, OrderStatusDelivered, order.Status)
+}
+
+func TestOrder_Holds(t *testing.T) {
+	order := newTestOrder(OrderStatusNewOrder)
+
+	require.NoError(t, order.PlaceHold(HoldReasonPaymentPending))
+	require.NoError(t, order.PlaceHold(HoldReasonFraudReview))
+	assert.Equal(t, OrderStatusOnHold, order.Status)
+	assert.True(t, errors.Is(order.PlaceHold("weather"), ErrInvalidOrder))
+
+	// Manual release is refused while reasons are outstanding
+	assert.True(t, errors.Is(order.TransitionTo(OrderStatusNewOrder), ErrInvalidStatusTransition))
+
+	released, err := order.ClearHolds(HoldReasonPaymentPending)
+	require.NoError(t, err)
+	assert.False(t, released)
+	assert.Equal(t, []HoldReason{HoldReasonFraudReview}, order.HoldReasons)
+
+	released, err = order.ClearHolds(HoldReasonFraudReview)
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, OrderStatusNewOrder, order.Status)
+}
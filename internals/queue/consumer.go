@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/omniful/go_commons/sqs"
+)
+
+// Handler processes one message taken off a queue
+type Handler func(ctx context.Context, message *sqs.Message) error
+
+// Source is a queue messages are received from
+type Source interface {
+	Receive(ctx context.Context) (*Delivery, error)
+}
+
+// DeadLetters keeps messages that failed on every receive so they can be looked at and replayed
+type DeadLetters interface {
+	DeadLetter(ctx context.Context, queue string, message *sqs.Message, receiveCount int, reason error) error
+}
+
+// ConsumerOptions tunes how a consumer handles messages
+type ConsumerOptions struct {
+	MaxReceives int // receives before a failing message is dead-lettered
+	Workers     int // messages handled at once
+}
+
+// Consumer hands messages from a source to a handler. A message whose handler fails is
+// received again, and once it has failed MaxReceives times it is moved to the dead letters
+// with the error instead of being retried forever.
+type Consumer struct {
+	queue       string
+	source      Source
+	handler     Handler
+	deadLetters DeadLetters
+	options     ConsumerOptions
+}
+
+func NewConsumer(queue string, source Source, handler Handler, deadLetters DeadLetters, options ConsumerOptions) *Consumer {
+	if options.MaxReceives <= 0 {
+		options.MaxReceives = 1
+	}
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	return &Consumer{
+		queue:       queue,
+		source:      source,
+		handler:     handler,
+		deadLetters: deadLetters,
+		options:     options,
+	}
+}
+
+// Run handles messages until ctx is done
+func (c *Consumer) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for range c.options.Workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				delivery, err := c.source.Receive(ctx)
+				if err != nil {
+					return
+				}
+				c.handle(ctx, delivery)
+			}
+		}()
+	}
+	workers.Wait()
+}
+
+func (c *Consumer) handle(ctx context.Context, delivery *Delivery) {
+	message := delivery.Message
+	err := c.call(ctx, message)
+	if err == nil {
+		delivery.Ack()
+		return
+	}
+
+	if delivery.ReceiveCount < c.options.MaxReceives {
+		log.Printf("Queue %s: message %s failed on receive %d of %d, retrying: %v",
+			c.queue, message.DeduplicationId, delivery.ReceiveCount, c.options.MaxReceives, err)
+		delivery.Retry()
+		return
+	}
+
+	// Keep the message on the queue if it cannot be set aside, so it is never lost
+	if dlqErr := c.deadLetters.DeadLetter(ctx, c.queue, message, delivery.ReceiveCount, err); dlqErr != nil {
+		log.Printf("Queue %s: failed to dead-letter message %s: %v", c.queue, message.DeduplicationId, dlqErr)
+		delivery.Retry()
+		return
+	}
+	log.Printf("Queue %s: message %s dead-lettered after %d receives: %v",
+		c.queue, message.DeduplicationId, delivery.ReceiveCount, err)
+	delivery.Ack()
+}
+
+// call runs the handler, turning a panic into an error so one bad message cannot stop a worker
+func (c *Consumer) call(ctx context.Context, message *sqs.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return c.handler(ctx, message)
+}
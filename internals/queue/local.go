@@ -0,0 +1,203 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/omniful/go_commons/sqs"
+)
+
+// deduplicationWindow is how long SQS FIFO queues drop messages with a deduplication ID already seen
+const deduplicationWindow = 5 * time.Minute
+
+// Delivery is a message received from a queue. Exactly one of Ack or Retry must be called
+// once the message has been handled.
+type Delivery struct {
+	Message      *sqs.Message
+	ReceiveCount int // times the message has been received, this delivery included
+	ack          func()
+	retry        func()
+}
+
+// Ack removes the message from the queue
+func (d *Delivery) Ack() { d.ack() }
+
+// Retry returns the message to the queue to be received again
+func (d *Delivery) Retry() { d.retry() }
+
+// LocalQueue is an in-memory stand-in for an SQS FIFO queue, so queued work runs locally
+// without SQS. Like a FIFO queue it keeps each message group in order with at most one of its
+// messages in flight, drops messages whose deduplication ID was seen within the last five
+// minutes and hands a message out again, counting receives, until it is acknowledged.
+// Retried messages wait retryDelay first, as they would for the SQS visibility timeout.
+type LocalQueue struct {
+	name       string
+	retryDelay time.Duration
+
+	mu       sync.Mutex
+	messages []*localMessage      // unacknowledged messages in publish order
+	inFlight map[string]bool      // groups with a message being handled
+	seen     map[string]time.Time // deduplication IDs by publish time
+	changed  chan struct{}        // closed and replaced whenever a message may have become receivable
+}
+
+type localMessage struct {
+	message   *sqs.Message
+	receives  int
+	visibleAt time.Time
+}
+
+func NewLocalQueue(name string, retryDelay time.Duration) *LocalQueue {
+	return &LocalQueue{
+		name:       name,
+		retryDelay: retryDelay,
+		inFlight:   make(map[string]bool),
+		seen:       make(map[string]time.Time),
+		changed:    make(chan struct{}),
+	}
+}
+
+func (q *LocalQueue) Name() string {
+	return q.name
+}
+
+func (q *LocalQueue) Publish(ctx context.Context, message *sqs.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for id, publishedAt := range q.seen {
+		if now.Sub(publishedAt) >= deduplicationWindow {
+			delete(q.seen, id)
+		}
+	}
+	if message.DeduplicationId != "" {
+		if _, ok := q.seen[message.DeduplicationId]; ok {
+			return nil
+		}
+		q.seen[message.DeduplicationId] = now
+	}
+
+	q.messages = append(q.messages, &localMessage{message: message, visibleAt: now})
+	q.notify()
+	return nil
+}
+
+// Receive waits for the next message whose group has nothing in flight, or until ctx is done
+func (q *LocalQueue) Receive(ctx context.Context) (*Delivery, error) {
+	for {
+		q.mu.Lock()
+		entry, wake := q.next(time.Now())
+		if entry != nil {
+			entry.receives++
+			q.inFlight[entry.message.GroupId] = true
+			q.mu.Unlock()
+			return &Delivery{
+				Message:      entry.message,
+				ReceiveCount: entry.receives,
+				ack:          func() { q.ack(entry) },
+				retry:        func() { q.retry(entry) },
+			}, nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		if err := wait(ctx, changed, wake); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait returns once changed is closed, wake has passed (if set) or ctx is done
+func wait(ctx context.Context, changed <-chan struct{}, wake time.Time) error {
+	var timeout <-chan time.Time
+	if !wake.IsZero() {
+		timer := time.NewTimer(time.Until(wake))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-changed:
+	case <-timeout:
+	}
+	return nil
+}
+
+// next finds the first receivable message. When there is none it also returns the earliest
+// time a waiting retry becomes visible, if any. The caller holds q.mu.
+func (q *LocalQueue) next(now time.Time) (*localMessage, time.Time) {
+	var wake time.Time
+	blocked := make(map[string]bool)
+	for _, entry := range q.messages {
+		group := entry.message.GroupId
+		// Only the oldest message of a group can be received
+		if blocked[group] {
+			continue
+		}
+		blocked[group] = true
+		if q.inFlight[group] {
+			continue
+		}
+		if entry.visibleAt.After(now) {
+			if wake.IsZero() || entry.visibleAt.Before(wake) {
+				wake = entry.visibleAt
+			}
+			continue
+		}
+		return entry, time.Time{}
+	}
+	return nil, wake
+}
+
+// Len is the number of messages published and not yet acknowledged
+func (q *LocalQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// WaitEmpty blocks until every message has been acknowledged, or until ctx is done
+func (q *LocalQueue) WaitEmpty(ctx context.Context) error {
+	for {
+		q.mu.Lock()
+		empty := len(q.messages) == 0
+		changed := q.changed
+		q.mu.Unlock()
+		if empty {
+			return nil
+		}
+		if err := wait(ctx, changed, time.Time{}); err != nil {
+			return err
+		}
+	}
+}
+
+func (q *LocalQueue) ack(entry *localMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, message := range q.messages {
+		if message == entry {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			break
+		}
+	}
+	delete(q.inFlight, entry.message.GroupId)
+	q.notify()
+}
+
+func (q *LocalQueue) retry(entry *localMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry.visibleAt = time.Now().Add(q.retryDelay)
+	delete(q.inFlight, entry.message.GroupId)
+	q.notify()
+}
+
+// notify wakes every waiting receiver; the caller holds q.mu
+func (q *LocalQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
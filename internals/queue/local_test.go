@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omniful/go_commons/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, q *LocalQueue) *Delivery {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	delivery, err := q.Receive(ctx)
+	require.NoError(t, err)
+	return delivery
+}
+
+func TestLocalQueue_DropsDuplicates(t *testing.T) {
+	q := NewLocalQueue("bulk-orders.fifo", 0)
+	ctx := context.Background()
+
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "g", DeduplicationId: "bulk-1"}))
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "g", DeduplicationId: "bulk-1"}))
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "g", DeduplicationId: "bulk-2"}))
+
+	assert.Equal(t, 2, q.Len())
+}
+
+func TestLocalQueue_OneMessageInFlightPerGroup(t *testing.T) {
+	q := NewLocalQueue("bulk-orders.fifo", 0)
+	ctx := context.Background()
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "a", DeduplicationId: "a-1"}))
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "a", DeduplicationId: "a-2"}))
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "b", DeduplicationId: "b-1"}))
+
+	first := receive(t, q)
+	second := receive(t, q)
+	assert.Equal(t, "a-1", first.Message.DeduplicationId)
+	assert.Equal(t, "b-1", second.Message.DeduplicationId, "a-2 must wait for a-1")
+
+	first.Ack()
+	third := receive(t, q)
+	assert.Equal(t, "a-2", third.Message.DeduplicationId)
+}
+
+func TestLocalQueue_RetryRedeliversInOrder(t *testing.T) {
+	q := NewLocalQueue("bulk-orders.fifo", 20*time.Millisecond)
+	ctx := context.Background()
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "a", DeduplicationId: "a-1"}))
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "a", DeduplicationId: "a-2"}))
+
+	first := receive(t, q)
+	assert.Equal(t, 1, first.ReceiveCount)
+	retriedAt := time.Now()
+	first.Retry()
+
+	again := receive(t, q)
+	assert.Equal(t, "a-1", again.Message.DeduplicationId)
+	assert.Equal(t, 2, again.ReceiveCount)
+	assert.GreaterOrEqual(t, time.Since(retriedAt), 20*time.Millisecond)
+}
+
+func TestLocalQueue_ReceiveStopsWithContext(t *testing.T) {
+	q := NewLocalQueue("bulk-orders.fifo", 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := q.Receive(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type recordedDeadLetter struct {
+	queue        string
+	message      *sqs.Message
+	receiveCount int
+	reason       error
+}
+
+type deadLetterRecorder struct {
+	mu      sync.Mutex
+	entries []recordedDeadLetter
+}
+
+func (r *deadLetterRecorder) DeadLetter(ctx context.Context, queue string, message *sqs.Message, receiveCount int, reason error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, recordedDeadLetter{queue, message, receiveCount, reason})
+	return nil
+}
+
+func runConsumer(t *testing.T, q *LocalQueue, handler Handler, deadLetters DeadLetters) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := NewConsumer(q.Name(), q, handler, deadLetters, ConsumerOptions{MaxReceives: 3, Workers: 2})
+	done := make(chan struct{})
+	go func() {
+		consumer.Run(ctx)
+		close(done)
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer waitCancel()
+	require.NoError(t, q.WaitEmpty(waitCtx))
+	cancel()
+	<-done
+}
+
+func TestConsumer_DeadLettersAfterMaxReceives(t *testing.T) {
+	q := NewLocalQueue("bulk-orders.fifo", time.Millisecond)
+	ctx := context.Background()
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "bulk-orders", DeduplicationId: "bulk-bad", Value: []byte("{")}))
+	require.NoError(t, q.Publish(ctx, &sqs.Message{GroupId: "bulk-orders", DeduplicationId: "bulk-good", Value: []byte("{}")}))
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	deadLetters := &deadLetterRecorder{}
+	runConsumer(t, q, func(ctx context.Context, message *sqs.Message) error {
+		mu.Lock()
+		attempts[message.DeduplicationId]++
+		mu.Unlock()
+		if message.DeduplicationId == "bulk-bad" {
+			return errors.New("corrupt file")
+		}
+		return nil
+	}, deadLetters)
+
+	assert.Equal(t, map[string]int{"bulk-bad": 3, "bulk-good": 1}, attempts)
+	require.Len(t, deadLetters.entries, 1)
+	entry := deadLetters.entries[0]
+	assert.Equal(t, "bulk-orders.fifo", entry.queue)
+	assert.Equal(t, "bulk-bad", entry.message.DeduplicationId)
+	assert.Equal(t, 3, entry.receiveCount)
+	assert.EqualError(t, entry.reason, "corrupt file")
+}
+
+func TestConsumer_PanicCountsAsFailure(t *testing.T) {
+	q := NewLocalQueue("bulk-orders.fifo", time.Millisecond)
+	require.NoError(t, q.Publish(context.Background(), &sqs.Message{GroupId: "bulk-orders", DeduplicationId: "bulk-1"}))
+
+	deadLetters := &deadLetterRecorder{}
+	runConsumer(t, q, func(ctx context.Context, message *sqs.Message) error {
+		panic("nil map")
+	}, deadLetters)
+
+	require.Len(t, deadLetters.entries, 1)
+	assert.Contains(t, deadLetters.entries[0].reason.Error(), "nil map")
+}
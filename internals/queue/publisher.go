@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"oms-service-goc/internals/configs"
+
+	"github.com/omniful/go_commons/sqs"
+)
+
+// Publisher matches services.SQSPublisher so any implementation here can be handed to the service layer
+type Publisher interface {
+	Publish(ctx context.Context, message *sqs.Message) error
+}
+
+// MockSQSPublisher only logs messages - used for local development without a queue
+type MockSQSPublisher struct{}
+
+func (m *MockSQSPublisher) Publish(ctx context.Context, message *sqs.Message) error {
+	log.Printf("Mock SQS: Publishing message - GroupID: %s, DeduplicationID: %s", message.GroupId, message.DeduplicationId)
+	return nil
+}
+
+// RealSQSPublisher adapts the go_commons SQS publisher to our Publisher interface
+type RealSQSPublisher struct {
+	publisher *sqs.Publisher
+}
+
+func NewRealSQSPublisher(publisher *sqs.Publisher) *RealSQSPublisher {
+	return &RealSQSPublisher{
+		publisher: publisher,
+	}
+}
+
+func (r *RealSQSPublisher) Publish(ctx context.Context, message *sqs.Message) error {
+	return r.publisher.Publish(ctx, message)
+}
+
+// NewPublisher builds a publisher for queueName using the implementation selected by
+// cfg.Queue.Publisher. For LocalStack and AWS the queue URL is resolved up front, so a
+// missing queue fails at startup instead of on the first publish.
+func NewPublisher(ctx context.Context, cfg *configs.Config, queueName string) (Publisher, error) {
+	switch cfg.Queue.Publisher {
+	case configs.PublisherMock:
+		return &MockSQSPublisher{}, nil
+
+	case configs.PublisherLocalStack:
+		if cfg.SQS == nil || cfg.SQS.Endpoint == "" {
+			return nil, fmt.Errorf("localstack publisher requires an SQS endpoint")
+		}
+		return newRealPublisher(ctx, queueName, cfg.SQS)
+
+	case configs.PublisherAWS:
+		if cfg.SQS == nil {
+			return nil, fmt.Errorf("aws publisher requires SQS configuration")
+		}
+		// Never point at a local endpoint in AWS mode, even if one is left over in the environment
+		sqsConfig := *cfg.SQS
+		sqsConfig.Endpoint = ""
+		return newRealPublisher(ctx, queueName, &sqsConfig)
+
+	default:
+		return nil, fmt.Errorf("unknown SQS publisher %q", cfg.Queue.Publisher)
+	}
+}
+
+func newRealPublisher(ctx context.Context, queueName string, sqsConfig *sqs.Config) (Publisher, error) {
+	if queueName == "" {
+		return nil, fmt.Errorf("queue name is required")
+	}
+
+	queue, err := sqs.NewFifoQueue(ctx, queueName, sqsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve SQS queue %s: %w", queueName, err)
+	}
+
+	return NewRealSQSPublisher(sqs.NewPublisher(queue)), nil
+}
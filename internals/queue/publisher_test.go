@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"oms-service-goc/internals/configs"
+	"testing"
+
+	"github.com/omniful/go_commons/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPublisher_Mock(t *testing.T) {
+	cfg := &configs.Config{
+		Queue: configs.QueueConfig{Publisher: configs.PublisherMock},
+	}
+
+	publisher, err := NewPublisher(context.Background(), cfg, "bulk-orders.fifo")
+
+	require.NoError(t, err)
+	assert.IsType(t, &MockSQSPublisher{}, publisher)
+}
+
+func TestNewPublisher_LocalStackRequiresEndpoint(t *testing.T) {
+	cfg := &configs.Config{
+		SQS:   &sqs.Config{Region: "us-east-1"},
+		Queue: configs.QueueConfig{Publisher: configs.PublisherLocalStack, BulkOrderQueue: "bulk-orders.fifo"},
+	}
+
+	_, err := NewPublisher(context.Background(), cfg, "bulk-orders.fifo")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "endpoint")
+}
+
+func TestNewPublisher_UnknownMode(t *testing.T) {
+	cfg := &configs.Config{
+		Queue: configs.QueueConfig{Publisher: "kafka"},
+	}
+
+	_, err := NewPublisher(context.Background(), cfg, "bulk-orders.fifo")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown SQS publisher")
+}
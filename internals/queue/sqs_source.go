@@ -0,0 +1,224 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"oms-service-goc/internals/configs"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/omniful/go_commons/sqs"
+)
+
+const (
+	// sqsWaitTime is how long a receive long-polls for a message
+	sqsWaitTime = 20 * time.Second
+	// sqsVisibility is how long a received message stays hidden; it is extended every half
+	// period for as long as the message is being handled, however long an import runs
+	sqsVisibility = time.Minute
+	// sqsBackoff is the wait before receiving again after SQS returned an error
+	sqsBackoff = 5 * time.Second
+	// sqsCallTimeout bounds the delete and visibility calls made after a message is handled
+	sqsCallTimeout = 10 * time.Second
+)
+
+// SQSAPI is the part of the SQS client SQSSource uses
+type SQSAPI interface {
+	ReceiveMessage(ctx context.Context, params *awssqs.ReceiveMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *awssqs.DeleteMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *awssqs.ChangeMessageVisibilityInput, optFns ...func(*awssqs.Options)) (*awssqs.ChangeMessageVisibilityOutput, error)
+}
+
+// SQSSource receives messages from an SQS queue. Acknowledging a delivery deletes the message;
+// retrying it makes the message visible again after retryDelay, and SQS counts the receives.
+type SQSSource struct {
+	client     SQSAPI
+	queueURL   string
+	retryDelay time.Duration
+	backoff    time.Duration // wait before receiving again after an error
+}
+
+func NewSQSSource(client SQSAPI, queueURL string, retryDelay time.Duration) *SQSSource {
+	return &SQSSource{
+		client:     client,
+		queueURL:   queueURL,
+		retryDelay: retryDelay,
+		backoff:    sqsBackoff,
+	}
+}
+
+// NewSource builds an SQSSource for queueName using the LocalStack or AWS settings in cfg.
+// The mock publisher has no queue to receive from; use a LocalQueue instead.
+func NewSource(ctx context.Context, cfg *configs.Config, queueName string) (*SQSSource, error) {
+	if cfg.SQS == nil {
+		return nil, fmt.Errorf("%s source requires SQS configuration", cfg.Queue.Publisher)
+	}
+	endpoint := ""
+	switch cfg.Queue.Publisher {
+	case configs.PublisherLocalStack:
+		if cfg.SQS.Endpoint == "" {
+			return nil, fmt.Errorf("localstack source requires an SQS endpoint")
+		}
+		endpoint = cfg.SQS.Endpoint
+	case configs.PublisherAWS:
+		// Never point at a local endpoint in AWS mode, even if one is left over in the environment
+	default:
+		return nil, fmt.Errorf("no SQS source for publisher %q", cfg.Queue.Publisher)
+	}
+	if queueName == "" {
+		return nil, fmt.Errorf("queue name is required")
+	}
+
+	client := awssqs.New(awssqs.Options{
+		Region:      cfg.SQS.Region,
+		Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(environmentCredentials)),
+	}, func(o *awssqs.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+	output, err := client.GetQueueUrl(ctx, &awssqs.GetQueueUrlInput{
+		QueueName:              aws.String(queueName),
+		QueueOwnerAWSAccountId: aws.String(cfg.SQS.Account),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve SQS queue %s: %w", queueName, err)
+	}
+	return NewSQSSource(client, aws.ToString(output.QueueUrl), cfg.Queue.RetryDelay), nil
+}
+
+func environmentCredentials(ctx context.Context) (aws.Credentials, error) {
+	credentials := aws.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Source:          "environment",
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return aws.Credentials{}, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required to receive from SQS")
+	}
+	return credentials, nil
+}
+
+// Receive long-polls until a message arrives or ctx is done. SQS errors are logged and
+// retried, so only a finished ctx ends the wait.
+func (s *SQSSource) Receive(ctx context.Context) (*Delivery, error) {
+	for {
+		output, err := s.client.ReceiveMessage(ctx, &awssqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(s.queueURL),
+			MaxNumberOfMessages:   1,
+			WaitTimeSeconds:       int32(sqsWaitTime / time.Second),
+			VisibilityTimeout:     int32(sqsVisibility / time.Second),
+			MessageAttributeNames: []string{"All"},
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameApproximateReceiveCount,
+				types.MessageSystemAttributeNameMessageGroupId,
+				types.MessageSystemAttributeNameMessageDeduplicationId,
+			},
+		})
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Queue %s: failed to receive messages, retrying in %s: %v", s.queueURL, s.backoff, err)
+			if err := wait(ctx, nil, time.Now().Add(s.backoff)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(output.Messages) > 0 {
+			return s.deliver(output.Messages[0]), nil
+		}
+	}
+}
+
+// deliver wraps a received message, keeping it hidden until it is acknowledged or retried
+func (s *SQSSource) deliver(received types.Message) *Delivery {
+	message := &sqs.Message{
+		GroupId:         received.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)],
+		DeduplicationId: received.Attributes[string(types.MessageSystemAttributeNameMessageDeduplicationId)],
+		Value:           []byte(aws.ToString(received.Body)),
+		ReceiptHandle:   aws.ToString(received.ReceiptHandle),
+	}
+	if len(received.MessageAttributes) > 0 {
+		message.Attributes = make(map[string]string, len(received.MessageAttributes))
+		for name, value := range received.MessageAttributes {
+			message.Attributes[name] = aws.ToString(value.StringValue)
+		}
+	}
+	receives, err := strconv.Atoi(received.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if err != nil || receives < 1 {
+		receives = 1
+	}
+
+	heartbeat, stop := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.keepHidden(heartbeat, message)
+	}()
+	var once sync.Once
+	finish := func(done func(ctx context.Context) error, action string) {
+		once.Do(func() {
+			stop()
+			<-stopped
+			ctx, cancel := context.WithTimeout(context.Background(), sqsCallTimeout)
+			defer cancel()
+			if err := done(ctx); err != nil {
+				log.Printf("Queue %s: failed to %s message %s: %v", s.queueURL, action, message.DeduplicationId, err)
+			}
+		})
+	}
+
+	return &Delivery{
+		Message:      message,
+		ReceiveCount: receives,
+		ack: func() {
+			finish(func(ctx context.Context) error {
+				_, err := s.client.DeleteMessage(ctx, &awssqs.DeleteMessageInput{
+					QueueUrl:      aws.String(s.queueURL),
+					ReceiptHandle: aws.String(message.ReceiptHandle),
+				})
+				return err
+			}, "delete")
+		},
+		retry: func() {
+			finish(func(ctx context.Context) error {
+				return s.setVisibility(ctx, message, s.retryDelay)
+			}, "retry")
+		},
+	}
+}
+
+// keepHidden extends the message's visibility every half period until ctx is done, so a
+// long import is not handed to another worker part way through
+func (s *SQSSource) keepHidden(ctx context.Context, message *sqs.Message) {
+	ticker := time.NewTicker(sqsVisibility / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.setVisibility(ctx, message, sqsVisibility); err != nil && ctx.Err() == nil {
+				log.Printf("Queue %s: failed to extend visibility of message %s: %v", s.queueURL, message.DeduplicationId, err)
+			}
+		}
+	}
+}
+
+func (s *SQSSource) setVisibility(ctx context.Context, message *sqs.Message, timeout time.Duration) error {
+	_, err := s.client.ChangeMessageVisibility(ctx, &awssqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.queueURL),
+		ReceiptHandle:     aws.String(message.ReceiptHandle),
+		VisibilityTimeout: int32(timeout / time.Second),
+	})
+	return err
+}
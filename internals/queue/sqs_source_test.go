@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQS hands out its messages one receive at a time and records what was done with them
+type fakeSQS struct {
+	mu         sync.Mutex
+	receives   []func() (*awssqs.ReceiveMessageOutput, error)
+	deleted    []string
+	visibility map[string]int32
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, params *awssqs.ReceiveMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	if len(f.receives) == 0 {
+		f.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	next := f.receives[0]
+	f.receives = f.receives[1:]
+	f.mu.Unlock()
+	return next()
+}
+
+func (f *fakeSQS) DeleteMessage(ctx context.Context, params *awssqs.DeleteMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &awssqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(ctx context.Context, params *awssqs.ChangeMessageVisibilityInput, optFns ...func(*awssqs.Options)) (*awssqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.visibility[aws.ToString(params.ReceiptHandle)] = params.VisibilityTimeout
+	return &awssqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func received(handle, body string, receives string) func() (*awssqs.ReceiveMessageOutput, error) {
+	return func() (*awssqs.ReceiveMessageOutput, error) {
+		return &awssqs.ReceiveMessageOutput{Messages: []types.Message{{
+			Body:          aws.String(body),
+			ReceiptHandle: aws.String(handle),
+			Attributes: map[string]string{
+				"MessageGroupId":          "bulk-orders/tenant1/seller1",
+				"MessageDeduplicationId":  "bulk-" + handle,
+				"ApproximateReceiveCount": receives,
+			},
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"replay_of": {DataType: aws.String("String"), StringValue: aws.String("dl-1")},
+			},
+		}}}, nil
+	}
+}
+
+func TestSQSSource_ReceiveAckAndRetry(t *testing.T) {
+	client := &fakeSQS{visibility: map[string]int32{}}
+	client.receives = append(client.receives,
+		func() (*awssqs.ReceiveMessageOutput, error) { return nil, errors.New("throttled") },
+		received("h1", `{"job_id":"1"}`, "1"),
+		received("h2", `{"job_id":"2"}`, "3"),
+	)
+	source := NewSQSSource(client, "https://sqs.example/bulk-orders.fifo", 30*time.Second)
+	source.backoff = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// The failed receive is retried after a backoff instead of ending the consumer
+	first, err := source.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"job_id":"1"}`, string(first.Message.Value))
+	assert.Equal(t, "bulk-orders/tenant1/seller1", first.Message.GroupId)
+	assert.Equal(t, "bulk-h1", first.Message.DeduplicationId)
+	assert.Equal(t, map[string]string{"replay_of": "dl-1"}, first.Message.Attributes)
+	assert.Equal(t, 1, first.ReceiveCount)
+	first.Ack()
+
+	second, err := source.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, second.ReceiveCount)
+	second.Retry()
+
+	assert.Equal(t, []string{"h1"}, client.deleted)
+	assert.Equal(t, map[string]int32{"h2": 30}, client.visibility)
+
+	// With nothing left, Receive waits until ctx is done
+	short, stop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer stop()
+	_, err = source.Receive(short)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrBulkJobNotFound  = errors.New("bulk job not found")
+	ErrBulkJobCancelled = errors.New("bulk job cancelled")
+)
+
+type BulkJobRepository interface {
+	Create(ctx context.Context, job *models.BulkJob) (*models.BulkJob, error)
+	FindByID(ctx context.Context, id string) (*models.BulkJob, error)
+	Update(ctx context.Context, job *models.BulkJob) error
+	SaveCheckpoint(ctx context.Context, id bson.ObjectID, line int, progress models.BulkJobSummary) error
+	RequestCancel(ctx context.Context, id string, rollback bool) (*models.BulkJob, error)
+}
+
+type bulkJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBulkJobRepository(db mongodm.Database) (BulkJobRepository, error) {
+	collection := db.GetWriteDB().Collection("bulk_jobs", collectionOptions())
+	return &bulkJobRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *bulkJobRepository) Create(ctx context.Context, job *models.BulkJob) (*models.BulkJob, error) {
+	if job.ID.IsZero() {
+		job.ID = bson.NewObjectID()
+	}
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	if job.Status == "" {
+		job.Status = models.BulkJobQueued
+	}
+	if _, err := r.collection.InsertOne(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *bulkJobRepository) FindByID(ctx context.Context, id string) (*models.BulkJob, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bulk job ID: %w", err)
+	}
+	var job models.BulkJob
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrBulkJobNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find bulk job: %w", err)
+	}
+	return &job, nil
+}
+
+// Update saves the job's progress. The worker is the only writer apart from cancellation, so
+// the one guard is that a job loaded before a cancellation was requested cannot be saved over
+// it; that returns ErrBulkJobCancelled.
+func (r *bulkJobRepository) Update(ctx context.Context, job *models.BulkJob) error {
+	job.UpdatedAt = time.Now()
+	filter := bson.M{"_id": job.ID}
+	if !job.CancelRequested {
+		filter["cancel_requested"] = bson.M{"$ne": true}
+	}
+	result, err := r.collection.ReplaceOne(ctx, filter, job)
+	if err != nil {
+		return fmt.Errorf("failed to update bulk job: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		if !job.CancelRequested {
+			count, err := r.collection.CountDocuments(ctx, bson.M{"_id": job.ID})
+			if err != nil {
+				return fmt.Errorf("failed to update bulk job: %w", err)
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: %s", ErrBulkJobCancelled, job.ID.Hex())
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrBulkJobNotFound, job.ID.Hex())
+	}
+	return nil
+}
+
+// RequestCancel flags a job for cancellation and returns it as stored. A rollback, once asked
+// for, stays asked for. Jobs already cancelled return ErrBulkJobCancelled.
+func (r *bulkJobRepository) RequestCancel(ctx context.Context, id string, rollback bool) (*models.BulkJob, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bulk job ID: %w", err)
+	}
+	set := bson.M{"cancel_requested": true, "updated_at": time.Now()}
+	if rollback {
+		set["rollback"] = true
+	}
+
+	var job models.BulkJob
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "status": bson.M{"$ne": models.BulkJobCancelled}},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrBulkJobCancelled, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to request bulk job cancellation: %w", err)
+	}
+	return &job, nil
+}
+
+// SaveCheckpoint records the importer's progress through the file, with what became of the
+// orders up to that line. The checkpoint only ever moves forward, so saves from writers
+// finishing in any order are safe.
+func (r *bulkJobRepository) SaveCheckpoint(ctx context.Context, id bson.ObjectID, line int, progress models.BulkJobSummary) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "checkpoint": bson.M{"$not": bson.M{"$gte": line}}}, bson.M{
+		"$set": bson.M{"checkpoint": line, "progress": progress, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save bulk job checkpoint: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return fmt.Errorf("failed to save bulk job checkpoint: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: %s", ErrBulkJobNotFound, id.Hex())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// newRegistry returns the default BSON registry extended to store decimal.Decimal as Decimal128,
+// so monetary amounts never round-trip through float64
+func newRegistry() *bson.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeEncoder(decimalType, bson.ValueEncoderFunc(encodeDecimal))
+	registry.RegisterTypeDecoder(decimalType, bson.ValueDecoderFunc(decodeDecimal))
+	return registry
+}
+
+// collectionOptions is used for every collection opened by this package
+func collectionOptions() *options.CollectionOptionsBuilder {
+	return options.Collection().SetRegistry(newRegistry())
+}
+
+func encodeDecimal(_ bson.EncodeContext, vw bson.ValueWriter, val reflect.Value) error {
+	if !val.IsValid() || val.Type() != decimalType {
+		return bson.ValueEncoderError{Name: "encodeDecimal", Types: []reflect.Type{decimalType}, Received: val}
+	}
+	d128, err := bson.ParseDecimal128(val.Interface().(decimal.Decimal).String())
+	if err != nil {
+		return fmt.Errorf("failed to encode decimal: %w", err)
+	}
+	return vw.WriteDecimal128(d128)
+}
+
+func decodeDecimal(_ bson.DecodeContext, vr bson.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != decimalType {
+		return bson.ValueDecoderError{Name: "decodeDecimal", Types: []reflect.Type{decimalType}, Received: val}
+	}
+
+	var (
+		d   decimal.Decimal
+		err error
+	)
+	switch vr.Type() {
+	case bson.TypeDecimal128:
+		var d128 bson.Decimal128
+		if d128, err = vr.ReadDecimal128(); err == nil {
+			d, err = decimal.NewFromString(d128.String())
+		}
+	case bson.TypeString:
+		var s string
+		if s, err = vr.ReadString(); err == nil {
+			d, err = decimal.NewFromString(s)
+		}
+	case bson.TypeDouble:
+		var f float64
+		if f, err = vr.ReadDouble(); err == nil {
+			d = decimal.NewFromFloat(f)
+		}
+	case bson.TypeInt32:
+		var i int32
+		if i, err = vr.ReadInt32(); err == nil {
+			d = decimal.NewFromInt32(i)
+		}
+	case bson.TypeInt64:
+		var i int64
+		if i, err = vr.ReadInt64(); err == nil {
+			d = decimal.NewFromInt(i)
+		}
+	case bson.TypeNull:
+		err = vr.ReadNull()
+	case bson.TypeUndefined:
+		err = vr.ReadUndefined()
+	default:
+		return fmt.Errorf("cannot decode %v into decimal", vr.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode decimal: %w", err)
+	}
+
+	val.Set(reflect.ValueOf(d))
+	return nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"bytes"
+	"oms-service-goc/internals/models"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDecimalCodec_RoundTrip(t *testing.T) {
+	registry := newRegistry()
+	order := models.Order{
+		Currency: "USD",
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 3, UnitPrice: decimal.RequireFromString("19.99")},
+		},
+		Total: decimal.RequireFromString("59.97"),
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := bson.NewEncoder(bson.NewDocumentWriter(buf))
+	encoder.SetRegistry(registry)
+	require.NoError(t, encoder.Encode(order))
+
+	raw := bson.Raw(buf.Bytes())
+	assert.Equal(t, bson.TypeDecimal128, raw.Lookup("total").Type)
+
+	var decoded models.Order
+	decoder := bson.NewDecoder(bson.NewDocumentReader(bytes.NewReader(buf.Bytes())))
+	decoder.SetRegistry(registry)
+	require.NoError(t, decoder.Decode(&decoded))
+
+	assert.True(t, order.Total.Equal(decoded.Total))
+	assert.True(t, order.Items[0].UnitPrice.Equal(decoded.Items[0].UnitPrice))
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetterFilter selects dead letters; replayed entries are left out unless IncludeReplayed is set
+type DeadLetterFilter struct {
+	Queue           string
+	IncludeReplayed bool
+}
+
+type DeadLetterRepository interface {
+	Create(ctx context.Context, entry *models.DeadLetter) (*models.DeadLetter, error)
+	FindByID(ctx context.Context, id string) (*models.DeadLetter, error)
+	List(ctx context.Context, filter DeadLetterFilter) ([]*models.DeadLetter, error)
+	MarkReplayed(ctx context.Context, id bson.ObjectID, at time.Time) error
+}
+
+type deadLetterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDeadLetterRepository(db mongodm.Database) (DeadLetterRepository, error) {
+	collection := db.GetWriteDB().Collection("dead_letters", collectionOptions())
+	return &deadLetterRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *deadLetterRepository) Create(ctx context.Context, entry *models.DeadLetter) (*models.DeadLetter, error) {
+	if entry.ID.IsZero() {
+		entry.ID = bson.NewObjectID()
+	}
+	if entry.FailedAt.IsZero() {
+		entry.FailedAt = time.Now()
+	}
+	if _, err := r.collection.InsertOne(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create dead letter: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *deadLetterRepository) FindByID(ctx context.Context, id string) (*models.DeadLetter, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dead letter ID: %w", err)
+	}
+	var entry models.DeadLetter
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead letter: %w", err)
+	}
+	return &entry, nil
+}
+
+// List returns the oldest failures first, the order they should be replayed in
+func (r *deadLetterRepository) List(ctx context.Context, filter DeadLetterFilter) ([]*models.DeadLetter, error) {
+	query := bson.M{}
+	if filter.Queue != "" {
+		query["queue"] = filter.Queue
+	}
+	if !filter.IncludeReplayed {
+		query["replayed_at"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "failed_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead letters: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+	var entries []*models.DeadLetter
+	for cursor.Next(ctx) {
+		var entry models.DeadLetter
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("failed to decode dead letter: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, cursor.Err()
+}
+
+func (r *deadLetterRepository) MarkReplayed(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"replayed_at": at},
+		"$inc": bson.M{"replays": 1},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letter replayed: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrExportJobNotFound = errors.New("export job not found")
+
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *models.ExportJob) (*models.ExportJob, error)
+	FindByID(ctx context.Context, id string) (*models.ExportJob, error)
+	Update(ctx context.Context, job *models.ExportJob) error
+}
+
+type exportJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExportJobRepository(db mongodm.Database) (ExportJobRepository, error) {
+	collection := db.GetWriteDB().Collection("export_jobs", collectionOptions())
+	return &exportJobRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *exportJobRepository) Create(ctx context.Context, job *models.ExportJob) (*models.ExportJob, error) {
+	if job.ID.IsZero() {
+		job.ID = bson.NewObjectID()
+	}
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	if job.Status == "" {
+		job.Status = models.ExportJobPending
+	}
+	if _, err := r.collection.InsertOne(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *exportJobRepository) FindByID(ctx context.Context, id string) (*models.ExportJob, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export job ID: %w", err)
+	}
+	var job models.ExportJob
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrExportJobNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find export job: %w", err)
+	}
+	return &job, nil
+}
+
+// Update saves the job's progress; jobs have a single writer so no guard is needed
+func (r *exportJobRepository) Update(ctx context.Context, job *models.ExportJob) error {
+	job.UpdatedAt = time.Now()
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	if err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrExportJobNotFound, job.ID.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrImportTemplateNotFound = errors.New("import template not found")
+
+type ImportTemplateRepository interface {
+	Create(ctx context.Context, template *models.ImportTemplate) (*models.ImportTemplate, error)
+	FindByID(ctx context.Context, id string) (*models.ImportTemplate, error)
+	FindBySellerID(ctx context.Context, sellerID string) ([]*models.ImportTemplate, error)
+	Update(ctx context.Context, template *models.ImportTemplate) error
+	Delete(ctx context.Context, id string) error
+}
+
+type importTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImportTemplateRepository(db mongodm.Database) (ImportTemplateRepository, error) {
+	collection := db.GetWriteDB().Collection("import_templates", collectionOptions())
+	return &importTemplateRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *importTemplateRepository) Create(ctx context.Context, template *models.ImportTemplate) (*models.ImportTemplate, error) {
+	if template.ID.IsZero() {
+		template.ID = bson.NewObjectID()
+	}
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	if _, err := r.collection.InsertOne(ctx, template); err != nil {
+		return nil, fmt.Errorf("failed to create import template: %w", err)
+	}
+	return template, nil
+}
+
+func (r *importTemplateRepository) FindByID(ctx context.Context, id string) (*models.ImportTemplate, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid import template ID %q", ErrImportTemplateNotFound, id)
+	}
+	var template models.ImportTemplate
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&template)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrImportTemplateNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import template: %w", err)
+	}
+	return &template, nil
+}
+
+func (r *importTemplateRepository) FindBySellerID(ctx context.Context, sellerID string) ([]*models.ImportTemplate, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"seller_id": sellerID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import templates: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+	var templates []*models.ImportTemplate
+	for cursor.Next(ctx) {
+		var template models.ImportTemplate
+		if err := cursor.Decode(&template); err != nil {
+			return nil, fmt.Errorf("failed to decode import template: %w", err)
+		}
+		templates = append(templates, &template)
+	}
+	return templates, cursor.Err()
+}
+
+func (r *importTemplateRepository) Update(ctx context.Context, template *models.ImportTemplate) error {
+	template.UpdatedAt = time.Now()
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": template.ID}, template)
+	if err != nil {
+		return fmt.Errorf("failed to update import template: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrImportTemplateNotFound, template.ID.Hex())
+	}
+	return nil
+}
+
+func (r *importTemplateRepository) Delete(ctx context.Context, id string) error {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%w: invalid import template ID %q", ErrImportTemplateNotFound, id)
+	}
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return fmt.Errorf("failed to delete import template: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrImportTemplateNotFound, id)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"oms-service-goc/internals/models"
 	"time"
@@ -9,21 +10,61 @@ import (
 	"github.com/omniful/go_commons/db/nosql/mongodm"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+var (
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrConcurrentModification = errors.New("concurrent modification, retry the request")
+	ErrOrderExists            = errors.New("order already exists")
+)
+
+// Attempts made by modify before giving up on a contended order
+const maxModifyAttempts = 3
+
+// duplicateKeyCode is the server error for a write that breaks a unique index such as _id
+const duplicateKeyCode = 11000
+
 type OrderRepository interface {
 	Create(ctx context.Context, order *models.Order) (*models.Order, error)
+	CreateMany(ctx context.Context, orders []*models.Order) ([]error, error)
+	Stream(ctx context.Context, filters OrderFilters, fn func(order *models.Order) error) error
 	FindByID(ctx context.Context, id string) (*models.Order, error)
 	FindByFilters(ctx context.Context, filters OrderFilters) ([]*models.Order, error)
 	UpdateStatus(ctx context.Context, id string, status models.OrderStatus) error
+	RecordFulfilment(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error)
+	RecordDelivery(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error)
+	RecordReturn(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error)
+	ReleaseReturn(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error)
+	Split(ctx context.Context, id string, assignments []models.HubAssignment) (*models.Order, []*models.Order, error)
+	Edit(ctx context.Context, id string, edit models.OrderEdit) (*models.Order, error)
+	PlaceHold(ctx context.Context, id string, reason models.HoldReason) (*models.Order, error)
+	ClearHolds(ctx context.Context, id string, reasons []models.HoldReason) (*models.Order, bool, error)
+	MarkSLABreached(ctx context.Context, order *models.Order, at time.Time) (bool, error)
+	BulkUpdateStatus(ctx context.Context, orders []*models.Order, status models.OrderStatus) ([]StatusResult, error)
+}
+
+// StatusResult is the outcome of one order in a bulk status update
+type StatusResult struct {
+	OrderID string
+	Order   *models.Order // the updated order, nil on failure
+	Err     error
 }
 
 type OrderFilters struct {
-	TenantID  string
-	SellerID  string
-	Status    string
-	StartDate *time.Time
-	EndDate   *time.Time
+	TenantID      string
+	SellerID      string
+	Status        string
+	ParentOrderID string
+	OrderIDs      []string
+	OrderRefs     []string // sellers' own order references
+	BulkJobID     string   // orders created by a bulk import
+	HubID         string
+	StartDate     *time.Time
+	EndDate       *time.Time
+	UpdatedBefore *time.Time
+	SLABreached   *bool      // true for flagged orders, false for orders never flagged
+	BreachedAfter *time.Time // flagged at or after this time
 }
 
 type orderRepository struct {
@@ -31,7 +72,7 @@ type orderRepository struct {
 }
 
 func NewOrderRepository(db mongodm.Database) (OrderRepository, error) {
-	collection := db.GetWriteDB().Collection("orders")
+	collection := db.GetWriteDB().Collection("orders", collectionOptions())
 	return &orderRepository{
 		collection: collection,
 	}, nil
@@ -44,6 +85,9 @@ func (r *orderRepository) Create(ctx context.Context, order *models.Order) (*mod
 	order.SetCreatedAt(time.Now())
 	order.SetUpdatedAt(time.Now())
 	order.Initialise(ctx)
+	if err := order.Validate(ctx); err != nil {
+		return nil, err
+	}
 	_, err := r.collection.InsertOne(ctx, order)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
@@ -51,6 +95,49 @@ func (r *orderRepository) Create(ctx context.Context, order *models.Order) (*mod
 	return order, nil
 }
 
+// CreateMany inserts orders with a single unordered InsertMany, so one bad order does not stop
+// the rest. errs[i] is why orders[i] was not created, ErrOrderExists when an order with its
+// ID is already stored; the error is for the call as a whole.
+func (r *orderRepository) CreateMany(ctx context.Context, orders []*models.Order) ([]error, error) {
+	now := time.Now()
+	errs := make([]error, len(orders))
+
+	var documents []interface{}
+	var inserted []int // index into orders for each document
+	for i, order := range orders {
+		if order.ID.IsZero() {
+			order.ID = bson.NewObjectID()
+		}
+		order.SetCreatedAt(now)
+		order.SetUpdatedAt(now)
+		order.Initialise(ctx)
+		if err := order.Validate(ctx); err != nil {
+			errs[i] = err
+			continue
+		}
+		documents = append(documents, order)
+		inserted = append(inserted, i)
+	}
+	if len(documents) == 0 {
+		return errs, nil
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bulkErr) {
+		return nil, fmt.Errorf("failed to create orders: %w", err)
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		i := inserted[writeErr.Index]
+		if writeErr.Code == duplicateKeyCode {
+			errs[i] = fmt.Errorf("%w: %s", ErrOrderExists, orders[i].ID.Hex())
+			continue
+		}
+		errs[i] = fmt.Errorf("failed to create order: %w", writeErr)
+	}
+	return errs, nil
+}
+
 func (r *orderRepository) FindByID(ctx context.Context, id string) (*models.Order, error) {
 	objID, err := bson.ObjectIDFromHex(id)
 	if err != nil {
@@ -58,13 +145,17 @@ func (r *orderRepository) FindByID(ctx context.Context, id string) (*models.Orde
 	}
 	var order models.Order
 	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, id)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("order not found: %w", err)
+		return nil, fmt.Errorf("failed to find order: %w", err)
 	}
 	return &order, nil
 }
 
-func (r *orderRepository) FindByFilters(ctx context.Context, filters OrderFilters) ([]*models.Order, error) {
+// buildFilter turns OrderFilters into a Mongo query
+func buildFilter(filters OrderFilters) (bson.M, error) {
 	filter := bson.M{}
 
 	if filters.TenantID != "" {
@@ -76,6 +167,50 @@ func (r *orderRepository) FindByFilters(ctx context.Context, filters OrderFilter
 	if filters.Status != "" {
 		filter["status"] = filters.Status
 	}
+	if filters.ParentOrderID != "" {
+		parentID, err := bson.ObjectIDFromHex(filters.ParentOrderID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent order ID: %w", err)
+		}
+		filter["parent_order_id"] = parentID
+	}
+	if len(filters.OrderIDs) > 0 {
+		ids := make([]bson.ObjectID, len(filters.OrderIDs))
+		for i, id := range filters.OrderIDs {
+			objID, err := bson.ObjectIDFromHex(id)
+			if err != nil {
+				return nil, fmt.Errorf("invalid order ID %q: %w", id, err)
+			}
+			ids[i] = objID
+		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	if len(filters.OrderRefs) > 0 {
+		filter["order_ref"] = bson.M{"$in": filters.OrderRefs}
+	}
+	if filters.BulkJobID != "" {
+		filter["bulk_job_id"] = filters.BulkJobID
+	}
+	if filters.HubID != "" {
+		filter["hub_id"] = filters.HubID
+	}
+	if filters.UpdatedBefore != nil {
+		filter["updated_at"] = bson.M{"$lt": *filters.UpdatedBefore}
+	}
+	if filters.SLABreached != nil || filters.BreachedAfter != nil {
+		breachFilter := bson.M{}
+		if filters.SLABreached != nil {
+			if *filters.SLABreached {
+				breachFilter["$ne"] = nil
+			} else {
+				breachFilter["$eq"] = nil
+			}
+		}
+		if filters.BreachedAfter != nil {
+			breachFilter["$gte"] = *filters.BreachedAfter
+		}
+		filter["sla_breached_at"] = breachFilter
+	}
 
 	if filters.StartDate != nil || filters.EndDate != nil {
 		dateFilter := bson.M{}
@@ -87,6 +222,14 @@ func (r *orderRepository) FindByFilters(ctx context.Context, filters OrderFilter
 		}
 		filter["created_at"] = dateFilter
 	}
+	return filter, nil
+}
+
+func (r *orderRepository) FindByFilters(ctx context.Context, filters OrderFilters) ([]*models.Order, error) {
+	filter, err := buildFilter(filters)
+	if err != nil {
+		return nil, err
+	}
 
 	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
@@ -105,23 +248,337 @@ func (r *orderRepository) FindByFilters(ctx context.Context, filters OrderFilter
 	return orders, cursor.Err()
 }
 
-func (r *orderRepository) UpdateStatus(ctx context.Context, id string, status models.OrderStatus) error {
-	objID, err := bson.ObjectIDFromHex(id)
+// Stream calls fn for each matching order in _id order, decoding one document at a time
+// from the cursor. It stops at the first error fn returns.
+func (r *orderRepository) Stream(ctx context.Context, filters OrderFilters, fn func(order *models.Order) error) error {
+	filter, err := buildFilter(filters)
 	if err != nil {
-		return fmt.Errorf("invalid order ID: %w", err)
+		return err
 	}
 
-	filter := bson.M{"_id": objID}
-	update := bson.M{
-		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
-		},
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("failed to find orders: %w", err)
 	}
 
-	_, err = r.collection.UpdateOne(ctx, filter, update)
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var order models.Order
+		if err := cursor.Decode(&order); err != nil {
+			return fmt.Errorf("failed to decode order: %w", err)
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *orderRepository) UpdateStatus(ctx context.Context, id string, status models.OrderStatus) error {
+	_, err := r.modify(ctx, id, func(order *models.Order) error {
+		return order.TransitionTo(status)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 	return nil
 }
+
+func (r *orderRepository) RecordFulfilment(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error) {
+	order, err := r.modify(ctx, id, func(order *models.Order) error {
+		return order.RecordFulfilment(quantities)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record fulfilment: %w", err)
+	}
+	return order, nil
+}
+
+func (r *orderRepository) RecordDelivery(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error) {
+	order, err := r.modify(ctx, id, func(order *models.Order) error {
+		return order.RecordDelivery(quantities)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return order, nil
+}
+
+func (r *orderRepository) RecordReturn(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error) {
+	order, err := r.modify(ctx, id, func(order *models.Order) error {
+		return order.RecordReturn(quantities)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record return: %w", err)
+	}
+	return order, nil
+}
+
+func (r *orderRepository) ReleaseReturn(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error) {
+	order, err := r.modify(ctx, id, func(order *models.Order) error {
+		return order.ReleaseReturn(quantities)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to release return: %w", err)
+	}
+	return order, nil
+}
+
+// Edit applies an item edit. A version mismatch is reported rather than retried, since
+// the caller's view of the order is stale.
+func (r *orderRepository) Edit(ctx context.Context, id string, edit models.OrderEdit) (*models.Order, error) {
+	return r.modify(ctx, id, func(order *models.Order) error {
+		return order.ApplyEdit(ctx, edit, time.Now())
+	})
+}
+
+func (r *orderRepository) PlaceHold(ctx context.Context, id string, reason models.HoldReason) (*models.Order, error) {
+	return r.modify(ctx, id, func(order *models.Order) error {
+		return order.PlaceHold(reason)
+	})
+}
+
+// ClearHolds removes hold reasons and reports whether that released the order to new_order
+func (r *orderRepository) ClearHolds(ctx context.Context, id string, reasons []models.HoldReason) (*models.Order, bool, error) {
+	var released bool
+	order, err := r.modify(ctx, id, func(order *models.Order) error {
+		var err error
+		released, err = order.ClearHolds(reasons...)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return order, released, nil
+}
+
+// MarkSLABreached flags the order as having breached its SLA. It does not touch updated_at,
+// which the SLA is measured from, or the version, and only applies if the order has not
+// changed since it was read; false means it had changed and was not flagged.
+func (r *orderRepository) MarkSLABreached(ctx context.Context, order *models.Order, at time.Time) (bool, error) {
+	filter := versionFilter(order.ID, order.Version)
+	filter["status"] = order.Status
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"sla_breached_at": at}})
+	if err != nil {
+		return false, fmt.Errorf("failed to flag SLA breach: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+	order.SLABreachedAt = &at
+	return true, nil
+}
+
+// BulkUpdateStatus moves each of the already loaded orders to status through the state
+// machine and writes the successful ones in a single unordered BulkWrite, each guarded
+// by the version it was loaded with. Every order gets a result in the order given; an
+// order that changed since it was loaded fails with ErrConcurrentModification.
+func (r *orderRepository) BulkUpdateStatus(ctx context.Context, orders []*models.Order, status models.OrderStatus) ([]StatusResult, error) {
+	// Mongo keeps milliseconds, so truncate to be able to find our own writes afterwards
+	now := time.Now().Truncate(time.Millisecond)
+	results := make([]StatusResult, len(orders))
+
+	var writes []mongo.WriteModel
+	var written []int // index into results for each write
+	for i, order := range orders {
+		results[i].OrderID = order.ID.Hex()
+
+		previousVersion, previousStatus := order.Version, order.Status
+		if err := order.TransitionTo(status); err != nil {
+			results[i].Err = err
+			continue
+		}
+		stamp(order, previousStatus, now)
+
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(versionFilter(order.ID, previousVersion)).
+			SetReplacement(order))
+		written = append(written, i)
+	}
+	if len(writes) == 0 {
+		return results, nil
+	}
+
+	result, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bulkErr) {
+		return nil, fmt.Errorf("failed to update order statuses: %w", err)
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		i := written[writeErr.Index]
+		results[i].Err = fmt.Errorf("failed to update order: %w", writeErr)
+	}
+
+	// BulkWrite only reports how many writes matched, so when some were skipped by the
+	// version guard look up which ones carry our write. Only one writer can move an order
+	// on from the version we loaded, so another write matching all of these set the same
+	// status in the same millisecond, which leaves the order as ours would have.
+	matched := map[bson.ObjectID]bool{}
+	if result != nil && int(result.MatchedCount) == len(writes)-len(bulkErr.WriteErrors) {
+		for _, i := range written {
+			matched[orders[i].ID] = true
+		}
+	} else {
+		ours := make(bson.A, len(written))
+		for k, i := range written {
+			ours[k] = bson.M{"_id": orders[i].ID, "version": orders[i].Version, "updated_at": now, "status": status}
+		}
+		cursor, err := r.collection.Find(ctx, bson.M{"$or": ours})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check order status updates: %w", err)
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var order models.Order
+			if err := cursor.Decode(&order); err != nil {
+				return nil, fmt.Errorf("failed to decode order: %w", err)
+			}
+			matched[order.ID] = true
+		}
+		if err := cursor.Err(); err != nil {
+			return nil, fmt.Errorf("failed to check order status updates: %w", err)
+		}
+	}
+
+	parents := map[bson.ObjectID]bool{}
+	for _, i := range written {
+		if results[i].Err != nil {
+			continue
+		}
+		order := orders[i]
+		if !matched[order.ID] {
+			results[i].Err = fmt.Errorf("%w: order %s", ErrConcurrentModification, order.ID.Hex())
+			continue
+		}
+		results[i].Order = order
+		if order.ParentOrderID != nil {
+			parents[*order.ParentOrderID] = true
+		}
+	}
+
+	for parentID := range parents {
+		if err := r.refreshParentStatus(ctx, parentID); err != nil {
+			return nil, fmt.Errorf("failed to refresh parent order status: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// Split moves the order's items into one child order per hub. The children are inserted
+// first and the parent is then linked to them under the version guard; if the parent
+// changed in the meantime the children are removed again.
+func (r *orderRepository) Split(ctx context.Context, id string, assignments []models.HubAssignment) (*models.Order, []*models.Order, error) {
+	parent, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	children, err := parent.Split(assignments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	documents := make([]interface{}, len(children))
+	childIDs := make([]bson.ObjectID, len(children))
+	for i, child := range children {
+		child.ID = bson.NewObjectID()
+		child.SetCreatedAt(now)
+		child.SetUpdatedAt(now)
+		child.Initialise(ctx)
+		if err := child.Validate(ctx); err != nil {
+			return nil, nil, err
+		}
+		documents[i] = child
+		childIDs[i] = child.ID
+	}
+
+	if _, err := r.collection.InsertMany(ctx, documents); err != nil {
+		return nil, nil, fmt.Errorf("failed to create child orders: %w", err)
+	}
+
+	previousVersion := parent.Version
+	parent.MarkSplit(children)
+	parent.Version++
+	parent.SetUpdatedAt(now)
+
+	result, err := r.collection.ReplaceOne(ctx, versionFilter(parent.ID, previousVersion), parent)
+	if err == nil && result.MatchedCount == 0 {
+		err = fmt.Errorf("%w: order %s", ErrConcurrentModification, id)
+	}
+	if err != nil {
+		if _, deleteErr := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": childIDs}}); deleteErr != nil {
+			return nil, nil, fmt.Errorf("failed to link child orders (%v) and to remove them: %w", err, deleteErr)
+		}
+		return nil, nil, fmt.Errorf("failed to link child orders: %w", err)
+	}
+
+	return parent, children, nil
+}
+
+// refreshParentStatus re-derives a split parent's status from its children
+func (r *orderRepository) refreshParentStatus(ctx context.Context, parentID bson.ObjectID) error {
+	children, err := r.FindByFilters(ctx, OrderFilters{ParentOrderID: parentID.Hex()})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.modify(ctx, parentID.Hex(), func(parent *models.Order) error {
+		parent.Status = models.AggregateStatus(children)
+		return nil
+	})
+	return err
+}
+
+// stamp records a change on the order before it is written back
+func stamp(order *models.Order, previousStatus models.OrderStatus, now time.Time) {
+	// An SLA breach belongs to the status it happened in
+	if order.Status != previousStatus {
+		order.SLABreachedAt = nil
+	}
+	order.Version++
+	order.SetUpdatedAt(now)
+}
+
+// versionFilter matches the order only while it is still at version. Orders stored before
+// versions were kept have none, which counts as version 0.
+func versionFilter(id bson.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+	return bson.M{"_id": id, "version": version}
+}
+
+// modify loads the order, applies mutate and replaces the document in a single write
+// guarded by its version, so item and order level changes land together or not at all.
+// A concurrent writer causes a reload and retry. When a child of a split order changes,
+// the parent's aggregate status is refreshed as well.
+func (r *orderRepository) modify(ctx context.Context, id string, mutate func(order *models.Order) error) (*models.Order, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		order, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		previousVersion := order.Version
+		previousStatus := order.Status
+		if err := mutate(order); err != nil {
+			return nil, err
+		}
+		stamp(order, previousStatus, time.Now())
+
+		result, err := r.collection.ReplaceOne(ctx, versionFilter(order.ID, previousVersion), order)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update order: %w", err)
+		}
+		if result.MatchedCount == 1 {
+			if order.ParentOrderID != nil {
+				if err := r.refreshParentStatus(ctx, *order.ParentOrderID); err != nil {
+					return nil, fmt.Errorf("failed to refresh parent order status: %w", err)
+				}
+			}
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: order %s", ErrConcurrentModification, id)
+}
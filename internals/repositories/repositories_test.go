@@ -9,6 +9,7 @@ import (
 	"github.com/omniful/go_commons/db/nosql/mongodm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 func setupTestDB() mongodm.Database {
@@ -78,3 +79,93 @@ func TestOrderRepository_FindByFilter(t *testing.T) {
 	assert.Len(t, orders, 1)
 	assert.Equal(t, created.ID, orders[0].ID)
 }
+
+func TestOrderRepository_CreateMany(t *testing.T) {
+	db := setupTestDB()
+	defer db.Client().Disconnect(context.Background())
+
+	repo, err := NewOrderRepository(db)
+	require.NoError(t, err)
+
+	existing, err := repo.Create(context.Background(), &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller1",
+		HubID:    "hub1",
+		Items:    []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+	})
+	require.NoError(t, err)
+
+	orders := []*models.Order{
+		{TenantID: "tenant1", SellerID: "seller1", HubID: "hub1", Items: []models.OrderItem{{SKUCode: "SKU001", Quantity: 2}}},
+		{TenantID: "tenant1", SellerID: "seller1", HubID: "hub1", Items: []models.OrderItem{{SKUCode: "SKU002", Quantity: 0}}},
+		{ID: existing.ID, TenantID: "tenant1", SellerID: "seller1", HubID: "hub1", Items: []models.OrderItem{{SKUCode: "SKU003", Quantity: 1}}},
+		{TenantID: "tenant1", SellerID: "seller1", HubID: "hub1", Items: []models.OrderItem{{SKUCode: "SKU004", Quantity: 4}}},
+	}
+
+	errs, err := repo.CreateMany(context.Background(), orders)
+	require.NoError(t, err)
+	require.Len(t, errs, 4)
+
+	// Invalid orders and failed writes are reported per order without stopping the others
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], models.ErrInvalidOrder)
+	assert.Error(t, errs[2])
+	assert.NoError(t, errs[3])
+
+	created, err := repo.FindByID(context.Background(), orders[3].ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 4, created.Items[0].Quantity)
+}
+
+func TestOrderRepository_StaleVersionLosesSameMillisecond(t *testing.T) {
+	db := setupTestDB()
+	defer db.Client().Disconnect(context.Background())
+	repo, err := NewOrderRepository(db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller1",
+		HubID:    "hub1",
+		Status:   models.OrderStatusNewOrder,
+		Items:    []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+	})
+	require.NoError(t, err)
+	first, err := repo.FindByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	stale, err := repo.FindByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+
+	results, err := repo.BulkUpdateStatus(ctx, []*models.Order{first}, models.OrderStatusCancelled)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+
+	// A second writer in the same millisecond carries the same updated_at, but not the same version
+	stored, err := repo.FindByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	stale.UpdatedAt = stored.UpdatedAt
+	results, err = repo.BulkUpdateStatus(ctx, []*models.Order{stale}, models.OrderStatusOnHold)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrConcurrentModification)
+
+	stored, err = repo.FindByID(ctx, created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, stored.Status)
+	assert.Equal(t, created.Version+1, stored.Version)
+}
+
+func TestBuildFilter_SLABreachedWithBreachedAfter(t *testing.T) {
+	breached := true
+	after := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	filter, err := buildFilter(OrderFilters{SLABreached: &breached, BreachedAfter: &after})
+	require.NoError(t, err)
+	// Both conditions hold; neither replaces the other
+	assert.Equal(t, bson.M{"$ne": nil, "$gte": after}, filter["sla_breached_at"])
+
+	notBreached := false
+	filter, err = buildFilter(OrderFilters{SLABreached: &notBreached})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$eq": nil}, filter["sla_breached_at"])
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrReturnNotFound = errors.New("return not found")
+
+type ReturnRepository interface {
+	Create(ctx context.Context, ret *models.Return) (*models.Return, error)
+	FindByID(ctx context.Context, id string) (*models.Return, error)
+	FindByOrderID(ctx context.Context, orderID string) ([]*models.Return, error)
+	Delete(ctx context.Context, id bson.ObjectID) error
+	Approve(ctx context.Context, id string) (*models.Return, error)
+	Reject(ctx context.Context, id, reason string) (*models.Return, error)
+	RevertRejection(ctx context.Context, id string) (*models.Return, error)
+	Receive(ctx context.Context, id, hubID string, quantities []models.ItemQuantity) (*models.Return, error)
+	Restock(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Return, error)
+}
+
+type returnRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReturnRepository(db mongodm.Database) (ReturnRepository, error) {
+	collection := db.GetWriteDB().Collection("returns", collectionOptions())
+	return &returnRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *returnRepository) Create(ctx context.Context, ret *models.Return) (*models.Return, error) {
+	if ret.ID.IsZero() {
+		ret.ID = bson.NewObjectID()
+	}
+	ret.SetCreatedAt(time.Now())
+	ret.SetUpdatedAt(time.Now())
+	ret.Initialise(ctx)
+	if err := ret.Validate(ctx); err != nil {
+		return nil, err
+	}
+	_, err := r.collection.InsertOne(ctx, ret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create return: %w", err)
+	}
+	return ret, nil
+}
+
+func (r *returnRepository) FindByID(ctx context.Context, id string) (*models.Return, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid return ID: %w", err)
+	}
+	var ret models.Return
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&ret)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrReturnNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find return: %w", err)
+	}
+	return &ret, nil
+}
+
+func (r *returnRepository) FindByOrderID(ctx context.Context, orderID string) ([]*models.Return, error) {
+	objID, err := bson.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": objID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find returns: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+	var returns []*models.Return
+	for cursor.Next(ctx) {
+		var ret models.Return
+		if err := cursor.Decode(&ret); err != nil {
+			return nil, fmt.Errorf("failed to decode return: %w", err)
+		}
+		returns = append(returns, &ret)
+	}
+	return returns, cursor.Err()
+}
+
+func (r *returnRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete return: %w", err)
+	}
+	return nil
+}
+
+func (r *returnRepository) Approve(ctx context.Context, id string) (*models.Return, error) {
+	return r.modify(ctx, id, func(ret *models.Return) error {
+		return ret.Approve(time.Now())
+	})
+}
+
+func (r *returnRepository) Reject(ctx context.Context, id, reason string) (*models.Return, error) {
+	return r.modify(ctx, id, func(ret *models.Return) error {
+		return ret.Reject(reason)
+	})
+}
+
+func (r *returnRepository) RevertRejection(ctx context.Context, id string) (*models.Return, error) {
+	return r.modify(ctx, id, func(ret *models.Return) error {
+		return ret.RevertRejection()
+	})
+}
+
+func (r *returnRepository) Receive(ctx context.Context, id, hubID string, quantities []models.ItemQuantity) (*models.Return, error) {
+	return r.modify(ctx, id, func(ret *models.Return) error {
+		return ret.Receive(hubID, quantities, time.Now())
+	})
+}
+
+func (r *returnRepository) Restock(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Return, error) {
+	return r.modify(ctx, id, func(ret *models.Return) error {
+		return ret.Restock(quantities, time.Now())
+	})
+}
+
+// modify applies mutate and replaces the document, guarded by updated_at so a concurrent writer causes a reload and retry
+func (r *returnRepository) modify(ctx context.Context, id string, mutate func(ret *models.Return) error) (*models.Return, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		ret, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		previousUpdatedAt := ret.UpdatedAt
+		if err := mutate(ret); err != nil {
+			return nil, err
+		}
+		ret.SetUpdatedAt(time.Now())
+
+		result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": ret.ID, "updated_at": previousUpdatedAt}, ret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update return: %w", err)
+		}
+		if result.MatchedCount == 1 {
+			return ret, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: return %s", ErrConcurrentModification, id)
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrShipmentNotFound = errors.New("shipment not found")
+
+type ShipmentRepository interface {
+	Create(ctx context.Context, shipment *models.Shipment) (*models.Shipment, error)
+	FindByID(ctx context.Context, id string) (*models.Shipment, error)
+	FindByOrderID(ctx context.Context, orderID string) ([]*models.Shipment, error)
+	Delete(ctx context.Context, id bson.ObjectID) error
+	AttachTracking(ctx context.Context, id, carrier, trackingNumber, trackingURL string) (*models.Shipment, error)
+	MarkDelivered(ctx context.Context, id string) (*models.Shipment, error)
+	RevertDelivery(ctx context.Context, id string) (*models.Shipment, error)
+}
+
+type shipmentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewShipmentRepository(db mongodm.Database) (ShipmentRepository, error) {
+	collection := db.GetWriteDB().Collection("shipments", collectionOptions())
+	return &shipmentRepository{
+		collection: collection,
+	}, nil
+}
+
+func (r *shipmentRepository) Create(ctx context.Context, shipment *models.Shipment) (*models.Shipment, error) {
+	if shipment.ID.IsZero() {
+		shipment.ID = bson.NewObjectID()
+	}
+	shipment.SetCreatedAt(time.Now())
+	shipment.SetUpdatedAt(time.Now())
+	shipment.Initialise(ctx)
+	if err := shipment.Validate(ctx); err != nil {
+		return nil, err
+	}
+	_, err := r.collection.InsertOne(ctx, shipment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipment: %w", err)
+	}
+	return shipment, nil
+}
+
+func (r *shipmentRepository) FindByID(ctx context.Context, id string) (*models.Shipment, error) {
+	objID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shipment ID: %w", err)
+	}
+	var shipment models.Shipment
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&shipment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrShipmentNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shipment: %w", err)
+	}
+	return &shipment, nil
+}
+
+func (r *shipmentRepository) FindByOrderID(ctx context.Context, orderID string) ([]*models.Shipment, error) {
+	objID, err := bson.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": objID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shipments: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+	var shipments []*models.Shipment
+	for cursor.Next(ctx) {
+		var shipment models.Shipment
+		if err := cursor.Decode(&shipment); err != nil {
+			return nil, fmt.Errorf("failed to decode shipment: %w", err)
+		}
+		shipments = append(shipments, &shipment)
+	}
+	return shipments, cursor.Err()
+}
+
+func (r *shipmentRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete shipment: %w", err)
+	}
+	return nil
+}
+
+func (r *shipmentRepository) AttachTracking(ctx context.Context, id, carrier, trackingNumber, trackingURL string) (*models.Shipment, error) {
+	return r.modify(ctx, id, func(shipment *models.Shipment) error {
+		return shipment.AttachTracking(carrier, trackingNumber, trackingURL, time.Now())
+	})
+}
+
+func (r *shipmentRepository) MarkDelivered(ctx context.Context, id string) (*models.Shipment, error) {
+	return r.modify(ctx, id, func(shipment *models.Shipment) error {
+		return shipment.MarkDelivered(time.Now())
+	})
+}
+
+func (r *shipmentRepository) RevertDelivery(ctx context.Context, id string) (*models.Shipment, error) {
+	return r.modify(ctx, id, func(shipment *models.Shipment) error {
+		return shipment.RevertDelivery()
+	})
+}
+
+// modify applies mutate and replaces the document, guarded by updated_at so a concurrent writer causes a reload and retry
+func (r *shipmentRepository) modify(ctx context.Context, id string, mutate func(shipment *models.Shipment) error) (*models.Shipment, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		shipment, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		previousUpdatedAt := shipment.UpdatedAt
+		if err := mutate(shipment); err != nil {
+			return nil, err
+		}
+		shipment.SetUpdatedAt(time.Now())
+
+		result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": shipment.ID, "updated_at": previousUpdatedAt}, shipment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update shipment: %w", err)
+		}
+		if result.MatchedCount == 1 {
+			return shipment, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: shipment %s", ErrConcurrentModification, id)
+}
@@ -0,0 +1,51 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header used to carry the request ID in and out of the service
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// MaxLength is the longest request ID accepted from a client
+const MaxLength = 128
+
+// New generates a fresh request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether a client-supplied request ID is safe to log and echo back: at most
+// MaxLength letters, digits, '-', '_', '.' or ':'
+func Valid(requestID string) bool {
+	if requestID == "" || len(requestID) > MaxLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying the given request ID
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
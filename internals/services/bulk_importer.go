@@ -0,0 +1,675 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/allocation"
+	"oms-service-goc/internals/bulk"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/requestid"
+	"oms-service-goc/internals/storage"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omniful/go_commons/sqs"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// bulkRollbackChunk is how many orders are cancelled per write when a bulk job is rolled back
+const bulkRollbackChunk = 500
+
+// errImportCancelled stops an import whose job was asked to be cancelled
+var errImportCancelled = errors.New("bulk import cancelled")
+
+// BulkImportOptions tunes how the importer writes orders
+type BulkImportOptions struct {
+	BatchSize           int // orders inserted per write
+	Concurrency         int // batches written at once per job
+	SellerConcurrency   int // batches written at once per seller across their jobs, 0 for no limit
+	SellerRowsPerMinute int // rows written per minute per seller, 0 for no limit
+}
+
+// BulkImporter is the worker side of bulk orders: it reads a queued file, turns its rows into
+// orders and records a summary and error report on the bulk job
+type BulkImporter struct {
+	orderRepo      repositories.OrderRepository
+	jobRepo        repositories.BulkJobRepository
+	templateRepo   repositories.ImportTemplateRepository
+	allocator      *allocation.Allocator
+	store          storage.FileStore
+	reportLocation string
+	options        BulkImportOptions
+	throttle       *sellerThrottle
+}
+
+// NewBulkImporter reads bulk files through store and writes error reports under reportLocation.
+// Orders without a hub_id are given one by allocator; without an allocator they are rejected.
+func NewBulkImporter(orderRepo repositories.OrderRepository, jobRepo repositories.BulkJobRepository, templateRepo repositories.ImportTemplateRepository, allocator *allocation.Allocator, store storage.FileStore, reportLocation string, options BulkImportOptions) *BulkImporter {
+	if options.BatchSize <= 0 {
+		options.BatchSize = 1
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	return &BulkImporter{
+		orderRepo:      orderRepo,
+		jobRepo:        jobRepo,
+		templateRepo:   templateRepo,
+		allocator:      allocator,
+		store:          store,
+		reportLocation: reportLocation,
+		options:        options,
+		throttle:       newSellerThrottle(options.SellerConcurrency, options.SellerRowsPerMinute),
+	}
+}
+
+// HandleMessage processes a CreateBulkOrderEvent taken off the bulk order queue
+func (i *BulkImporter) HandleMessage(ctx context.Context, message *sqs.Message) error {
+	var event models.CreateBulkOrderEvent
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		log.ErrorfWithContext(ctx, "failed to decode bulk order message: %v", err)
+		return fmt.Errorf("failed to decode bulk order message: %w", err)
+	}
+	if event.RequestID != "" {
+		ctx = requestid.NewContext(ctx, event.RequestID)
+	}
+	_, err := i.Process(ctx, event.JobID)
+	return err
+}
+
+// Process imports the file of a bulk job. Rows and orders that are rejected go to the error
+// report and the rest of the file carries on; an error is only returned, and the job marked
+// failed, when the file cannot be imported at all. A dry run does everything but create orders.
+//
+// Processing a job again, as when its message is redelivered after a crash, resumes from the
+// job's checkpoint and never creates an order twice. Completed and cancelled jobs are left
+// alone. When a cancellation is requested the import stops at the next batch and the job is
+// cancelled instead of completed.
+func (i *BulkImporter) Process(ctx context.Context, jobID string) (*models.BulkJob, error) {
+	job, err := i.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to load bulk job %s: %v", jobID, err)
+		return nil, fmt.Errorf("failed to load bulk job: %w", err)
+	}
+	if job.Status == models.BulkJobCompleted || job.Status == models.BulkJobCancelled {
+		log.InfofWithContext(ctx, "Bulk job %s is already %s, skipping", jobID, job.Status)
+		return job, nil
+	}
+	if job.CancelRequested {
+		return i.cancel(ctx, jobID)
+	}
+	if job.Checkpoint > 0 {
+		log.InfofWithContext(ctx, "Resuming bulk job %s after line %d", jobID, job.Checkpoint)
+	}
+
+	job.Status = models.BulkJobRunning
+	job.Error = ""
+	if err := i.jobRepo.Update(ctx, job); err != nil {
+		if errors.Is(err, repositories.ErrBulkJobCancelled) {
+			return i.cancel(ctx, jobID)
+		}
+		log.ErrorfWithContext(ctx, "failed to mark bulk job %s running: %v", jobID, err)
+		return nil, fmt.Errorf("failed to update bulk job: %w", err)
+	}
+
+	summary, rowErrors, err := i.importFile(ctx, job)
+	if errors.Is(err, errImportCancelled) {
+		return i.cancel(ctx, jobID)
+	}
+	if err != nil {
+		log.ErrorfWithContext(ctx, "bulk job %s failed: %v", jobID, err)
+		job.Status = models.BulkJobFailed
+		job.Error = err.Error()
+		if err := i.jobRepo.Update(ctx, job); err != nil {
+			if errors.Is(err, repositories.ErrBulkJobCancelled) {
+				return i.cancel(ctx, jobID)
+			}
+			log.ErrorfWithContext(ctx, "failed to record bulk job %s failure: %v", jobID, err)
+		}
+		return job, err
+	}
+
+	job.ErrorReport = ""
+	if len(rowErrors) > 0 {
+		report := storage.Join(i.reportLocation, job.ID.Hex()+"-errors.csv")
+		if err := bulk.WriteErrorReport(ctx, i.store, report, rowErrors); err != nil {
+			log.ErrorfWithContext(ctx, "failed to write error report of bulk job %s: %v", jobID, err)
+		} else {
+			job.ErrorReport = report
+		}
+	}
+
+	job.Status = models.BulkJobCompleted
+	job.Summary = summary
+	if err := i.jobRepo.Update(ctx, job); err != nil {
+		if errors.Is(err, repositories.ErrBulkJobCancelled) {
+			return i.cancel(ctx, jobID)
+		}
+		log.ErrorfWithContext(ctx, "failed to complete bulk job %s: %v", jobID, err)
+		return nil, fmt.Errorf("failed to update bulk job: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Bulk job %s completed (dry run: %t): %d rows, %d orders created, %d rejected",
+		jobID, job.DryRun, summary.Rows, summary.Created, summary.Rejected)
+	return job, nil
+}
+
+// cancel finishes a cancellation requested while the job was queued or being imported
+func (i *BulkImporter) cancel(ctx context.Context, jobID string) (*models.BulkJob, error) {
+	job, err := i.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to load cancelled bulk job %s: %v", jobID, err)
+		return nil, fmt.Errorf("failed to load bulk job: %w", err)
+	}
+	if err := cancelBulkJob(ctx, i.orderRepo, i.jobRepo, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// cancelBulkJob marks a job cancelled, first cancelling the orders it created when a rollback
+// was asked for. Orders already cancelled are skipped, so finishing a cancellation twice is safe.
+func cancelBulkJob(ctx context.Context, orderRepo repositories.OrderRepository, jobRepo repositories.BulkJobRepository, job *models.BulkJob) error {
+	if job.Rollback && !job.DryRun {
+		rollback, err := rollbackBulkOrders(ctx, orderRepo, job.ID.Hex())
+		if err != nil {
+			log.ErrorfWithContext(ctx, "failed to roll back bulk job %s: %v", job.ID.Hex(), err)
+			return fmt.Errorf("failed to roll back bulk job: %w", err)
+		}
+		job.RolledBack = rollback
+	}
+
+	now := time.Now()
+	job.Status = models.BulkJobCancelled
+	job.CancelledAt = &now
+	if err := jobRepo.Update(ctx, job); err != nil {
+		log.ErrorfWithContext(ctx, "failed to mark bulk job %s cancelled: %v", job.ID.Hex(), err)
+		return fmt.Errorf("failed to update bulk job: %w", err)
+	}
+
+	if job.RolledBack != nil {
+		log.InfofWithContext(ctx, "Bulk job %s cancelled, %d orders cancelled with it and %d left as they were",
+			job.ID.Hex(), job.RolledBack.Cancelled, job.RolledBack.Failed)
+	} else {
+		log.InfofWithContext(ctx, "Bulk job %s cancelled", job.ID.Hex())
+	}
+	return nil
+}
+
+// rollbackBulkOrders cancels the orders a bulk job created, a chunk at a time. Orders that can
+// no longer be cancelled, such as shipped ones, are counted as failed and left alone.
+func rollbackBulkOrders(ctx context.Context, orderRepo repositories.OrderRepository, jobID string) (*models.BulkJobRollback, error) {
+	rollback := &models.BulkJobRollback{}
+	var orders []*models.Order
+	flush := func() error {
+		if len(orders) == 0 {
+			return nil
+		}
+		results, err := orderRepo.BulkUpdateStatus(ctx, orders, models.OrderStatusCancelled)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				log.InfofWithContext(ctx, "Order %s of bulk job %s was not cancelled: %v", result.OrderID, jobID, result.Err)
+				rollback.Failed++
+				continue
+			}
+			rollback.Cancelled++
+		}
+		orders = nil
+		return nil
+	}
+
+	err := orderRepo.Stream(ctx, repositories.OrderFilters{BulkJobID: jobID}, func(order *models.Order) error {
+		if order.Status == models.OrderStatusCancelled {
+			return nil
+		}
+		orders = append(orders, order)
+		if len(orders) < bulkRollbackChunk {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// importResult gathers the outcome of an import from the goroutines writing its batches
+type importResult struct {
+	mu        sync.Mutex
+	summary   models.BulkJobSummary
+	rowErrors []bulk.RowError
+}
+
+func (r *importResult) reject(duplicate bool, rowErrors ...bulk.RowError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rowErrors = append(r.rowErrors, rowErrors...)
+	r.summary.Rejected++
+	if duplicate {
+		r.summary.Duplicates++
+	}
+}
+
+func (r *importResult) created(count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Created += count
+}
+
+// merge adds the outcome of one batch's write
+func (r *importResult) merge(batch *importResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rowErrors = append(r.rowErrors, batch.rowErrors...)
+	addOutcome(&r.summary, batch.summary)
+}
+
+// addOutcome adds what became of more orders to a summary
+func addOutcome(summary *models.BulkJobSummary, more models.BulkJobSummary) {
+	summary.Orders += more.Orders
+	summary.Created += more.Created
+	summary.Rejected += more.Rejected
+	summary.Duplicates += more.Duplicates
+}
+
+// importFile streams the job's file through a pipeline: the reader groups rows into orders,
+// allocates a hub to those without one, validates them and checks order_refs within the file, then hands batches of orders to a
+// pool of writers that check order_refs against stored orders and insert the rest. The batch
+// channel is bounded, so the reader waits whenever the writers fall behind. It returns the
+// summary and the errors sorted by line.
+//
+// A resumed import starts from the checkpoint's progress and skips the rows of orders that
+// ended at or before the checkpoint without grouping or writing them again, so rows rejected
+// there are counted in the summary but no longer listed in the error report.
+func (i *BulkImporter) importFile(ctx context.Context, job *models.BulkJob) (*models.BulkJobSummary, []bulk.RowError, error) {
+	mapping, err := loadMapping(ctx, i.templateRepo, job.TemplateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := bulk.ReadOptions{
+		Format:  bulk.InputFormat(job.Format),
+		Sheet:   job.Sheet,
+		Mapping: mapping,
+	}
+
+	// A first pass finds where each order ends so the second can release orders as it goes
+	lastLines := map[string]int{}
+	_, err = bulk.ScanFile(ctx, i.store, job.FilePath, opts, func(row bulk.ParsedRow) error {
+		if key := bulk.GroupKey(row.Row); key != "" {
+			lastLines[key] = row.Line
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read bulk file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		writeErr  error
+		writeOnce sync.Once
+		writers   sync.WaitGroup
+		result    importResult
+		stopped   atomic.Bool // set once a cancellation is seen, so batches still queued are dropped
+	)
+	var progress models.BulkJobSummary
+	if job.Progress != nil {
+		progress = *job.Progress
+	}
+	checkpoints := checkpointer{line: job.Checkpoint, progress: progress, done: map[int]checkpoint{}}
+	seller := bulkOwnerKey(job)
+	batches := make(chan importBatch, i.options.Concurrency)
+	for w := 0; w < i.options.Concurrency; w++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for batch := range batches {
+				if ctx.Err() != nil || stopped.Load() {
+					continue
+				}
+				var written importResult
+				if err := i.writeThrottled(ctx, job, seller, batch, &written); err != nil {
+					writeOnce.Do(func() {
+						writeErr = err
+						cancel()
+					})
+					continue
+				}
+				result.merge(&written)
+				outcome := batch.read
+				addOutcome(&outcome, written.summary)
+				if line, progress, moved := checkpoints.finish(batch.seq, checkpoint{line: batch.line, progress: outcome}); moved && !job.DryRun {
+					if err := i.jobRepo.SaveCheckpoint(ctx, job.ID, line, progress); err != nil {
+						log.ErrorfWithContext(ctx, "failed to save checkpoint of bulk job %s: %v", job.ID.Hex(), err)
+					}
+				}
+			}
+		}()
+	}
+
+	var (
+		rows     int
+		orders   int
+		lastLine int
+		batch    importBatch
+	)
+	used := map[string]int{} // order_ref key to the first line using it in this file
+	send := func() error {
+		if len(batch.groups) == 0 {
+			return nil
+		}
+		// Batch boundaries are where a requested cancellation takes effect
+		if i.cancelRequested(ctx, job.ID.Hex()) {
+			stopped.Store(true)
+			return errImportCancelled
+		}
+		batch.line = lastLine
+		select {
+		case batches <- batch:
+			batch = importBatch{seq: batch.seq + 1}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	accept := func(group *bulk.OrderGroup) error {
+		orders++
+		batch.read.Orders++
+		if rowErrors := group.Allocate(ctx, i.allocator); len(rowErrors) > 0 {
+			result.reject(false, rowErrors...)
+			batch.read.Rejected++
+			return nil
+		}
+		if rowErrors := group.Validate(ctx); len(rowErrors) > 0 {
+			result.reject(false, rowErrors...)
+			batch.read.Rejected++
+			return nil
+		}
+		if rowErrors := checkOrderRef(group, used); len(rowErrors) > 0 {
+			result.reject(true, rowErrors...)
+			batch.read.Rejected++
+			batch.read.Duplicates++
+			return nil
+		}
+		batch.groups = append(batch.groups, group)
+		if len(batch.groups) < i.options.BatchSize {
+			return nil
+		}
+		return send()
+	}
+
+	grouper := bulk.NewGrouper(lastLines)
+	rowErrors, err := bulk.ScanFile(ctx, i.store, job.FilePath, opts, func(row bulk.ParsedRow) error {
+		rows++
+		lastLine = row.Line
+		if row.Line <= job.Checkpoint {
+			key := bulk.GroupKey(row.Row)
+			if key == "" || lastLines[key] <= job.Checkpoint {
+				// The order was dealt with before the checkpoint; only its order_ref is kept,
+				// so later orders in the file using it again are still caught
+				skipOrderRef(row.Row, used, row.Line)
+				return nil
+			}
+		}
+		if group := grouper.Add(row); group != nil {
+			return accept(group)
+		}
+		return nil
+	})
+	if err == nil {
+		for _, group := range grouper.Flush() {
+			if err = accept(group); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = send()
+	}
+	close(batches)
+	writers.Wait()
+	job.Checkpoint = checkpoints.line
+	job.Progress = &checkpoints.progress
+
+	if errors.Is(err, errImportCancelled) {
+		return nil, nil, errImportCancelled
+	}
+	if writeErr != nil {
+		return nil, nil, writeErr
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read bulk file: %w", err)
+	}
+
+	rejectedLines := map[int]bool{}
+	for _, rowError := range rowErrors {
+		rejectedLines[rowError.Line] = true
+	}
+	rowErrors = append(rowErrors, result.rowErrors...)
+	sort.SliceStable(rowErrors, func(a, b int) bool { return rowErrors[a].Line < rowErrors[b].Line })
+
+	summary := result.summary
+	summary.Orders = orders
+	addOutcome(&summary, progress)
+	summary.Rows = rows + len(rejectedLines)
+	summary.RowErrors = len(rowErrors)
+	return &summary, rowErrors, nil
+}
+
+// cancelRequested checks whether the job was asked to be cancelled since it was loaded. A
+// failed check is logged and the import carries on; the next batch checks again.
+func (i *BulkImporter) cancelRequested(ctx context.Context, jobID string) bool {
+	job, err := i.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to check bulk job %s for cancellation: %v", jobID, err)
+		return false
+	}
+	return job.CancelRequested
+}
+
+// importBatch is a run of orders handed to the writers. Every order in it ends at or before
+// line, and batches are numbered in the order they leave the reader.
+type importBatch struct {
+	seq    int
+	line   int
+	groups []*bulk.OrderGroup
+	read   models.BulkJobSummary // orders read since the previous batch, and those rejected while reading
+}
+
+// checkpointer tracks which batches are written. Batches finish out of order, so the
+// checkpoint only moves past a batch once every earlier batch has finished too.
+type checkpointer struct {
+	mu       sync.Mutex
+	next     int                // oldest batch not yet finished
+	done     map[int]checkpoint // batches finished ahead of next
+	line     int
+	progress models.BulkJobSummary // what became of the orders up to line
+}
+
+// checkpoint is where a finished batch ends and what became of the orders read for it
+type checkpoint struct {
+	line     int
+	progress models.BulkJobSummary
+}
+
+// finish records a written batch and returns the checkpoint and its progress when it moved
+func (c *checkpointer) finish(seq int, batch checkpoint) (int, models.BulkJobSummary, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done[seq] = batch
+	moved := false
+	for {
+		batch, ok := c.done[c.next]
+		if !ok {
+			return c.line, c.progress, moved
+		}
+		delete(c.done, c.next)
+		c.next++
+		addOutcome(&c.progress, batch.progress)
+		if batch.line > c.line {
+			c.line, moved = batch.line, true
+		}
+	}
+}
+
+// bulkOrderID is the ID of the order a bulk job creates from the row group starting at line,
+// the same on every attempt so a retried write can never create the order twice. It keeps
+// the job ID's timestamp so the orders sort with others created at the time.
+func bulkOrderID(jobID bson.ObjectID, line int) bson.ObjectID {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", jobID.Hex(), line)))
+	var id bson.ObjectID
+	copy(id[:4], jobID[:4])
+	copy(id[4:], sum[:8])
+	return id
+}
+
+// writeThrottled writes a batch once the seller's concurrency cap and row quota allow it.
+// Dry runs create nothing and are not held back.
+func (i *BulkImporter) writeThrottled(ctx context.Context, job *models.BulkJob, seller string, batch importBatch, result *importResult) error {
+	if job.DryRun {
+		return i.writeBatch(ctx, job, batch, result)
+	}
+	rows := 0
+	for _, group := range batch.groups {
+		rows += len(group.Lines)
+	}
+	release, err := i.throttle.acquire(ctx, seller, rows)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return i.writeBatch(ctx, job, batch, result)
+}
+
+// writeBatch rejects the orders of a batch whose order_ref is already stored and inserts the
+// rest in one write, unless the job is a dry run. An order already stored under its ID counts
+// as created: it was written by an attempt that stopped before saving the checkpoint.
+func (i *BulkImporter) writeBatch(ctx context.Context, job *models.BulkJob, batch importBatch, result *importResult) error {
+	groups := batch.groups
+	for _, group := range groups {
+		group.Order.ID = bulkOrderID(job.ID, group.Lines[0])
+		group.Order.BulkJobID = job.ID.Hex()
+	}
+
+	existing, err := i.existingOrderRefs(ctx, groups)
+	if err != nil {
+		return err
+	}
+
+	var pending []*bulk.OrderGroup
+	for _, group := range groups {
+		orderID, ok := existing[orderRefKey(group.Order)]
+		if ok && orderID == group.Order.ID.Hex() {
+			result.created(1)
+			continue
+		}
+		if ok {
+			result.reject(true, bulk.RowError{Line: group.Lines[0], Column: "order_ref", Message: fmt.Sprintf("order_ref %s already exists as order %s", group.Order.OrderRef, orderID)})
+			continue
+		}
+		pending = append(pending, group)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if job.DryRun {
+		result.created(len(pending))
+		return nil
+	}
+
+	orders := make([]*models.Order, len(pending))
+	for k, group := range pending {
+		orders[k] = group.Order
+	}
+	errs, err := i.orderRepo.CreateMany(ctx, orders)
+	if err != nil {
+		return fmt.Errorf("failed to create orders from line %d: %w", pending[0].Lines[0], err)
+	}
+	created := 0
+	for k, err := range errs {
+		if err != nil && !errors.Is(err, repositories.ErrOrderExists) {
+			result.reject(false, bulk.RowError{Line: pending[k].Lines[0], Message: err.Error()})
+			continue
+		}
+		created++
+	}
+	result.created(created)
+	return nil
+}
+
+// orderRefKey scopes an order_ref to its tenant and seller
+func orderRefKey(order *models.Order) string {
+	return strings.Join([]string{order.TenantID, order.SellerID, order.OrderRef}, "\x00")
+}
+
+// existingOrderRefs looks up which of the groups' order_refs are already used by stored
+// orders, returning the ID of the order using each one
+func (i *BulkImporter) existingOrderRefs(ctx context.Context, groups []*bulk.OrderGroup) (map[string]string, error) {
+	type seller struct{ tenantID, sellerID string }
+	refs := map[seller][]string{}
+	for _, group := range groups {
+		if group.Order.OrderRef == "" {
+			continue
+		}
+		key := seller{group.Order.TenantID, group.Order.SellerID}
+		refs[key] = append(refs[key], group.Order.OrderRef)
+	}
+
+	existing := map[string]string{}
+	for key, orderRefs := range refs {
+		orders, err := i.orderRepo.FindByFilters(ctx, repositories.OrderFilters{
+			TenantID:  key.tenantID,
+			SellerID:  key.sellerID,
+			OrderRefs: orderRefs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up existing order refs: %w", err)
+		}
+		for _, order := range orders {
+			existing[orderRefKey(order)] = order.ID.Hex()
+		}
+	}
+	return existing, nil
+}
+
+// checkOrderRef rejects a group whose order_ref an earlier group of the same file already
+// used, e.g. one with the same reference but a different hub. As these never reach the
+// writers, batches written at the same time never hold the same order_ref.
+func checkOrderRef(group *bulk.OrderGroup, used map[string]int) []bulk.RowError {
+	if group.Order.OrderRef == "" {
+		return nil
+	}
+	key := orderRefKey(group.Order)
+	if line, ok := used[key]; ok {
+		return []bulk.RowError{{Line: group.Lines[0], Column: "order_ref", Message: fmt.Sprintf("order_ref %s is already used on line %d", group.Order.OrderRef, line)}}
+	}
+	used[key] = group.Lines[0]
+	return nil
+}
+
+// skipOrderRef records the order_ref of a row skipped on resume, as checkOrderRef would have
+func skipOrderRef(row models.OrderCSVRow, used map[string]int, line int) {
+	if row.OrderRef == "" {
+		return
+	}
+	key := orderRefKey(&models.Order{TenantID: row.TenantID, SellerID: row.SellerID, OrderRef: row.OrderRef})
+	if _, ok := used[key]; !ok {
+		used[key] = line
+	}
+}
@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/allocation"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/storage"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memoryOrderRepository keeps orders in a map and waits roundTrip on every call, standing in
+// for the network so batching shows up in the numbers
+type memoryOrderRepository struct {
+	repositories.OrderRepository
+	roundTrip time.Duration
+
+	mu         sync.Mutex
+	orders     []*models.Order
+	failAt     int              // CreateMany fails once this many orders are stored, when set
+	afterWrite func(orders int) // called after each CreateMany with the number of orders stored
+	lookedUp   []string         // order_refs FindByFilters was asked for
+}
+
+func (r *memoryOrderRepository) Create(ctx context.Context, order *models.Order) (*models.Order, error) {
+	errs, err := r.CreateMany(ctx, []*models.Order{order})
+	if err != nil {
+		return nil, err
+	}
+	return order, errs[0]
+}
+
+func (r *memoryOrderRepository) CreateMany(ctx context.Context, orders []*models.Order) ([]error, error) {
+	time.Sleep(r.roundTrip)
+	errs := make([]error, len(orders))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failAt > 0 && len(r.orders) >= r.failAt {
+		return nil, errors.New("connection lost")
+	}
+	for i, order := range orders {
+		if order.ID.IsZero() {
+			order.ID = bson.NewObjectID()
+		}
+		if r.find(order.ID) != nil {
+			errs[i] = fmt.Errorf("%w: %s", repositories.ErrOrderExists, order.ID.Hex())
+			continue
+		}
+		order.Initialise(ctx)
+		if errs[i] = order.Validate(ctx); errs[i] == nil {
+			stored := *order
+			r.orders = append(r.orders, &stored)
+		}
+	}
+	if r.afterWrite != nil {
+		r.afterWrite(len(r.orders))
+	}
+	return errs, nil
+}
+
+func (r *memoryOrderRepository) find(id bson.ObjectID) *models.Order {
+	for _, order := range r.orders {
+		if order.ID == id {
+			return order
+		}
+	}
+	return nil
+}
+
+// FindByFilters supports the lookup the importer makes, by order_refs of one tenant and seller
+func (r *memoryOrderRepository) FindByFilters(ctx context.Context, filters repositories.OrderFilters) ([]*models.Order, error) {
+	time.Sleep(r.roundTrip)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookedUp = append(r.lookedUp, filters.OrderRefs...)
+	var found []*models.Order
+	refs := map[string]bool{}
+	for _, ref := range filters.OrderRefs {
+		refs[ref] = true
+	}
+	for _, order := range r.orders {
+		if order.TenantID == filters.TenantID && order.SellerID == filters.SellerID && refs[order.OrderRef] {
+			found = append(found, order)
+		}
+	}
+	return found, nil
+}
+
+// Stream supports the rollback's lookup of the orders of one bulk job
+func (r *memoryOrderRepository) Stream(ctx context.Context, filters repositories.OrderFilters, fn func(order *models.Order) error) error {
+	r.mu.Lock()
+	var found []*models.Order
+	for _, order := range r.orders {
+		if order.BulkJobID == filters.BulkJobID {
+			copied := *order
+			found = append(found, &copied)
+		}
+	}
+	r.mu.Unlock()
+	for _, order := range found {
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryOrderRepository) BulkUpdateStatus(ctx context.Context, orders []*models.Order, status models.OrderStatus) ([]repositories.StatusResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]repositories.StatusResult, len(orders))
+	for i, order := range orders {
+		results[i].OrderID = order.ID.Hex()
+		stored := r.find(order.ID)
+		if err := stored.TransitionTo(status); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Order = stored
+	}
+	return results, nil
+}
+
+type memoryBulkJobRepository struct {
+	repositories.BulkJobRepository
+
+	mu   sync.Mutex
+	jobs map[string]models.BulkJob
+}
+
+func (r *memoryBulkJobRepository) Create(ctx context.Context, job *models.BulkJob) (*models.BulkJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.ID.IsZero() {
+		job.ID = bson.NewObjectID()
+	}
+	if r.jobs == nil {
+		r.jobs = map[string]models.BulkJob{}
+	}
+	r.jobs[job.ID.Hex()] = *job
+	return job, nil
+}
+
+func (r *memoryBulkJobRepository) FindByID(ctx context.Context, id string) (*models.BulkJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrBulkJobNotFound, id)
+	}
+	return &job, nil
+}
+
+func (r *memoryBulkJobRepository) Update(ctx context.Context, job *models.BulkJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !job.CancelRequested && r.jobs[job.ID.Hex()].CancelRequested {
+		return fmt.Errorf("%w: %s", repositories.ErrBulkJobCancelled, job.ID.Hex())
+	}
+	r.jobs[job.ID.Hex()] = *job
+	return nil
+}
+
+func (r *memoryBulkJobRepository) RequestCancel(ctx context.Context, id string, rollback bool) (*models.BulkJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrBulkJobNotFound, id)
+	}
+	if job.Status == models.BulkJobCancelled {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrBulkJobCancelled, id)
+	}
+	job.CancelRequested = true
+	job.Rollback = job.Rollback || rollback
+	r.jobs[id] = job
+	return &job, nil
+}
+
+func (r *memoryBulkJobRepository) SaveCheckpoint(ctx context.Context, id bson.ObjectID, line int, progress models.BulkJobSummary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[id.Hex()]
+	if line > job.Checkpoint {
+		job.Checkpoint = line
+		job.Progress = &progress
+	}
+	r.jobs[id.Hex()] = job
+	return nil
+}
+
+// writeBulkFile writes rows of two-item orders for a fresh tenant, returning the file's URI
+func writeBulkFile(t testing.TB, store storage.FileStore, location string, rows int) string {
+	tenantID := bson.NewObjectID().Hex()
+	var file strings.Builder
+	file.WriteString("tenant_id,seller_id,hub_id,order_ref,sku_code,quantity\n")
+	for row := 0; row < rows; row++ {
+		fmt.Fprintf(&file, "%s,seller1,hub1,R-%d,SKU%03d,1\n", tenantID, row/2, row%2)
+	}
+	uri := storage.Join(location, tenantID+".csv")
+	require.NoError(t, storage.Put(context.Background(), store, uri, strings.NewReader(file.String())))
+	return uri
+}
+
+func TestBulkImporter_ResumesFromCheckpoint(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	// The writes stop after 30 of the file's 50 orders, as if the worker had crashed
+	orderRepo := &memoryOrderRepository{failAt: 30}
+	jobRepo := &memoryBulkJobRepository{}
+	importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 10, Concurrency: 1})
+
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: writeBulkFile(t, store, location, 100)})
+	require.NoError(t, err)
+	_, err = importer.Process(context.Background(), job.ID.Hex())
+	require.Error(t, err)
+
+	failed, err := jobRepo.FindByID(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobFailed, failed.Status)
+	assert.Equal(t, 61, failed.Checkpoint, "three batches of ten two-row orders end at line 61")
+	assert.Equal(t, &models.BulkJobSummary{Orders: 30, Created: 30}, failed.Progress)
+	assert.Len(t, orderRepo.orders, 30)
+
+	// The redelivered message picks up after the checkpoint without creating orders twice,
+	// and without looking up the orders before it
+	orderRepo.failAt = 0
+	orderRepo.lookedUp = nil
+	resumed, err := importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobCompleted, resumed.Status)
+	assert.Equal(t, &models.BulkJobSummary{Rows: 100, Orders: 50, Created: 50}, resumed.Summary)
+	assert.Len(t, orderRepo.orders, 50)
+	assert.Len(t, orderRepo.lookedUp, 20)
+	assert.NotContains(t, orderRepo.lookedUp, "R-29")
+	for _, order := range orderRepo.orders {
+		assert.Equal(t, job.ID.Hex(), order.BulkJobID)
+	}
+
+	// A message delivered again after completion changes nothing
+	again, err := importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, resumed.Summary, again.Summary)
+	assert.Len(t, orderRepo.orders, 50)
+}
+
+func TestBulkImporter_RetriedWritesAreIdempotent(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	orderRepo := &memoryOrderRepository{}
+	jobRepo := &memoryBulkJobRepository{}
+	importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 4, Concurrency: 2})
+
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: writeBulkFile(t, store, location, 40)})
+	require.NoError(t, err)
+	_, err = importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+
+	// A crash after the writes but before any checkpoint was saved
+	stored, err := jobRepo.FindByID(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	stored.Status, stored.Checkpoint, stored.Progress, stored.Summary = models.BulkJobRunning, 0, nil, nil
+	require.NoError(t, jobRepo.Update(context.Background(), stored))
+
+	retried, err := importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, &models.BulkJobSummary{Rows: 40, Orders: 20, Created: 20}, retried.Summary)
+	assert.Len(t, orderRepo.orders, 20)
+}
+
+func TestBulkImporter_BatchedWritesMatchOneByOne(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	for _, options := range []BulkImportOptions{{BatchSize: 1, Concurrency: 1}, {BatchSize: 7, Concurrency: 3}} {
+		orderRepo := &memoryOrderRepository{}
+		jobRepo := &memoryBulkJobRepository{}
+		importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), options)
+
+		filePath := writeBulkFile(t, store, location, 101)
+		job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: filePath})
+		require.NoError(t, err)
+
+		job, err = importer.Process(context.Background(), job.ID.Hex())
+		require.NoError(t, err)
+		assert.Equal(t, &models.BulkJobSummary{Rows: 101, Orders: 51, Created: 51}, job.Summary)
+		assert.Len(t, orderRepo.orders, 51)
+	}
+}
+
+// BenchmarkBulkImporter compares inserting one order per write with batched, concurrent
+// writes. The mongo cases need a local MongoDB like the other service tests:
+//
+//	go test ./internals/services -run '^$' -bench BulkImporter
+func BenchmarkBulkImporter(b *testing.B) {
+	const rows = 10000
+
+	root := b.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(b, err)
+	location := storage.LocalLocation(root)
+
+	repos := []struct {
+		name string
+		new  func(b *testing.B) (repositories.OrderRepository, repositories.BulkJobRepository)
+	}{
+		{"memory", func(b *testing.B) (repositories.OrderRepository, repositories.BulkJobRepository) {
+			return &memoryOrderRepository{roundTrip: 200 * time.Microsecond}, &memoryBulkJobRepository{}
+		}},
+		{"mongo", func(b *testing.B) (repositories.OrderRepository, repositories.BulkJobRepository) {
+			_, orderRepo, jobRepo, _, _ := setupBulkImporterTest(b)
+			return orderRepo, jobRepo
+		}},
+	}
+	modes := []struct {
+		name    string
+		options BulkImportOptions
+	}{
+		{"one_by_one", BulkImportOptions{BatchSize: 1, Concurrency: 1}},
+		{"batched", BulkImportOptions{BatchSize: 500, Concurrency: 4}},
+	}
+
+	for _, repo := range repos {
+		for _, mode := range modes {
+			b.Run(repo.name+"/"+mode.name, func(b *testing.B) {
+				orderRepo, jobRepo := repo.new(b)
+				importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), mode.options)
+
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					filePath := writeBulkFile(b, store, location, rows)
+					job, err := jobRepo.Create(context.Background(), &models.BulkJob{Status: models.BulkJobQueued, FilePath: filePath})
+					require.NoError(b, err)
+					b.StartTimer()
+
+					job, err = importer.Process(context.Background(), job.ID.Hex())
+					require.NoError(b, err)
+					require.Equal(b, rows/2, job.Summary.Created)
+				}
+				b.ReportMetric(float64(rows*b.N)/b.Elapsed().Seconds(), "rows/s")
+			})
+		}
+	}
+}
+
+func TestBulkImporter_AllocatesMissingHubs(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	file := "tenant_id,seller_id,hub_id,order_ref,sku_code,quantity\n" +
+		"tenant1,seller1,,R-1,SKU001,1\n" +
+		"tenant1,seller1,own-hub,R-2,SKU001,1\n"
+	uri := storage.Join(location, "hubs.csv")
+	require.NoError(t, storage.Put(context.Background(), store, uri, strings.NewReader(file)))
+
+	hubs := allocation.StaticHubs{{ID: "blr-1", Country: "IN"}, {ID: "del-1", Country: "IN"}}
+	allocator := allocation.NewAllocator(hubs, allocation.PriorityStrategy{Priorities: map[string][]string{"seller1": {"del-1"}}})
+	for _, tc := range []struct {
+		name      string
+		allocator *allocation.Allocator
+		hubs      map[string]string // order_ref to the hub it was stored with
+		rejected  []string
+	}{
+		{name: "allocated", allocator: allocator, hubs: map[string]string{"R-1": "del-1", "R-2": "own-hub"}},
+		{name: "no allocator", hubs: map[string]string{"R-2": "own-hub"}, rejected: []string{"line 2, column hub_id: hub_id is required"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			orderRepo := &memoryOrderRepository{}
+			jobRepo := &memoryBulkJobRepository{}
+			importer := NewBulkImporter(orderRepo, jobRepo, nil, tc.allocator, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 10, Concurrency: 1})
+			job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: uri})
+			require.NoError(t, err)
+
+			summary, rowErrors, err := importer.importFile(context.Background(), job)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.hubs), summary.Created)
+
+			var rejected []string
+			for _, rowError := range rowErrors {
+				rejected = append(rejected, rowError.Error())
+			}
+			assert.Equal(t, tc.rejected, rejected)
+
+			stored := map[string]string{}
+			for _, order := range orderRepo.orders {
+				stored[order.OrderRef] = order.HubID
+			}
+			assert.Equal(t, tc.hubs, stored)
+		})
+	}
+}
+
+func TestBulkImporter_ImportsPricing(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	file := "tenant_id,seller_id,hub_id,order_ref,sku_code,quantity,unit_price,discount,tax_rate,shipping_fee,currency\n" +
+		"tenant1,seller1,hub1,R-1,SKU001,2,10,2,10,5,inr\n" +
+		"tenant1,seller1,hub1,R-1,SKU002,1,6,,,,\n" +
+		"tenant1,seller1,hub1,R-2,SKU001,1,10,,,,\n"
+	uri := storage.Join(location, "priced.csv")
+	require.NoError(t, storage.Put(context.Background(), store, uri, strings.NewReader(file)))
+
+	orderRepo := &memoryOrderRepository{}
+	jobRepo := &memoryBulkJobRepository{}
+	importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 10, Concurrency: 1})
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: uri})
+	require.NoError(t, err)
+
+	summary, rowErrors, err := importer.importFile(context.Background(), job)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Created)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 4, rowErrors[0].Line)
+	assert.Contains(t, rowErrors[0].Message, "currency is required for priced orders")
+
+	require.Len(t, orderRepo.orders, 1)
+	order := orderRepo.orders[0]
+	assert.Equal(t, "INR", order.Currency)
+	assert.Equal(t, "26", order.Subtotal.String())
+	assert.Equal(t, "1.8", order.TaxTotal.String())
+	assert.Equal(t, "30.8", order.Total.String())
+}
+
+func TestBulkImporter_CancelStopsAtBatchAndRollsBack(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	orderRepo := &memoryOrderRepository{}
+	jobRepo := &memoryBulkJobRepository{}
+	importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 10, Concurrency: 1})
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: writeBulkFile(t, store, location, 200)})
+	require.NoError(t, err)
+
+	// The seller cancels once the first two batches are in
+	var once sync.Once
+	orderRepo.afterWrite = func(orders int) {
+		if orders >= 20 {
+			once.Do(func() {
+				_, err := jobRepo.RequestCancel(context.Background(), job.ID.Hex(), true)
+				assert.NoError(t, err)
+			})
+		}
+	}
+
+	cancelled, err := importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+
+	assert.Equal(t, models.BulkJobCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.CancelledAt)
+	assert.Less(t, len(orderRepo.orders), 100, "the import must stop before the end of the file")
+	require.NotNil(t, cancelled.RolledBack)
+	assert.Equal(t, len(orderRepo.orders), cancelled.RolledBack.Cancelled)
+	assert.Zero(t, cancelled.RolledBack.Failed)
+	for _, order := range orderRepo.orders {
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	}
+
+	// The message delivered again leaves the cancelled job alone
+	again, err := importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobCancelled, again.Status)
+}
+
+func TestBulkOrderService_CancelCompletedJobRollsBackWhatItCan(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	orderRepo := &memoryOrderRepository{}
+	jobRepo := &memoryBulkJobRepository{}
+	importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 10, Concurrency: 2})
+	service := NewBulkOrderService(orderRepo, jobRepo, nil, nil, store, location, BulkUploadLimits{})
+
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: writeBulkFile(t, store, location, 20)})
+	require.NoError(t, err)
+	_, err = importer.Process(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, orderRepo.orders, 10)
+	orderRepo.orders[0].Status = models.OrderStatusShipped
+
+	cancelled, err := service.CancelBulkJob(context.Background(), job.ID.Hex(), true)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobCancelled, cancelled.Status)
+	assert.Equal(t, &models.BulkJobRollback{Cancelled: 9, Failed: 1}, cancelled.RolledBack)
+
+	_, err = service.CancelBulkJob(context.Background(), job.ID.Hex(), false)
+	assert.ErrorIs(t, err, repositories.ErrBulkJobCancelled)
+}
+
+func TestBulkOrderService_CancelQueuedJobWithoutRollback(t *testing.T) {
+	orderRepo := &memoryOrderRepository{}
+	jobRepo := &memoryBulkJobRepository{}
+	service := NewBulkOrderService(orderRepo, jobRepo, nil, nil, nil, "", BulkUploadLimits{})
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{Status: models.BulkJobQueued})
+	require.NoError(t, err)
+
+	cancelled, err := service.CancelBulkJob(context.Background(), job.ID.Hex(), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobCancelled, cancelled.Status)
+	assert.Nil(t, cancelled.RolledBack)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"io"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/storage"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func setupBulkImporterTest(t testing.TB) (*BulkImporter, repositories.OrderRepository, repositories.BulkJobRepository, storage.FileStore, string) {
+	db := newTestDatabase(t)
+	orderRepo, err := repositories.NewOrderRepository(db)
+	require.NoError(t, err)
+	jobRepo, err := repositories.NewBulkJobRepository(db)
+	require.NoError(t, err)
+	templateRepo, err := repositories.NewImportTemplateRepository(db)
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	importer := NewBulkImporter(orderRepo, jobRepo, templateRepo, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 2, Concurrency: 2})
+	return importer, orderRepo, jobRepo, store, location
+}
+
+func queueTestBulkJob(t testing.TB, jobRepo repositories.BulkJobRepository, filePath string, dryRun bool) *models.BulkJob {
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{
+		Status:   models.BulkJobQueued,
+		FilePath: filePath,
+		DryRun:   dryRun,
+		UserID:   "user123",
+	})
+	require.NoError(t, err)
+	return job
+}
+
+func TestBulkImporter_DryRunMatchesRealRun(t *testing.T) {
+	importer, orderRepo, jobRepo, store, location := setupBulkImporterTest(t)
+	ctx := context.Background()
+
+	// A fresh tenant keeps order_refs from earlier test runs out of the duplicate check
+	tenantID := bson.NewObjectID().Hex()
+	file := strings.Join([]string{
+		"tenant_id,seller_id,hub_id,order_ref,sku_code,quantity",
+		tenantID + ",seller1,hub1,A-1,SKU001,2",
+		tenantID + ",seller1,hub1,A-1,SKU002,1",
+		tenantID + ",seller1,hub1,,SKU003,x",
+		tenantID + ",seller1,hub2,A-1,SKU004,1",
+		tenantID + ",seller1,hub1,A-2,SKU005,3",
+	}, "\n")
+	filePath := storage.Join(location, "orders.csv")
+	require.NoError(t, storage.Put(ctx, store, filePath, strings.NewReader(file)))
+
+	dryRun, err := importer.Process(ctx, queueTestBulkJob(t, jobRepo, filePath, true).ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobCompleted, dryRun.Status)
+	assert.Equal(t, &models.BulkJobSummary{Rows: 5, RowErrors: 2, Orders: 3, Created: 2, Rejected: 1, Duplicates: 1}, dryRun.Summary)
+
+	orders, err := orderRepo.FindByFilters(ctx, repositories.OrderFilters{TenantID: tenantID})
+	require.NoError(t, err)
+	assert.Empty(t, orders, "a dry run must not create orders")
+
+	report, err := store.Open(ctx, dryRun.ErrorReport)
+	require.NoError(t, err)
+	dryRunReport, err := io.ReadAll(report)
+	require.NoError(t, err)
+	report.Close()
+	assert.Contains(t, string(dryRunReport), "4,quantity,")
+	assert.Contains(t, string(dryRunReport), "5,order_ref,order_ref A-1 is already used on line 2")
+
+	realRun, err := importer.Process(ctx, queueTestBulkJob(t, jobRepo, filePath, false).ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, dryRun.Summary, realRun.Summary)
+
+	report, err = store.Open(ctx, realRun.ErrorReport)
+	require.NoError(t, err)
+	realRunReport, err := io.ReadAll(report)
+	require.NoError(t, err)
+	report.Close()
+	assert.Equal(t, string(dryRunReport), string(realRunReport))
+
+	orders, err = orderRepo.FindByFilters(ctx, repositories.OrderFilters{TenantID: tenantID})
+	require.NoError(t, err)
+	assert.Len(t, orders, 2)
+
+	// Importing the same file again finds the order_refs already taken
+	again, err := importer.Process(ctx, queueTestBulkJob(t, jobRepo, filePath, true).ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Summary.Created)
+	assert.Equal(t, 3, again.Summary.Duplicates)
+}
+
+func TestBulkImporter_UnreadableFileFailsJob(t *testing.T) {
+	importer, _, jobRepo, _, location := setupBulkImporterTest(t)
+
+	job := queueTestBulkJob(t, jobRepo, storage.Join(location, "missing.csv"), false)
+	_, err := importer.Process(context.Background(), job.ID.Hex())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	stored, err := jobRepo.FindByID(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobFailed, stored.Status)
+	assert.NotEmpty(t, stored.Error)
+}
+
+func TestBulkImporter_CancelRequestIsNotOverwritten(t *testing.T) {
+	_, _, jobRepo, _, location := setupBulkImporterTest(t)
+	ctx := context.Background()
+
+	job := queueTestBulkJob(t, jobRepo, storage.Join(location, "orders.csv"), false)
+	loaded, err := jobRepo.FindByID(ctx, job.ID.Hex())
+	require.NoError(t, err)
+
+	requested, err := jobRepo.RequestCancel(ctx, job.ID.Hex(), true)
+	require.NoError(t, err)
+	assert.True(t, requested.CancelRequested)
+	assert.True(t, requested.Rollback)
+
+	// The worker's copy predates the request, so saving it must fail rather than lose it
+	loaded.Status = models.BulkJobRunning
+	assert.ErrorIs(t, jobRepo.Update(ctx, loaded), repositories.ErrBulkJobCancelled)
+
+	requested.Status = models.BulkJobCancelled
+	require.NoError(t, jobRepo.Update(ctx, requested))
+	_, err = jobRepo.RequestCancel(ctx, job.ID.Hex(), false)
+	assert.ErrorIs(t, err, repositories.ErrBulkJobCancelled)
+}
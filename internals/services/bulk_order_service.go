@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"oms-service-goc/internals/bulk"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/requestid"
+	"oms-service-goc/internals/storage"
+	"path/filepath"
+
+	"github.com/omniful/go_commons/sqs"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrInvalidBulkOrder  = errors.New("invalid bulk order request")
+	ErrInvalidUpload     = errors.New("invalid bulk upload")
+	ErrUploadTooLarge    = errors.New("bulk upload is too large")
+	ErrUnsupportedUpload = errors.New("unsupported bulk upload type")
+)
+
+// uploadContentTypes lists the content types accepted for each upload format. Browsers
+// label files inconsistently, so the generic types are accepted too.
+var uploadContentTypes = map[bulk.InputFormat][]string{
+	bulk.InputCSV:    {"text/csv", "application/csv", "text/plain", "application/vnd.ms-excel", "application/octet-stream"},
+	bulk.InputXLSX:   {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip", "application/octet-stream"},
+	bulk.InputJSON:   {"application/json", "text/plain", "application/octet-stream"},
+	bulk.InputNDJSON: {"application/x-ndjson", "application/jsonl", "application/json", "text/plain", "application/octet-stream"},
+}
+
+// BulkUploadLimits bounds direct uploads; PreviewRows is how many rows are checked before
+// the file is accepted
+type BulkUploadLimits struct {
+	MaxBytes    int64
+	PreviewRows int
+}
+
+// BulkUpload is a bulk order file sent directly to the service. Format may be left empty
+// to detect it from the file name or content.
+type BulkUpload struct {
+	UserID      string
+	UserName    string
+	FileName    string
+	ContentType string
+	Format      string
+	Sheet       string
+	TemplateID  string
+	DryRun      bool
+	TenantID    string
+	SellerID    string
+	Size        int64
+	File        io.ReadSeeker
+}
+
+type BulkOrderService struct {
+	orderRepo      repositories.OrderRepository
+	jobRepo        repositories.BulkJobRepository
+	templateRepo   repositories.ImportTemplateRepository
+	sqsPublisher   SQSPublisher
+	store          storage.FileStore
+	uploadLocation string
+	limits         BulkUploadLimits
+}
+
+// NewBulkOrderService queues bulk order files for the worker. Uploaded files are written
+// through store under uploadLocation.
+func NewBulkOrderService(orderRepo repositories.OrderRepository, jobRepo repositories.BulkJobRepository, templateRepo repositories.ImportTemplateRepository, sqsPublisher SQSPublisher, store storage.FileStore, uploadLocation string, limits BulkUploadLimits) *BulkOrderService {
+	return &BulkOrderService{
+		orderRepo:      orderRepo,
+		jobRepo:        jobRepo,
+		templateRepo:   templateRepo,
+		sqsPublisher:   sqsPublisher,
+		store:          store,
+		uploadLocation: uploadLocation,
+		limits:         limits,
+	}
+}
+
+func (s *BulkOrderService) Limits() BulkUploadLimits {
+	return s.limits
+}
+
+// CREATE BULK ORDER
+func (s *BulkOrderService) CreateBulkOrder(ctx context.Context, request *models.BulkOrderRequest) (*models.BulkJob, error) {
+	// The worker picks a file store by scheme, so the location must have one
+	location, err := storage.NormalizeLocation(request.FilePath)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "invalid bulk order file path %q: %v", request.FilePath, err)
+		return nil, fmt.Errorf("invalid file_path: %w", err)
+	}
+	request.FilePath = location
+
+	format, err := bulk.ParseInputFormat(request.Format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBulkOrder, err)
+	}
+	mapping, err := loadMapping(ctx, s.templateRepo, request.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.BulkJob{
+		FilePath:   location,
+		Format:     string(format),
+		Sheet:      request.Sheet,
+		TemplateID: request.TemplateID,
+		DryRun:     request.DryRun,
+		TenantID:   request.TenantID,
+		SellerID:   request.SellerID,
+		UserID:     request.UserID,
+		UserName:   request.UserName,
+	}
+	setJobOwner(job, mapping, nil)
+	return s.queue(ctx, job)
+}
+
+// setJobOwner fills in the tenant and seller a job was not given, from the import template's
+// defaults or else the first row of the file when it has been read
+func setJobOwner(job *models.BulkJob, mapping *bulk.Mapping, rows []bulk.ParsedRow) {
+	if job.SellerID == "" {
+		job.TenantID, job.SellerID = mapping.Default("tenant_id"), mapping.Default("seller_id")
+	}
+	if job.SellerID == "" && len(rows) > 0 {
+		job.TenantID, job.SellerID = rows[0].Row.TenantID, rows[0].Row.SellerID
+	}
+}
+
+// maxMessageGroupLength is the longest message group ID SQS accepts
+const maxMessageGroupLength = 128
+
+// bulkOwnerKey identifies whose file a job is: its tenant and seller, or the user who sent it
+// when the seller is not known
+func bulkOwnerKey(job *models.BulkJob) string {
+	switch {
+	case job.SellerID != "":
+		return job.TenantID + "/" + job.SellerID
+	case job.UserID != "":
+		return "user/" + job.UserID
+	default:
+		return ""
+	}
+}
+
+// bulkMessageGroup gives each owner's jobs a message group of their own, so FIFO ordering only
+// holds a file behind the same seller's earlier files. Keys SQS would not accept as a group
+// ID are hashed.
+func bulkMessageGroup(job *models.BulkJob) string {
+	key := bulkOwnerKey(job)
+	if key == "" {
+		return "bulk-orders"
+	}
+	group := "bulk-orders/" + key
+	valid := len(group) <= maxMessageGroupLength
+	for _, r := range group {
+		// Group IDs may only hold letters, digits and punctuation
+		if r < '!' || r > '~' {
+			valid = false
+			break
+		}
+	}
+	if !valid {
+		sum := sha256.Sum256([]byte(key))
+		group = "bulk-orders/" + hex.EncodeToString(sum[:16])
+	}
+	return group
+}
+
+// loadMapping loads the column mapping of an import template; no template means no mapping
+func loadMapping(ctx context.Context, templateRepo repositories.ImportTemplateRepository, templateID string) (*bulk.Mapping, error) {
+	if templateID == "" {
+		return nil, nil
+	}
+	template, err := templateRepo.FindByID(ctx, templateID)
+	if errors.Is(err, repositories.ErrImportTemplateNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBulkOrder, err)
+	}
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to load import template %s: %v", templateID, err)
+		return nil, fmt.Errorf("failed to load import template: %w", err)
+	}
+	return templateMapping(template)
+}
+
+// BulkPreview is how the first rows of a file would be read
+type BulkPreview struct {
+	Format    bulk.InputFormat
+	Rows      []bulk.ParsedRow
+	RowErrors []bulk.RowError
+	Orders    []*bulk.OrderGroup
+}
+
+// PreviewBulkOrder reads the first rows of a file the way the worker would, with the
+// upload's format, sheet and import template, without storing or queueing anything
+func (s *BulkOrderService) PreviewBulkOrder(ctx context.Context, upload BulkUpload) (*BulkPreview, error) {
+	format, err := s.checkUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := loadMapping(ctx, s.templateRepo, upload.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, rowErrors, err := bulk.Parse(upload.File, upload.FileName, bulk.ReadOptions{
+		Format:  format,
+		Sheet:   upload.Sheet,
+		Mapping: mapping,
+		Limit:   s.limits.PreviewRows,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+	return &BulkPreview{
+		Format:    format,
+		Rows:      rows,
+		RowErrors: rowErrors,
+		Orders:    bulk.GroupOrders(rows),
+	}, nil
+}
+
+// UploadBulkOrder checks the header and first rows of an uploaded file, stores it and queues
+// it like CreateBulkOrder. Row errors found in the preview are returned with ErrInvalidUpload.
+func (s *BulkOrderService) UploadBulkOrder(ctx context.Context, upload BulkUpload) (*models.BulkJob, []bulk.RowError, error) {
+	format, err := s.checkUpload(upload)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "rejected bulk upload %q: %v", upload.FileName, err)
+		return nil, nil, err
+	}
+
+	mapping, err := loadMapping(ctx, s.templateRepo, upload.TemplateID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, rowErrors, err := bulk.Parse(upload.File, upload.FileName, bulk.ReadOptions{
+		Format:  format,
+		Sheet:   upload.Sheet,
+		Mapping: mapping,
+		Limit:   s.limits.PreviewRows,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+	if len(rowErrors) > 0 {
+		return nil, rowErrors, fmt.Errorf("%w: %d errors in the first %d rows", ErrInvalidUpload, len(rowErrors), s.limits.PreviewRows)
+	}
+	if _, err := upload.File.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+
+	job := &models.BulkJob{
+		ID:         bson.NewObjectID(),
+		FileName:   upload.FileName,
+		Format:     string(format),
+		Sheet:      upload.Sheet,
+		TemplateID: upload.TemplateID,
+		DryRun:     upload.DryRun,
+		TenantID:   upload.TenantID,
+		SellerID:   upload.SellerID,
+		UserID:     upload.UserID,
+		UserName:   upload.UserName,
+	}
+	setJobOwner(job, mapping, rows)
+	job.FilePath = storage.Join(s.uploadLocation, job.ID.Hex()+format.Extension())
+	if err := storage.Put(ctx, s.store, job.FilePath, upload.File); err != nil {
+		log.ErrorfWithContext(ctx, "failed to store bulk upload %q: %v", upload.FileName, err)
+		return nil, nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	job, err = s.queue(ctx, job)
+	return job, nil, err
+}
+
+// checkUpload enforces the size and type limits and works out the file's format from the
+// requested format, the extension or, failing both, the first bytes of the file
+func (s *BulkOrderService) checkUpload(upload BulkUpload) (bulk.InputFormat, error) {
+	if upload.Size > s.limits.MaxBytes {
+		return "", fmt.Errorf("%w: %d bytes, the limit is %d", ErrUploadTooLarge, upload.Size, s.limits.MaxBytes)
+	}
+
+	format, err := bulk.ParseInputFormat(upload.Format)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedUpload, err)
+	}
+	if format == "" && filepath.Ext(upload.FileName) != "" {
+		format = bulk.FormatForExtension(upload.FileName)
+		if format == "" {
+			return "", fmt.Errorf("%w: %q is not a .csv, .xlsx, .json or .ndjson file", ErrUnsupportedUpload, upload.FileName)
+		}
+	}
+	if format == "" {
+		head := make([]byte, 512)
+		read, _ := io.ReadFull(upload.File, head)
+		if _, err := upload.File.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to rewind upload: %w", err)
+		}
+		format = bulk.DetectFormat(upload.FileName, head[:read])
+	}
+
+	if upload.ContentType == "" {
+		return format, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(upload.ContentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedUpload, err)
+	}
+	for _, contentType := range uploadContentTypes[format] {
+		if mediaType == contentType {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s files cannot be %s", ErrUnsupportedUpload, format, mediaType)
+}
+
+// queue records the job and publishes the event the worker picks it up from. A job whose
+// event could not be published is marked failed.
+func (s *BulkOrderService) queue(ctx context.Context, job *models.BulkJob) (*models.BulkJob, error) {
+	// Carry the request ID onto the queue so the worker's logs can be tied back to the upload
+	job.Status = models.BulkJobQueued
+	job.RequestID = requestid.FromContext(ctx)
+	job, err := s.jobRepo.Create(ctx, job)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to create bulk job: %v", err)
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+
+	event := models.CreateBulkOrderEvent{
+		JobID:      job.ID.Hex(),
+		FilePath:   job.FilePath,
+		UserID:     job.UserID,
+		UserName:   job.UserName,
+		Format:     job.Format,
+		Sheet:      job.Sheet,
+		TemplateID: job.TemplateID,
+		DryRun:     job.DryRun,
+		TenantID:   job.TenantID,
+		SellerID:   job.SellerID,
+		RequestID:  job.RequestID,
+	}
+
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to marshal bulk order request: %v", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	message := &sqs.Message{
+		GroupId:         bulkMessageGroup(job),
+		Value:           eventData,
+		DeduplicationId: "bulk-" + job.ID.Hex(),
+	}
+
+	if err := s.sqsPublisher.Publish(ctx, message); err != nil {
+		log.ErrorfWithContext(ctx, "failed to publish bulk order message: %v", err)
+		job.Status = models.BulkJobFailed
+		job.Error = err.Error()
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			log.ErrorfWithContext(ctx, "failed to record bulk job %s failure: %v", job.ID.Hex(), err)
+		}
+		return nil, fmt.Errorf("failed to queue bulk order message: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Bulk order job %s queued successfully", job.ID.Hex())
+	return job, nil
+}
+
+func (s *BulkOrderService) GetBulkJob(ctx context.Context, id string) (*models.BulkJob, error) {
+	job, err := s.jobRepo.FindByID(ctx, id)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get bulk job %s: %v", id, err)
+		return nil, fmt.Errorf("bulk job not found : %w", err)
+	}
+	return job, nil
+}
+
+// CancelBulkJob asks for a job to be cancelled and, with rollback, for the orders it created to
+// be cancelled too. A running job is cancelled by its worker at the next batch, so it is
+// returned still running with cancel_requested set; any other job is cancelled right away.
+func (s *BulkOrderService) CancelBulkJob(ctx context.Context, id string, rollback bool) (*models.BulkJob, error) {
+	job, err := s.jobRepo.RequestCancel(ctx, id, rollback)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to request cancellation of bulk job %s: %v", id, err)
+		return nil, fmt.Errorf("failed to cancel bulk job: %w", err)
+	}
+	if job.Status == models.BulkJobRunning {
+		log.InfofWithContext(ctx, "Cancellation of bulk job %s requested (rollback: %t)", id, job.Rollback)
+		return job, nil
+	}
+
+	if err := cancelBulkJob(ctx, s.orderRepo, s.jobRepo, job); err != nil {
+		return nil, fmt.Errorf("failed to cancel bulk job: %w", err)
+	}
+	return job, nil
+}
+
+// OpenErrorReport opens the error report the importer wrote for a job
+func (s *BulkOrderService) OpenErrorReport(ctx context.Context, job *models.BulkJob) (io.ReadCloser, error) {
+	if job.ErrorReport == "" {
+		return nil, fmt.Errorf("%w: bulk job %s has no error report", storage.ErrNotFound, job.ID.Hex())
+	}
+	file, err := s.store.Open(ctx, job.ErrorReport)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to open error report of bulk job %s: %v", job.ID.Hex(), err)
+		return nil, fmt.Errorf("failed to open error report: %w", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/storage"
+	"strings"
+	"testing"
+
+	"github.com/omniful/go_commons/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBulkOrderServiceTest(t *testing.T) (*BulkOrderService, repositories.BulkJobRepository, *MockSQSPublisher, storage.FileStore, string) {
+	db := newTestDatabase(t)
+	orderRepo, err := repositories.NewOrderRepository(db)
+	require.NoError(t, err)
+	jobRepo, err := repositories.NewBulkJobRepository(db)
+	require.NoError(t, err)
+	templateRepo, err := repositories.NewImportTemplateRepository(db)
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	uploads := storage.Join(storage.LocalLocation(root), "uploads")
+
+	mockSQS := &MockSQSPublisher{}
+	service := NewBulkOrderService(orderRepo, jobRepo, templateRepo, mockSQS, store, uploads, BulkUploadLimits{MaxBytes: 1024, PreviewRows: 2})
+	return service, jobRepo, mockSQS, store, uploads
+}
+
+func TestBulkOrderService_CreateBulkOrder(t *testing.T) {
+	service, jobRepo, mockSQS, _, _ := setupBulkOrderServiceTest(t)
+
+	var event models.CreateBulkOrderEvent
+	mockSQS.On("Publish", mock.Anything, mock.MatchedBy(func(msg *sqs.Message) bool {
+		// Without a seller the job is grouped by the user who sent it
+		assert.Equal(t, "bulk-orders/user/user123", msg.GroupId)
+		assert.Contains(t, msg.DeduplicationId, "bulk-")
+		return assert.NoError(t, json.Unmarshal(msg.Value, &event))
+	})).Return(nil)
+
+	request := &models.BulkOrderRequest{
+		FilePath: "/test/orders.csv",
+		UserID:   "user123",
+		UserName: "testuser",
+	}
+
+	job, err := service.CreateBulkOrder(context.Background(), request)
+	require.NoError(t, err)
+	mockSQS.AssertExpectations(t)
+
+	// Bare paths are sent on as file:// locations
+	assert.Equal(t, "file:///test/orders.csv", event.FilePath)
+	assert.Equal(t, "testuser", event.UserName)
+	assert.Equal(t, job.ID.Hex(), event.JobID)
+
+	stored, err := jobRepo.FindByID(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobQueued, stored.Status)
+	assert.Equal(t, "file:///test/orders.csv", stored.FilePath)
+}
+
+func TestBulkOrderService_CreateBulkOrder_SQSFailure(t *testing.T) {
+	service, jobRepo, mockSQS, _, _ := setupBulkOrderServiceTest(t)
+
+	var event models.CreateBulkOrderEvent
+	mockSQS.On("Publish", mock.Anything, mock.MatchedBy(func(msg *sqs.Message) bool {
+		return assert.NoError(t, json.Unmarshal(msg.Value, &event))
+	})).Return(fmt.Errorf("SQS connection failed"))
+
+	_, err := service.CreateBulkOrder(context.Background(), &models.BulkOrderRequest{
+		FilePath: "/test/orders.csv",
+		UserID:   "user123",
+		UserName: "testuser",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to queue bulk order")
+	mockSQS.AssertExpectations(t)
+
+	// The job is kept as failed so the attempt can be traced
+	stored, err := jobRepo.FindByID(context.Background(), event.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobFailed, stored.Status)
+}
+
+func TestBulkOrderService_CreateBulkOrder_InvalidLocation(t *testing.T) {
+	service, _, mockSQS, _, _ := setupBulkOrderServiceTest(t)
+
+	for _, filePath := range []string{"", "orders.csv", "ftp://example.com/orders.csv", "s3://bucket-only"} {
+		_, err := service.CreateBulkOrder(context.Background(), &models.BulkOrderRequest{
+			FilePath: filePath,
+			UserID:   "user123",
+			UserName: "testuser",
+		})
+		assert.ErrorIs(t, err, storage.ErrInvalidLocation, filePath)
+	}
+	mockSQS.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestBulkOrderService_UploadBulkOrder(t *testing.T) {
+	service, _, mockSQS, store, uploads := setupBulkOrderServiceTest(t)
+	mockSQS.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	file := "tenant_id,seller_id,sku_code,quantity\ntenant1,seller1,SKU001,2\n"
+	job, rowErrors, err := service.UploadBulkOrder(context.Background(), BulkUpload{
+		UserID:      "user123",
+		UserName:    "testuser",
+		FileName:    "orders.csv",
+		ContentType: "text/csv; charset=utf-8",
+		Size:        int64(len(file)),
+		File:        strings.NewReader(file),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	assert.Equal(t, "orders.csv", job.FileName)
+	assert.Equal(t, storage.Join(uploads, job.ID.Hex()+".csv"), job.FilePath)
+	// The seller is taken from the file when the upload does not name one
+	assert.Equal(t, "tenant1", job.TenantID)
+	assert.Equal(t, "seller1", job.SellerID)
+	mockSQS.AssertCalled(t, "Publish", mock.Anything, mock.MatchedBy(func(msg *sqs.Message) bool {
+		return msg.GroupId == "bulk-orders/tenant1/seller1"
+	}))
+	mockSQS.AssertExpectations(t)
+
+	reader, err := store.Open(context.Background(), job.FilePath)
+	require.NoError(t, err)
+	defer reader.Close()
+	stored, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, file, string(stored))
+}
+
+func TestBulkOrderService_UploadBulkOrder_Rejected(t *testing.T) {
+	service, _, mockSQS, _, _ := setupBulkOrderServiceTest(t)
+
+	upload := func(fileName, contentType, file string) ([]string, error) {
+		_, rowErrors, err := service.UploadBulkOrder(context.Background(), BulkUpload{
+			FileName:    fileName,
+			ContentType: contentType,
+			Size:        int64(len(file)),
+			File:        strings.NewReader(file),
+		})
+		var messages []string
+		for _, rowError := range rowErrors {
+			messages = append(messages, rowError.Error())
+		}
+		return messages, err
+	}
+
+	_, err := upload("orders.csv", "text/csv", strings.Repeat("x", 2048))
+	assert.ErrorIs(t, err, ErrUploadTooLarge)
+
+	_, err = upload("orders.pdf", "application/pdf", "tenant_id\n")
+	assert.ErrorIs(t, err, ErrUnsupportedUpload)
+
+	_, err = upload("orders.csv", "image/png", "tenant_id\n")
+	assert.ErrorIs(t, err, ErrUnsupportedUpload)
+
+	_, err = upload("orders.csv", "text/csv", "tenant_id,seller_id\n")
+	assert.ErrorIs(t, err, ErrInvalidUpload)
+
+	rowErrors, err := upload("orders.csv", "text/csv", "tenant_id,seller_id,sku_code,quantity\ntenant1,seller1,SKU001,two\n")
+	assert.ErrorIs(t, err, ErrInvalidUpload)
+	assert.Len(t, rowErrors, 1)
+
+	mockSQS.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestBulkOrderService_UploadBulkOrder_DetectsFormat(t *testing.T) {
+	service, _, mockSQS, _, uploads := setupBulkOrderServiceTest(t)
+
+	var event models.CreateBulkOrderEvent
+	mockSQS.On("Publish", mock.Anything, mock.MatchedBy(func(msg *sqs.Message) bool {
+		return assert.NoError(t, json.Unmarshal(msg.Value, &event))
+	})).Return(nil)
+
+	// No extension, so the format comes from the content
+	file := `[{"tenant_id": "tenant1", "seller_id": "seller1", "sku_code": "SKU001", "quantity": 2}]`
+	job, _, err := service.UploadBulkOrder(context.Background(), BulkUpload{
+		FileName:    "orders",
+		ContentType: "application/json",
+		Size:        int64(len(file)),
+		File:        strings.NewReader(file),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "json", job.Format)
+	assert.Equal(t, storage.Join(uploads, job.ID.Hex()+".json"), job.FilePath)
+	assert.Equal(t, "json", event.Format)
+
+	// A spreadsheet must not be labelled as JSON
+	_, _, err = service.UploadBulkOrder(context.Background(), BulkUpload{
+		FileName:    "orders.xlsx",
+		ContentType: "application/json",
+		Size:        int64(len(file)),
+		File:        strings.NewReader(file),
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedUpload)
+}
+
+func TestBulkMessageGroup(t *testing.T) {
+	assert.Equal(t, "bulk-orders/tenant1/seller1", bulkMessageGroup(&models.BulkJob{TenantID: "tenant1", SellerID: "seller1", UserID: "user123"}))
+	assert.Equal(t, "bulk-orders/user/user123", bulkMessageGroup(&models.BulkJob{UserID: "user123"}))
+	assert.Equal(t, "bulk-orders", bulkMessageGroup(&models.BulkJob{}))
+
+	// Keys SQS would refuse are hashed, and still tell sellers apart
+	spaced := bulkMessageGroup(&models.BulkJob{TenantID: "tenant 1", SellerID: "seller1"})
+	long := bulkMessageGroup(&models.BulkJob{TenantID: "tenant1", SellerID: strings.Repeat("s", 200)})
+	for _, group := range []string{spaced, long} {
+		assert.True(t, strings.HasPrefix(group, "bulk-orders/"), group)
+		assert.LessOrEqual(t, len(group), maxMessageGroupLength)
+		assert.NotContains(t, group, " ")
+	}
+	assert.NotEqual(t, spaced, long)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/requestid"
+	"time"
+
+	"github.com/omniful/go_commons/sqs"
+)
+
+var ErrInvalidReplay = errors.New("invalid dead letter replay")
+
+// DeadLetterService keeps queue messages that kept failing and publishes them again once the
+// cause has been fixed
+type DeadLetterService struct {
+	repo       repositories.DeadLetterRepository
+	publishers map[string]SQSPublisher
+}
+
+// NewDeadLetterService replays dead letters through the publisher of the queue they came from
+func NewDeadLetterService(repo repositories.DeadLetterRepository, publishers map[string]SQSPublisher) *DeadLetterService {
+	return &DeadLetterService{
+		repo:       repo,
+		publishers: publishers,
+	}
+}
+
+// DeadLetter records a message that failed on its last receive, with the reason it failed
+func (s *DeadLetterService) DeadLetter(ctx context.Context, queue string, message *sqs.Message, receiveCount int, reason error) error {
+	entry := &models.DeadLetter{
+		Queue:           queue,
+		GroupID:         message.GroupId,
+		DeduplicationID: message.DeduplicationId,
+		Body:            string(message.Value),
+		Attributes:      message.Attributes,
+		Reason:          reason.Error(),
+		ReceiveCount:    receiveCount,
+	}
+
+	// Bulk order events and domain events name their job or request; anything else is kept as is
+	var ids struct {
+		JobID     string `json:"job_id"`
+		RequestID string `json:"request_id"`
+	}
+	if json.Unmarshal(message.Value, &ids) == nil {
+		entry.JobID = ids.JobID
+		entry.RequestID = ids.RequestID
+	}
+
+	if _, err := s.repo.Create(ctx, entry); err != nil {
+		log.ErrorfWithContext(ctx, "failed to record dead letter %s from %s: %v", message.DeduplicationId, queue, err)
+		return fmt.Errorf("failed to record dead letter: %w", err)
+	}
+	log.InfofWithContext(ctx, "Message %s from %s dead-lettered as %s after %d receives",
+		message.DeduplicationId, queue, entry.ID.Hex(), receiveCount)
+	return nil
+}
+
+func (s *DeadLetterService) ListDeadLetters(ctx context.Context, filter repositories.DeadLetterFilter) ([]*models.DeadLetter, error) {
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to list dead letters: %v", err)
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *DeadLetterService) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	entry, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get dead letter %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return entry, nil
+}
+
+// ReplayDeadLetter publishes the message of a dead letter to its queue again. The replay gets
+// a deduplication ID of its own, since FIFO queues drop a message whose ID they saw recently.
+func (s *DeadLetterService) ReplayDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	entry, err := s.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.replay(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ReplayDeadLetters replays every dead letter not replayed yet, oldest first, optionally only
+// those from one queue. It stops at the first failure and returns the entries replayed so far.
+func (s *DeadLetterService) ReplayDeadLetters(ctx context.Context, queue string) ([]*models.DeadLetter, error) {
+	entries, err := s.ListDeadLetters(ctx, repositories.DeadLetterFilter{Queue: queue})
+	if err != nil {
+		return nil, err
+	}
+	replayed := make([]*models.DeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		if err := s.replay(ctx, entry); err != nil {
+			return replayed, err
+		}
+		replayed = append(replayed, entry)
+	}
+	return replayed, nil
+}
+
+func (s *DeadLetterService) replay(ctx context.Context, entry *models.DeadLetter) error {
+	publisher, ok := s.publishers[entry.Queue]
+	if !ok {
+		return fmt.Errorf("%w: no publisher for queue %s", ErrInvalidReplay, entry.Queue)
+	}
+	if entry.RequestID != "" {
+		ctx = requestid.NewContext(ctx, entry.RequestID)
+	}
+
+	attributes := make(map[string]string, len(entry.Attributes)+1)
+	for key, value := range entry.Attributes {
+		attributes[key] = value
+	}
+	attributes["replay_of"] = entry.ID.Hex()
+
+	message := &sqs.Message{
+		GroupId:         entry.GroupID,
+		Value:           []byte(entry.Body),
+		Attributes:      attributes,
+		DeduplicationId: fmt.Sprintf("replay-%s-%d", entry.ID.Hex(), entry.Replays+1),
+	}
+	if err := publisher.Publish(ctx, message); err != nil {
+		log.ErrorfWithContext(ctx, "failed to replay dead letter %s: %v", entry.ID.Hex(), err)
+		return fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.repo.MarkReplayed(ctx, entry.ID, now); err != nil {
+		log.ErrorfWithContext(ctx, "dead letter %s was replayed but could not be marked: %v", entry.ID.Hex(), err)
+		return fmt.Errorf("failed to mark dead letter replayed: %w", err)
+	}
+	entry.Replays++
+	entry.ReplayedAt = &now
+
+	log.InfofWithContext(ctx, "Dead letter %s replayed to %s", entry.ID.Hex(), entry.Queue)
+	return nil
+}
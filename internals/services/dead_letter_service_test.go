@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/queue"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/storage"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omniful/go_commons/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type memoryDeadLetterRepository struct {
+	mu      sync.Mutex
+	entries []*models.DeadLetter
+}
+
+func (r *memoryDeadLetterRepository) Create(ctx context.Context, entry *models.DeadLetter) (*models.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.ID = bson.NewObjectID()
+	entry.FailedAt = time.Now()
+	r.entries = append(r.entries, entry)
+	return entry, nil
+}
+
+func (r *memoryDeadLetterRepository) FindByID(ctx context.Context, id string) (*models.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.entries {
+		if entry.ID.Hex() == id {
+			copied := *entry
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", repositories.ErrDeadLetterNotFound, id)
+}
+
+func (r *memoryDeadLetterRepository) List(ctx context.Context, filter repositories.DeadLetterFilter) ([]*models.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []*models.DeadLetter
+	for _, entry := range r.entries {
+		if (filter.Queue == "" || entry.Queue == filter.Queue) && (filter.IncludeReplayed || entry.ReplayedAt == nil) {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].FailedAt.Before(entries[j].FailedAt) })
+	return entries, nil
+}
+
+func (r *memoryDeadLetterRepository) MarkReplayed(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.entries {
+		if entry.ID == id {
+			entry.ReplayedAt = &at
+			entry.Replays++
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", repositories.ErrDeadLetterNotFound, id.Hex())
+}
+
+func TestDeadLetterService_PoisonBulkMessageIsSetAsideAndReplayed(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	location := storage.LocalLocation(root)
+
+	orderRepo := &memoryOrderRepository{}
+	jobRepo := &memoryBulkJobRepository{}
+	importer := NewBulkImporter(orderRepo, jobRepo, nil, nil, store, storage.Join(location, "reports"), BulkImportOptions{BatchSize: 10, Concurrency: 1})
+
+	// The file is not there yet, so every attempt at the job fails
+	filePath := storage.Join(location, "late.csv")
+	job, err := jobRepo.Create(context.Background(), &models.BulkJob{FilePath: filePath, RequestID: "req-1"})
+	require.NoError(t, err)
+	event, err := json.Marshal(models.CreateBulkOrderEvent{JobID: job.ID.Hex(), FilePath: filePath, RequestID: "req-1"})
+	require.NoError(t, err)
+
+	bulkQueue := queue.NewLocalQueue("bulk-orders.fifo", time.Millisecond)
+	deadLetterRepo := &memoryDeadLetterRepository{}
+	service := NewDeadLetterService(deadLetterRepo, map[string]SQSPublisher{"bulk-orders.fifo": bulkQueue})
+	consumer := queue.NewConsumer("bulk-orders.fifo", bulkQueue, importer.HandleMessage, service, queue.ConsumerOptions{MaxReceives: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.Run(ctx)
+
+	drain := func() {
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer waitCancel()
+		require.NoError(t, bulkQueue.WaitEmpty(waitCtx))
+	}
+
+	require.NoError(t, bulkQueue.Publish(context.Background(), &sqs.Message{GroupId: "bulk-orders", Value: event, DeduplicationId: "bulk-" + job.ID.Hex()}))
+	drain()
+
+	entries, err := service.ListDeadLetters(context.Background(), repositories.DeadLetterFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "bulk-orders.fifo", entry.Queue)
+	assert.Equal(t, job.ID.Hex(), entry.JobID)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, 3, entry.ReceiveCount)
+	assert.Contains(t, entry.Reason, "failed to read bulk file")
+
+	failed, err := jobRepo.FindByID(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobFailed, failed.Status)
+
+	// Once the file is in place the replay imports it
+	require.NoError(t, storage.Put(context.Background(), store, filePath,
+		strings.NewReader("tenant_id,seller_id,hub_id,order_ref,sku_code,quantity\nt1,seller1,hub1,R-1,SKU1,2\n")))
+	replayed, err := service.ReplayDeadLetters(context.Background(), "bulk-orders.fifo")
+	require.NoError(t, err)
+	require.Len(t, replayed, 1)
+	assert.Equal(t, 1, replayed[0].Replays)
+	drain()
+
+	completed, err := jobRepo.FindByID(context.Background(), job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobCompleted, completed.Status)
+	assert.Len(t, orderRepo.orders, 1)
+
+	pending, err := service.ListDeadLetters(context.Background(), repositories.DeadLetterFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDeadLetterService_ReplayNeedsKnownQueue(t *testing.T) {
+	deadLetterRepo := &memoryDeadLetterRepository{}
+	entry, err := deadLetterRepo.Create(context.Background(), &models.DeadLetter{Queue: "retired.fifo", GroupID: "g"})
+	require.NoError(t, err)
+	service := NewDeadLetterService(deadLetterRepo, map[string]SQSPublisher{})
+
+	_, err = service.ReplayDeadLetter(context.Background(), entry.ID.Hex())
+
+	assert.ErrorIs(t, err, ErrInvalidReplay)
+}
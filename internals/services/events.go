@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/requestid"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/sqs"
+)
+
+// EventPublisher wraps domain events in models.Event and publishes them on the order events queue.
+// Events for the same order share a message group so consumers see them in order.
+type EventPublisher struct {
+	publisher SQSPublisher
+}
+
+func NewEventPublisher(publisher SQSPublisher) *EventPublisher {
+	return &EventPublisher{
+		publisher: publisher,
+	}
+}
+
+func (p *EventPublisher) Publish(ctx context.Context, eventType, orderID, tenantID, sellerID string, data interface{}) error {
+	event := models.Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OrderID:    orderID,
+		TenantID:   tenantID,
+		SellerID:   sellerID,
+		RequestID:  requestid.FromContext(ctx),
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	message := &sqs.Message{
+		GroupId:         orderID,
+		Value:           value,
+		DeduplicationId: event.ID,
+	}
+	if err := p.publisher.Publish(ctx, message); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"oms-service-goc/internals/bulk"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"oms-service-goc/internals/requestid"
+	"oms-service-goc/internals/storage"
+	"time"
+)
+
+var ErrInvalidExport = errors.New("invalid export")
+
+// exportProgressEvery is how many orders an async export writes between progress updates
+const exportProgressEvery = 1000
+
+type ExportService struct {
+	orderRepo repositories.OrderRepository
+	jobRepo   repositories.ExportJobRepository
+	store     storage.FileStore
+	location  string
+}
+
+// NewExportService writes background exports through store under location, a directory-like
+// URI such as file:///var/lib/oms/exports or s3://bucket/exports
+func NewExportService(orderRepo repositories.OrderRepository, jobRepo repositories.ExportJobRepository, store storage.FileStore, location string) *ExportService {
+	return &ExportService{
+		orderRepo: orderRepo,
+		jobRepo:   jobRepo,
+		store:     store,
+		location:  location,
+	}
+}
+
+func exportFilters(filter models.ExportFilter) (repositories.OrderFilters, error) {
+	if filter.TenantID == "" && filter.SellerID == "" {
+		return repositories.OrderFilters{}, fmt.Errorf("%w: tenant_id or seller_id is required", ErrInvalidExport)
+	}
+	return repositories.OrderFilters{
+		TenantID:  filter.TenantID,
+		SellerID:  filter.SellerID,
+		HubID:     filter.HubID,
+		Status:    filter.Status,
+		StartDate: filter.From,
+		EndDate:   filter.To,
+	}, nil
+}
+
+// ValidateExport checks the filter before anything is written, so a streamed response can
+// still report the problem with a proper status code
+func (s *ExportService) ValidateExport(filter models.ExportFilter) error {
+	_, err := exportFilters(filter)
+	return err
+}
+
+// Export streams the matching orders to w straight from the cursor. It returns the number
+// of orders written; on error the output is incomplete.
+func (s *ExportService) Export(ctx context.Context, filter models.ExportFilter, format bulk.ExportFormat, w io.Writer) (int, error) {
+	return s.export(ctx, filter, format, w, nil)
+}
+
+func (s *ExportService) export(ctx context.Context, filter models.ExportFilter, format bulk.ExportFormat, w io.Writer, progress func(orders int)) (int, error) {
+	filters, err := exportFilters(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	writer, err := bulk.NewOrderWriter(format, w)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	written := 0
+	err = s.orderRepo.Stream(ctx, filters, func(order *models.Order) error {
+		if err := writer.Write(order); err != nil {
+			return err
+		}
+		written++
+		if progress != nil && written%exportProgressEvery == 0 {
+			progress(written)
+		}
+		return nil
+	})
+	if err != nil {
+		return written, fmt.Errorf("export failed after %d orders: %w", written, err)
+	}
+	if err := writer.Close(); err != nil {
+		return written, fmt.Errorf("failed to finish export: %w", err)
+	}
+	return written, nil
+}
+
+// StartExport records an export job and writes the file in the background. The job can
+// be polled with GetExportJob and downloaded once completed.
+func (s *ExportService) StartExport(ctx context.Context, filter models.ExportFilter, format bulk.ExportFormat) (*models.ExportJob, error) {
+	if err := s.ValidateExport(filter); err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobRepo.Create(ctx, &models.ExportJob{
+		Format:    string(format),
+		Filter:    filter,
+		RequestID: requestid.FromContext(ctx),
+	})
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to create export job: %v", err)
+		return nil, fmt.Errorf("failed to start export: %w", err)
+	}
+
+	// The request context ends with the response; keep only its request ID
+	go s.runExport(requestid.NewContext(context.Background(), job.RequestID), job, format)
+
+	log.InfofWithContext(ctx, "Export job %s started", job.ID.Hex())
+	return job, nil
+}
+
+func (s *ExportService) runExport(ctx context.Context, job *models.ExportJob, format bulk.ExportFormat) {
+	fail := func(err error) {
+		log.ErrorfWithContext(ctx, "export job %s failed: %v", job.ID.Hex(), err)
+		job.Status = models.ExportJobFailed
+		job.Error = err.Error()
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			log.ErrorfWithContext(ctx, "failed to record export job %s failure: %v", job.ID.Hex(), err)
+		}
+	}
+
+	job.Status = models.ExportJobRunning
+	job.Location = storage.Join(s.location, job.ID.Hex()+format.Extension())
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		fail(err)
+		return
+	}
+
+	file, err := s.store.Create(ctx, job.Location)
+	if err != nil {
+		fail(fmt.Errorf("failed to create export file: %w", err))
+		return
+	}
+
+	written, err := s.export(ctx, job.Filter, format, file, func(orders int) {
+		job.Orders = orders
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			log.ErrorfWithContext(ctx, "failed to record export job %s progress: %v", job.ID.Hex(), err)
+		}
+	})
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close export file: %w", closeErr)
+	}
+	job.Orders = written
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	now := time.Now()
+	job.Status = models.ExportJobCompleted
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.ErrorfWithContext(ctx, "failed to record export job %s completion: %v", job.ID.Hex(), err)
+		return
+	}
+	log.InfofWithContext(ctx, "Export job %s completed with %d orders", job.ID.Hex(), written)
+}
+
+func (s *ExportService) GetExportJob(ctx context.Context, id string) (*models.ExportJob, error) {
+	job, err := s.jobRepo.FindByID(ctx, id)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get export job %s: %v", id, err)
+		return nil, fmt.Errorf("export job not found : %w", err)
+	}
+	return job, nil
+}
+
+// OpenExport opens the file of a completed export job
+func (s *ExportService) OpenExport(ctx context.Context, job *models.ExportJob) (io.ReadCloser, error) {
+	file, err := s.store.Open(ctx, job.Location)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to open export %s: %v", job.ID.Hex(), err)
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	return file, nil
+}
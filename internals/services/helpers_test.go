@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omniful/go_commons/db/nosql/mongodm"
+	"github.com/omniful/go_commons/sqs"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestDatabase connects to the local Mongo the service tests share
+func newTestDatabase(t testing.TB) mongodm.Database {
+	t.Helper()
+	return mongodm.NewDatabase(mongodm.Config{
+		Database:        "test_oms_service",
+		URI:             "mongodb://localhost:27017",
+		ReadPreference:  mongodm.ReadPrefPrimary,
+		DefaultTimeout:  5 * time.Second,
+		MaxPoolSize:     5,
+		MinPoolSize:     1,
+		MaxConnIdleTime: 1 * time.Minute,
+	})
+}
+
+// MockSQSPublisher stands in for the SQS publisher of services that queue bulk jobs or
+// publish order events
+type MockSQSPublisher struct {
+	mock.Mock
+}
+
+func (m *MockSQSPublisher) Publish(ctx context.Context, message *sqs.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"oms-service-goc/internals/holds"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"time"
+)
+
+type HoldService struct {
+	orderRepo repositories.OrderRepository
+	engine    *holds.Engine
+	events    *EventPublisher
+}
+
+func NewHoldService(orderRepo repositories.OrderRepository, engine *holds.Engine, events *EventPublisher) *HoldService {
+	return &HoldService{
+		orderRepo: orderRepo,
+		engine:    engine,
+		events:    events,
+	}
+}
+
+// PlaceHold puts an order on hold for reason
+func (s *HoldService) PlaceHold(ctx context.Context, orderID string, reason models.HoldReason) (*models.Order, error) {
+	order, err := s.orderRepo.PlaceHold(ctx, orderID, reason)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to hold order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to hold order: %w", err)
+	}
+
+	s.publish(ctx, models.EventOrderHeld, order, map[string]interface{}{"reason": reason})
+	return order, nil
+}
+
+// ReleaseHold clears one hold reason by request, e.g. after a fraud review
+func (s *HoldService) ReleaseHold(ctx context.Context, orderID string, reason models.HoldReason) (*models.Order, error) {
+	order, released, err := s.orderRepo.ClearHolds(ctx, orderID, []models.HoldReason{reason})
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to clear %s hold on order %s: %v", reason, orderID, err)
+		return nil, fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Order %s: %s hold cleared", orderID, reason)
+	if released {
+		s.publish(ctx, models.EventOrderReleased, order, map[string]interface{}{"cleared": []models.HoldReason{reason}})
+	}
+	return order, nil
+}
+
+// EvaluateHolds reports the release rule outcome for each of the order's hold reasons
+func (s *HoldService) EvaluateHolds(ctx context.Context, orderID string) ([]holds.Outcome, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+	return s.engine.Evaluate(ctx, order)
+}
+
+// ReleaseEligible runs the release rules over every held order once, clearing the
+// reasons that are satisfied. It returns how many orders were released to new_order.
+// A failure on one order is logged and does not stop the others.
+func (s *HoldService) ReleaseEligible(ctx context.Context) (int, error) {
+	orders, err := s.orderRepo.FindByFilters(ctx, repositories.OrderFilters{Status: string(models.OrderStatusOnHold)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list held orders: %w", err)
+	}
+
+	released := 0
+	for _, order := range orders {
+		// A split parent's holds are released on its children
+		if order.IsSplit() || len(order.HoldReasons) == 0 {
+			continue
+		}
+
+		reasons, err := s.engine.Clearable(ctx, order)
+		if err != nil {
+			log.ErrorfWithContext(ctx, "failed to evaluate holds on order %s: %v", order.ID.Hex(), err)
+			continue
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		updated, wasReleased, err := s.orderRepo.ClearHolds(ctx, order.ID.Hex(), reasons)
+		if err != nil {
+			log.ErrorfWithContext(ctx, "failed to clear holds on order %s: %v", order.ID.Hex(), err)
+			continue
+		}
+		if wasReleased {
+			released++
+			s.publish(ctx, models.EventOrderReleased, updated, map[string]interface{}{"cleared": reasons})
+		}
+	}
+	return released, nil
+}
+
+// RunReleaseJob calls ReleaseEligible every interval until ctx is cancelled
+func (s *HoldService) RunReleaseJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := s.ReleaseEligible(ctx)
+			if err != nil {
+				log.ErrorfWithContext(ctx, "hold release job failed: %v", err)
+				continue
+			}
+			if released > 0 {
+				log.InfofWithContext(ctx, "Hold release job released %d orders", released)
+			}
+		}
+	}
+}
+
+func (s *HoldService) publish(ctx context.Context, eventType string, order *models.Order, data map[string]interface{}) {
+	data["status"] = order.Status
+	data["hold_reasons"] = order.HoldReasons
+	if err := s.events.Publish(ctx, eventType, order.ID.Hex(), order.TenantID, order.SellerID, data); err != nil {
+		log.ErrorfWithContext(ctx, "failed to publish %s for order %s: %v", eventType, order.ID.Hex(), err)
+		return
+	}
+	log.InfofWithContext(ctx, "Order %s: %s", order.ID.Hex(), eventType)
+}
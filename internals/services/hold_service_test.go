@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"oms-service-goc/internals/holds"
+	"oms-service-goc/internals/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestHoldService_ReleaseEligible(t *testing.T) {
+	// ReleaseEligible looks at every held order, so none may be left by earlier tests, and
+	// this test's own orders must not turn up in later ones
+	cleanupTestData(t, newTestDatabase(t))
+	_, repo := setupOrderServiceTest(t)
+	mockSQS := &MockSQSPublisher{}
+	mockSQS.On("Publish", mock.Anything, mock.Anything).Return(nil)
+	service := NewHoldService(repo, holds.NewEngine(holds.AddressRule{}), NewEventPublisher(mockSQS))
+
+	tenantID, sellerID := bson.NewObjectID().Hex(), bson.NewObjectID().Hex()
+	newHeld := func(reasons ...models.HoldReason) *models.Order {
+		order, err := repo.Create(context.Background(), &models.Order{
+			TenantID:        tenantID,
+			SellerID:        sellerID,
+			HubID:           "hub1",
+			HoldReasons:     reasons,
+			Items:           []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+			ShippingAddress: &models.Address{Name: "Jane", Line1: "1 Main St", City: "San Francisco", PostalCode: "94105", Country: "US"},
+		})
+		require.NoError(t, err)
+		return order
+	}
+
+	verifiable := newHeld(models.HoldReasonAddressVerification)
+	underReview := newHeld(models.HoldReasonAddressVerification, models.HoldReasonFraudReview)
+
+	released, err := service.ReleaseEligible(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+
+	stored, err := repo.FindByID(context.Background(), verifiable.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusNewOrder, stored.Status)
+
+	// Fraud review has no rule and stays until released by request
+	stored, err = repo.FindByID(context.Background(), underReview.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusOnHold, stored.Status)
+	assert.Equal(t, []models.HoldReason{models.HoldReasonFraudReview}, stored.HoldReasons)
+
+	order, err := service.ReleaseHold(context.Background(), underReview.ID.Hex(), models.HoldReasonFraudReview)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusNewOrder, order.Status)
+}
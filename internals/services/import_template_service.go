@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oms-service-goc/internals/bulk"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+)
+
+var ErrInvalidImportTemplate = errors.New("invalid import template")
+
+type ImportTemplateService struct {
+	templateRepo repositories.ImportTemplateRepository
+}
+
+func NewImportTemplateService(templateRepo repositories.ImportTemplateRepository) *ImportTemplateService {
+	return &ImportTemplateService{
+		templateRepo: templateRepo,
+	}
+}
+
+// templateMapping builds the bulk column mapping a template describes. Rows without a
+// seller_id belong to the template's seller unless the template sets another default.
+func templateMapping(template *models.ImportTemplate) (*bulk.Mapping, error) {
+	columns := make(map[string]string, len(template.Columns))
+	for _, column := range template.Columns {
+		if _, ok := columns[column.Header]; ok {
+			return nil, fmt.Errorf("%w: header %q is mapped twice", ErrInvalidImportTemplate, column.Header)
+		}
+		columns[column.Header] = column.Column
+	}
+	defaults := map[string]string{"seller_id": template.SellerID}
+	for column, value := range template.Defaults {
+		defaults[column] = value
+	}
+	mapping, err := bulk.NewMapping(columns, defaults)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportTemplate, err)
+	}
+	return mapping, nil
+}
+
+func validateTemplate(template *models.ImportTemplate) error {
+	if template.SellerID == "" || template.Name == "" {
+		return fmt.Errorf("%w: seller_id and name are required", ErrInvalidImportTemplate)
+	}
+	_, err := templateMapping(template)
+	return err
+}
+
+func (s *ImportTemplateService) CreateTemplate(ctx context.Context, template *models.ImportTemplate) (*models.ImportTemplate, error) {
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+	created, err := s.templateRepo.Create(ctx, template)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to create import template: %v", err)
+		return nil, fmt.Errorf("failed to create import template: %w", err)
+	}
+	log.InfofWithContext(ctx, "Import template %s created for seller %s", created.ID.Hex(), created.SellerID)
+	return created, nil
+}
+
+func (s *ImportTemplateService) GetTemplate(ctx context.Context, id string) (*models.ImportTemplate, error) {
+	template, err := s.templateRepo.FindByID(ctx, id)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get import template %s: %v", id, err)
+		return nil, fmt.Errorf("import template not found : %w", err)
+	}
+	return template, nil
+}
+
+func (s *ImportTemplateService) GetTemplatesBySellerID(ctx context.Context, sellerID string) ([]*models.ImportTemplate, error) {
+	templates, err := s.templateRepo.FindBySellerID(ctx, sellerID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get import templates for seller %s: %v", sellerID, err)
+		return nil, fmt.Errorf("unable to fetch import templates : %w", err)
+	}
+	return templates, nil
+}
+
+// UpdateTemplate replaces the name, columns and defaults; the seller cannot change
+func (s *ImportTemplateService) UpdateTemplate(ctx context.Context, id string, update *models.ImportTemplate) (*models.ImportTemplate, error) {
+	template, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Name = update.Name
+	template.Columns = update.Columns
+	template.Defaults = update.Defaults
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		log.ErrorfWithContext(ctx, "failed to update import template %s: %v", id, err)
+		return nil, fmt.Errorf("failed to update import template: %w", err)
+	}
+	return template, nil
+}
+
+func (s *ImportTemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		log.ErrorfWithContext(ctx, "failed to delete import template %s: %v", id, err)
+		return fmt.Errorf("failed to delete import template: %w", err)
+	}
+	return nil
+}
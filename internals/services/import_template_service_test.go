@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupImportTemplateServiceTest(t *testing.T) (*ImportTemplateService, *BulkOrderService) {
+	db := newTestDatabase(t)
+	templateRepo, err := repositories.NewImportTemplateRepository(db)
+	require.NoError(t, err)
+	jobRepo, err := repositories.NewBulkJobRepository(db)
+	require.NoError(t, err)
+
+	bulkService := NewBulkOrderService(nil, jobRepo, templateRepo, &MockSQSPublisher{}, nil, "", BulkUploadLimits{MaxBytes: 1024, PreviewRows: 10})
+	return NewImportTemplateService(templateRepo), bulkService
+}
+
+func TestImportTemplateService_Lifecycle(t *testing.T) {
+	service, _ := setupImportTemplateServiceTest(t)
+	ctx := context.Background()
+
+	_, err := service.CreateTemplate(ctx, &models.ImportTemplate{
+		SellerID: "seller1",
+		Name:     "broken",
+		Columns:  []models.ColumnMapping{{Header: "SKU", Column: "sku"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidImportTemplate)
+
+	template, err := service.CreateTemplate(ctx, &models.ImportTemplate{
+		SellerID: "seller1",
+		Name:     "marketplace export",
+		Columns:  []models.ColumnMapping{{Header: "SKU", Column: "sku_code"}},
+	})
+	require.NoError(t, err)
+
+	updated, err := service.UpdateTemplate(ctx, template.ID.Hex(), &models.ImportTemplate{
+		SellerID: "someone-else",
+		Name:     "marketplace export v2",
+		Columns:  []models.ColumnMapping{{Header: "SKU", Column: "sku_code"}, {Header: "Qty", Column: "quantity"}},
+		Defaults: map[string]string{"hub_id": "hub1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "seller1", updated.SellerID)
+
+	templates, err := service.GetTemplatesBySellerID(ctx, "seller1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, templates)
+
+	require.NoError(t, service.DeleteTemplate(ctx, template.ID.Hex()))
+	_, err = service.GetTemplate(ctx, template.ID.Hex())
+	assert.ErrorIs(t, err, repositories.ErrImportTemplateNotFound)
+}
+
+func TestBulkOrderService_PreviewWithTemplate(t *testing.T) {
+	service, bulkService := setupImportTemplateServiceTest(t)
+	ctx := context.Background()
+
+	template, err := service.CreateTemplate(ctx, &models.ImportTemplate{
+		SellerID: "seller1",
+		Name:     "preview",
+		Columns: []models.ColumnMapping{
+			{Header: "Tenant", Column: "tenant_id"},
+			{Header: "SKU", Column: "sku_code"},
+			{Header: "Qty", Column: "quantity"},
+			{Header: "Ref", Column: "order_ref"},
+		},
+		Defaults: map[string]string{"hub_id": "hub1"},
+	})
+	require.NoError(t, err)
+
+	file := "Tenant,SKU,Qty,Ref\ntenant1,SKU001,2,A-1\ntenant1,SKU002,x,A-1\ntenant1,SKU003,1,A-1\n"
+	preview, err := bulkService.PreviewBulkOrder(ctx, BulkUpload{
+		FileName:   "orders.csv",
+		TemplateID: template.ID.Hex(),
+		Size:       int64(len(file)),
+		File:       strings.NewReader(file),
+	})
+	require.NoError(t, err)
+	require.Len(t, preview.Rows, 2)
+	// seller_id comes from the template's seller
+	assert.Equal(t, map[string]string{"tenant_id": "tenant1", "seller_id": "seller1", "hub_id": "hub1", "order_ref": "A-1", "sku_code": "SKU001", "quantity": "2"}, preview.Rows[0].Columns())
+	require.Len(t, preview.RowErrors, 1)
+	assert.Equal(t, "quantity", preview.RowErrors[0].Column)
+	require.Len(t, preview.Orders, 1)
+	assert.Len(t, preview.Orders[0].Order.Items, 2)
+
+	_, err = bulkService.PreviewBulkOrder(ctx, BulkUpload{
+		FileName:   "orders.csv",
+		TemplateID: "000000000000000000000000",
+		Size:       int64(len(file)),
+		File:       strings.NewReader(file),
+	})
+	assert.ErrorIs(t, err, ErrInvalidBulkOrder)
+}
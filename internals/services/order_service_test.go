@@ -2,64 +2,26 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"oms-service-goc/internals/models"
 	"oms-service-goc/internals/repositories"
 	"testing"
-	"time"
 
 	"github.com/omniful/go_commons/db/nosql/mongodm"
-	"github.com/omniful/go_commons/sqs"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-// Mock SQS Publisher - implements the SQSPublisher interface
-type MockSQSPublisher struct {
-	mock.Mock
-}
-
-func (m *MockSQSPublisher) Publish(ctx context.Context, message *sqs.Message) error {
-	args := m.Called(ctx, message)
-	return args.Error(0)
-}
-
-// Wrapper for the real SQS Publisher to implement our interface
-type RealSQSPublisher struct {
-	publisher *sqs.Publisher
-}
-
-func (r *RealSQSPublisher) Publish(ctx context.Context, message *sqs.Message) error {
-	return r.publisher.Publish(ctx, message)
-}
-
 // Test setup
-func setupOrderServiceTest(t *testing.T) (*OrderService, repositories.OrderRepository, *MockSQSPublisher) {
-	// Setup test database
-	cfg := mongodm.Config{
-		Database:        "test_oms_service",
-		URI:             "mongodb://localhost:27017",
-		ReadPreference:  mongodm.ReadPrefPrimary,
-		DefaultTimeout:  5 * time.Second,
-		MaxPoolSize:     5,
-		MinPoolSize:     1,
-		MaxConnIdleTime: 1 * time.Minute,
-	}
-
-	db := mongodm.NewDatabase(cfg)
+func setupOrderServiceTest(t *testing.T) (*OrderService, repositories.OrderRepository) {
+	db := newTestDatabase(t)
 	repo, err := repositories.NewOrderRepository(db)
 	require.NoError(t, err)
 
-	// Create mock SQS publisher
-	mockSQS := &MockSQSPublisher{}
+	service := NewOrderService(repo)
 
-	// Create service - now mockSQS implements SQSPublisher interface
-	service := NewOrderService(repo, mockSQS)
-
-	return service, repo, mockSQS
+	return service, repo
 }
 
 // Add cleanup helper
@@ -71,7 +33,7 @@ func cleanupTestData(t *testing.T, db mongodm.Database) {
 }
 
 func TestOrderService_GetOrdersBySellerID(t *testing.T) {
-	service, repo, _ := setupOrderServiceTest(t)
+	service, repo := setupOrderServiceTest(t)
 
 	// Create test orders
 	testOrders := []*models.Order{
@@ -127,42 +89,8 @@ func TestOrderService_GetOrdersBySellerID(t *testing.T) {
 	// })
 }
 
-func TestOrderService_CreateBulkOrder(t *testing.T) {
-	service, _, mockSQS := setupOrderServiceTest(t)
-
-	// Setup mock expectations
-	mockSQS.On("Publish", mock.Anything, mock.MatchedBy(func(msg *sqs.Message) bool {
-		// Verify the message structure
-		assert.Equal(t, "bulk-orders", msg.GroupId)
-		assert.Contains(t, msg.DeduplicationId, "bulk-")
-
-		// Verify the message content
-		var request models.BulkOrderRequest
-		err := json.Unmarshal(msg.Value, &request)
-		assert.NoError(t, err)
-		assert.Equal(t, "/test/orders.csv", request.FilePath)
-		assert.Equal(t, "testuser", request.UserName)
-
-		return true
-	})).Return(nil)
-
-	// Test data
-	request := &models.BulkOrderRequest{
-		FilePath: "/test/orders.csv",
-		UserID:   "user123",
-		UserName: "testuser",
-	}
-
-	// Test the service method
-	err := service.CreateBulkOrder(context.Background(), request)
-
-	// Assertions
-	assert.NoError(t, err)
-	mockSQS.AssertExpectations(t)
-}
-
 func TestOrderService_GetOrderByID(t *testing.T) {
-	service, repo, _ := setupOrderServiceTest(t)
+	service, repo := setupOrderServiceTest(t)
 
 	// Create test order
 	testOrder := &models.Order{
@@ -189,7 +117,7 @@ func TestOrderService_GetOrderByID(t *testing.T) {
 }
 
 func TestOrderService_UpdateOrderStatus(t *testing.T) {
-	service, repo, _ := setupOrderServiceTest(t)
+	service, repo := setupOrderServiceTest(t)
 
 	// Create test order
 	testOrder := &models.Order{
@@ -216,7 +144,7 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 }
 
 func TestOrderService_GetOrdersBySellerID_EmptyResult(t *testing.T) {
-	service, _, _ := setupOrderServiceTest(t)
+	service, _ := setupOrderServiceTest(t)
 
 	// Test with non-existent seller
 	result, err := service.GetOrdersBySellerID(context.Background(), "nonexistent_seller")
@@ -227,7 +155,7 @@ func TestOrderService_GetOrdersBySellerID_EmptyResult(t *testing.T) {
 }
 
 func TestOrderService_GetOrderByID_NotFound(t *testing.T) {
-	service, _, _ := setupOrderServiceTest(t)
+	service, _ := setupOrderServiceTest(t)
 
 	// Test with non-existent order ID
 	_, err := service.GetOrderByID(context.Background(), bson.NewObjectID().Hex())
@@ -237,24 +165,177 @@ func TestOrderService_GetOrderByID_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "order not found")
 }
 
-// Add test for SQS publishing failure
-func TestOrderService_CreateBulkOrder_SQSFailure(t *testing.T) {
-	service, _, mockSQS := setupOrderServiceTest(t)
+func TestOrderService_RecordFulfilment(t *testing.T) {
+	service, repo := setupOrderServiceTest(t)
+
+	testOrder := &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Status:   models.OrderStatusNewOrder,
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 3},
+			{SKUCode: "SKU002", Quantity: 2},
+		},
+	}
+
+	created, err := repo.Create(context.Background(), testOrder)
+	require.NoError(t, err)
 
-	// Setup mock to return error
-	mockSQS.On("Publish", mock.Anything, mock.Anything).Return(fmt.Errorf("SQS connection failed"))
+	// Ship the first SKU only
+	result, err := service.RecordFulfilment(context.Background(), created.ID.Hex(), []models.ItemQuantity{
+		{SKUCode: "SKU001", Quantity: 3},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPartiallyShipped, result.Status)
+
+	// Both levels must be persisted together
+	stored, err := repo.FindByID(context.Background(), created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPartiallyShipped, stored.Status)
+	assert.Equal(t, 3, stored.Items[0].FulfilledQuantity)
+	assert.Equal(t, models.ItemStatusFulfilled, stored.Items[0].Status)
+
+	// Over-fulfilling is rejected
+	_, err = service.RecordFulfilment(context.Background(), created.ID.Hex(), []models.ItemQuantity{
+		{SKUCode: "SKU002", Quantity: 5},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+}
+
+func TestOrderService_SplitOrder(t *testing.T) {
+	service, repo := setupOrderServiceTest(t)
 
-	request := &models.BulkOrderRequest{
-		FilePath: "/test/orders.csv",
-		UserID:   "user123",
-		UserName: "testuser",
+	created, err := repo.Create(context.Background(), &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Status:   models.OrderStatusNewOrder,
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 3},
+			{SKUCode: "SKU002", Quantity: 2},
+		},
+	})
+	require.NoError(t, err)
+
+	parent, children, err := service.SplitOrder(context.Background(), created.ID.Hex(), []models.HubAssignment{
+		{SKUCode: "SKU001", Quantity: 3, HubID: "hub1"},
+		{SKUCode: "SKU002", Quantity: 2, HubID: "hub2"},
+	})
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	assert.Len(t, parent.ChildOrderIDs, 2)
+
+	// Shipping one child moves the parent to partially_shipped
+	_, err = service.RecordFulfilment(context.Background(), children[0].ID.Hex(), []models.ItemQuantity{
+		{SKUCode: "SKU001", Quantity: 3},
+	})
+	require.NoError(t, err)
+
+	stored, err := repo.FindByID(context.Background(), created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPartiallyShipped, stored.Status)
+
+	listed, err := service.GetChildOrders(context.Background(), created.ID.Hex())
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+
+	// The parent itself can no longer be fulfilled
+	_, err = service.RecordFulfilment(context.Background(), created.ID.Hex(), []models.ItemQuantity{
+		{SKUCode: "SKU002", Quantity: 1},
+	})
+	assert.ErrorIs(t, err, models.ErrOrderIsSplit)
+}
+
+func TestOrderService_EditOrder(t *testing.T) {
+	service, repo := setupOrderServiceTest(t)
+
+	created, err := repo.Create(context.Background(), &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Items:    []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+	})
+	require.NoError(t, err)
+
+	edited, err := service.EditOrder(context.Background(), created.ID.Hex(), models.OrderEdit{
+		Version:    created.Version,
+		Quantities: []models.ItemQuantity{{SKUCode: "SKU001", Quantity: 4}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, created.Version+1, edited.Version)
+
+	stored, err := repo.FindByID(context.Background(), created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 4, stored.Items[0].Quantity)
+	require.Len(t, stored.History, 1)
+
+	// Editing from the stale version is refused
+	_, err = service.EditOrder(context.Background(), created.ID.Hex(), models.OrderEdit{
+		Version: created.Version,
+		Add:     []models.OrderItem{{SKUCode: "SKU002", Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+
+	// A priced item can be added to an unpriced order once the edit names a currency
+	priced := []models.OrderItem{{SKUCode: "SKU002", Quantity: 2, UnitPrice: decimal.RequireFromString("7.50")}}
+	_, err = service.EditOrder(context.Background(), created.ID.Hex(), models.OrderEdit{Version: edited.Version, Add: priced})
+	assert.ErrorIs(t, err, models.ErrInvalidOrder)
+
+	edited, err = service.EditOrder(context.Background(), created.ID.Hex(), models.OrderEdit{Version: edited.Version, Currency: "usd", Add: priced})
+	require.NoError(t, err)
+
+	stored, err = repo.FindByID(context.Background(), created.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "USD", stored.Currency)
+	assert.Equal(t, "15", stored.Total.String())
+	assert.Equal(t, edited.Version, stored.Version)
+}
+
+func TestOrderService_BulkUpdateStatus(t *testing.T) {
+	service, repo := setupOrderServiceTest(t)
+
+	create := func(status models.OrderStatus) *models.Order {
+		order, err := repo.Create(context.Background(), &models.Order{
+			TenantID: "tenant1",
+			SellerID: "seller123",
+			HubID:    "hub1",
+			Status:   status,
+			Items:    []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+		})
+		require.NoError(t, err)
+		return order
 	}
+	held := create(models.OrderStatusOnHold)
+	cancelled := create(models.OrderStatusCancelled)
+	missing := bson.NewObjectID().Hex()
+
+	results, err := service.BulkUpdateStatus(context.Background(), &BulkStatusRequest{
+		Status:   models.OrderStatusNewOrder,
+		OrderIDs: []string{held.ID.Hex(), cancelled.ID.Hex(), missing, "not-an-id"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
 
-	// Test the service method
-	err := service.CreateBulkOrder(context.Background(), request)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, models.OrderStatusNewOrder, results[0].Order.Status)
+	assert.ErrorIs(t, results[1].Err, models.ErrInvalidStatusTransition)
+	assert.ErrorIs(t, results[2].Err, repositories.ErrOrderNotFound)
+	assert.ErrorIs(t, results[3].Err, ErrInvalidBulkUpdate)
 
-	// Should return error
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to queue bulk order")
-	mockSQS.AssertExpectations(t)
+	stored, err := repo.FindByID(context.Background(), held.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusNewOrder, stored.Status)
+
+	// By filter
+	results, err = service.BulkUpdateStatus(context.Background(), &BulkStatusRequest{
+		Status: models.OrderStatusOnHold,
+		Filter: &BulkStatusFilter{SellerID: "seller123", Status: string(models.OrderStatusNewOrder)},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.NoError(t, results[0].Err)
+
+	_, err = service.BulkUpdateStatus(context.Background(), &BulkStatusRequest{Status: models.OrderStatusOnHold})
+	assert.ErrorIs(t, err, ErrInvalidBulkUpdate)
 }
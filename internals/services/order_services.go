@@ -2,13 +2,14 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"oms-service-goc/internals/log"
 	"oms-service-goc/internals/models"
 	"oms-service-goc/internals/repositories"
 
-	"github.com/omniful/go_commons/log"
 	"github.com/omniful/go_commons/sqs"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type SQSPublisher interface {
@@ -16,14 +17,12 @@ type SQSPublisher interface {
 }
 
 type OrderService struct {
-	orderRepo    repositories.OrderRepository
-	sqsPublisher SQSPublisher
+	orderRepo repositories.OrderRepository
 }
 
-func NewOrderService(orderRepo repositories.OrderRepository, sqsPublisher SQSPublisher) *OrderService {
+func NewOrderService(orderRepo repositories.OrderRepository) *OrderService {
 	return &OrderService{
-		orderRepo:    orderRepo,
-		sqsPublisher: sqsPublisher,
+		orderRepo: orderRepo,
 	}
 }
 
@@ -39,30 +38,6 @@ func (s *OrderService) GetOrdersBySellerID(ctx context.Context, sellerID string)
 	return orders, nil
 }
 
-// CREATE BULK ORDER
-func (s *OrderService) CreateBulkOrder(ctx context.Context, request *models.BulkOrderRequest) error {
-	eventData, err := json.Marshal(request)
-	if err != nil {
-		log.ErrorfWithContext(ctx, "failed to marshal bulk order request: %w", err)
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	message := &sqs.Message{
-		GroupId:         "bulk-orders",
-		Value:           eventData,
-		DeduplicationId: fmt.Sprintf("bulk-%s-%s", request.UserName, request.FilePath),
-	}
-
-	err = s.sqsPublisher.Publish(ctx, message)
-	if err != nil {
-		log.ErrorfWithContext(ctx, "failed to publish bulk order message: %v", err)
-		return fmt.Errorf("failed to queue bulk order message: %w", err)
-	}
-
-	log.Info("Bulk order request queued successfully")
-	return nil
-}
-
 // GET ORDER BY ID
 func (s *OrderService) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
 	order, err := s.orderRepo.FindByID(ctx, orderID)
@@ -81,6 +56,184 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, st
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
-	log.Info("Order %s status updated to %s", orderID, status)
+	log.InfofWithContext(ctx, "Order %s status updated to %s", orderID, status)
 	return nil
 }
+
+// MaxBulkStatusOrders caps how many orders one bulk status update may touch
+const MaxBulkStatusOrders = 1000
+
+var ErrInvalidBulkUpdate = errors.New("invalid bulk status update")
+
+// BulkStatusRequest selects orders either by ID or by filter, not both
+type BulkStatusRequest struct {
+	Status   models.OrderStatus `json:"status" binding:"required"`
+	OrderIDs []string           `json:"order_ids"`
+	Filter   *BulkStatusFilter  `json:"filter"`
+}
+
+type BulkStatusFilter struct {
+	TenantID string `json:"tenant_id"`
+	SellerID string `json:"seller_id"`
+	HubID    string `json:"hub_id"`
+	Status   string `json:"status"`
+}
+
+// BulkUpdateStatus applies the state machine to every selected order and returns one
+// result per order. For order_ids the results follow the request; unknown or malformed
+// IDs fail individually rather than failing the request.
+func (s *OrderService) BulkUpdateStatus(ctx context.Context, request *BulkStatusRequest) ([]repositories.StatusResult, error) {
+	if (len(request.OrderIDs) == 0) == (request.Filter == nil) {
+		return nil, fmt.Errorf("%w: give either order_ids or filter", ErrInvalidBulkUpdate)
+	}
+
+	var orders []*models.Order
+	var results []repositories.StatusResult
+	if request.Filter != nil {
+		filter := request.Filter
+		if filter.TenantID == "" && filter.SellerID == "" && filter.HubID == "" && filter.Status == "" {
+			return nil, fmt.Errorf("%w: filter needs at least one field", ErrInvalidBulkUpdate)
+		}
+
+		found, err := s.orderRepo.FindByFilters(ctx, repositories.OrderFilters{
+			TenantID: filter.TenantID,
+			SellerID: filter.SellerID,
+			HubID:    filter.HubID,
+			Status:   filter.Status,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch orders: %w", err)
+		}
+		if len(found) > MaxBulkStatusOrders {
+			return nil, fmt.Errorf("%w: filter matches %d orders, more than the limit of %d", ErrInvalidBulkUpdate, len(found), MaxBulkStatusOrders)
+		}
+		orders = found
+	} else {
+		if len(request.OrderIDs) > MaxBulkStatusOrders {
+			return nil, fmt.Errorf("%w: %d order_ids given, more than the limit of %d", ErrInvalidBulkUpdate, len(request.OrderIDs), MaxBulkStatusOrders)
+		}
+
+		var err error
+		orders, results, err = s.resolveOrderIDs(ctx, request.OrderIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.orderRepo.BulkUpdateStatus(ctx, orders, request.Status)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to bulk update order statuses: %v", err)
+		return nil, fmt.Errorf("failed to update order statuses: %w", err)
+	}
+
+	// Merge the write results back into the failures found while resolving IDs
+	byID := make(map[string]repositories.StatusResult, len(updated))
+	for _, result := range updated {
+		byID[result.OrderID] = result
+	}
+	if results == nil {
+		results = updated
+	} else {
+		for i := range results {
+			if result, ok := byID[results[i].OrderID]; ok && results[i].Err == nil {
+				results[i] = result
+			}
+		}
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Err == nil {
+			succeeded++
+		}
+	}
+	log.InfofWithContext(ctx, "Bulk status update to %s: %d of %d orders updated", request.Status, succeeded, len(results))
+	return results, nil
+}
+
+// resolveOrderIDs loads the orders for ids, returning a result slot per distinct ID with
+// the malformed and unknown ones already failed
+func (s *OrderService) resolveOrderIDs(ctx context.Context, ids []string) ([]*models.Order, []repositories.StatusResult, error) {
+	var results []repositories.StatusResult
+	var valid []string
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := repositories.StatusResult{OrderID: id}
+		if _, err := bson.ObjectIDFromHex(id); err != nil {
+			result.Err = fmt.Errorf("%w: invalid order ID %q", ErrInvalidBulkUpdate, id)
+		} else {
+			valid = append(valid, id)
+		}
+		results = append(results, result)
+	}
+	if len(valid) == 0 {
+		return nil, results, nil
+	}
+
+	orders, err := s.orderRepo.FindByFilters(ctx, repositories.OrderFilters{OrderIDs: valid})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to fetch orders: %w", err)
+	}
+
+	found := make(map[string]bool, len(orders))
+	for _, order := range orders {
+		found[order.ID.Hex()] = true
+	}
+	for i := range results {
+		if results[i].Err == nil && !found[results[i].OrderID] {
+			results[i].Err = fmt.Errorf("%w: %s", repositories.ErrOrderNotFound, results[i].OrderID)
+		}
+	}
+	return orders, results, nil
+}
+
+// RecordFulfilment marks item quantities as fulfilled; item and order statuses are updated together
+func (s *OrderService) RecordFulfilment(ctx context.Context, orderID string, quantities []models.ItemQuantity) (*models.Order, error) {
+	order, err := s.orderRepo.RecordFulfilment(ctx, orderID, quantities)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to record fulfilment for order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to record fulfilment: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Order %s fulfilment recorded, status is now %s", orderID, order.Status)
+	return order, nil
+}
+
+// EditOrder changes the items of an order that has not been confirmed yet
+func (s *OrderService) EditOrder(ctx context.Context, orderID string, edit models.OrderEdit) (*models.Order, error) {
+	order, err := s.orderRepo.Edit(ctx, orderID, edit)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to edit order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to edit order: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Order %s edited, now at version %d", orderID, order.Version)
+	return order, nil
+}
+
+// SplitOrder moves an order's items into child orders, one per assigned hub
+func (s *OrderService) SplitOrder(ctx context.Context, orderID string, assignments []models.HubAssignment) (*models.Order, []*models.Order, error) {
+	parent, children, err := s.orderRepo.Split(ctx, orderID, assignments)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to split order %s: %v", orderID, err)
+		return nil, nil, fmt.Errorf("failed to split order: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Order %s split into %d child orders", orderID, len(children))
+	return parent, children, nil
+}
+
+// GetChildOrders returns the orders a split order was divided into
+func (s *OrderService) GetChildOrders(ctx context.Context, orderID string) ([]*models.Order, error) {
+	children, err := s.orderRepo.FindByFilters(ctx, repositories.OrderFilters{ParentOrderID: orderID})
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get child orders of %s: %v", orderID, err)
+		return nil, fmt.Errorf("unable to fetch child orders: %w", err)
+	}
+	return children, nil
+}
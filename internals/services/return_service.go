@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+)
+
+type ReturnService struct {
+	orderRepo  repositories.OrderRepository
+	returnRepo repositories.ReturnRepository
+	events     *EventPublisher
+}
+
+func NewReturnService(orderRepo repositories.OrderRepository, returnRepo repositories.ReturnRepository, events *EventPublisher) *ReturnService {
+	return &ReturnService{
+		orderRepo:  orderRepo,
+		returnRepo: returnRepo,
+		events:     events,
+	}
+}
+
+type CreateReturnRequest struct {
+	Items []models.ReturnItem `json:"items" binding:"required,min=1,dive"`
+	Notes string              `json:"notes"`
+}
+
+// CreateReturn opens a return against delivered items of an order. The quantities are
+// claimed on the order straight away so two returns can't cover the same goods.
+func (s *ReturnService) CreateReturn(ctx context.Context, orderID string, request *CreateReturnRequest) (*models.Return, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+
+	ret := &models.Return{
+		OrderID:  order.ID,
+		TenantID: order.TenantID,
+		SellerID: order.SellerID,
+		HubID:    order.HubID,
+		Items:    request.Items,
+		Notes:    request.Notes,
+	}
+
+	// Check against delivered quantities on a copy before creating anything
+	check := *order
+	check.Items = append([]models.OrderItem(nil), order.Items...)
+	if err := check.RecordReturn(ret.Quantities()); err != nil {
+		return nil, fmt.Errorf("cannot return items of order %s: %w", orderID, err)
+	}
+
+	ret, err = s.returnRepo.Create(ctx, ret)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to create return for order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to create return: %w", err)
+	}
+
+	if _, err := s.orderRepo.RecordReturn(ctx, orderID, ret.Quantities()); err != nil {
+		log.ErrorfWithContext(ctx, "failed to claim return quantities for return %s: %v", ret.ID.Hex(), err)
+		if deleteErr := s.returnRepo.Delete(ctx, ret.ID); deleteErr != nil {
+			log.ErrorfWithContext(ctx, "failed to remove orphaned return %s: %v", ret.ID.Hex(), deleteErr)
+		}
+		return nil, fmt.Errorf("failed to create return: %w", err)
+	}
+
+	s.publish(ctx, models.EventReturnRequested, ret)
+	return ret, nil
+}
+
+func (s *ReturnService) GetReturn(ctx context.Context, returnID string) (*models.Return, error) {
+	ret, err := s.returnRepo.FindByID(ctx, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+	return ret, nil
+}
+
+func (s *ReturnService) GetReturnsByOrderID(ctx context.Context, orderID string) ([]*models.Return, error) {
+	returns, err := s.returnRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get returns for order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to get returns: %w", err)
+	}
+	return returns, nil
+}
+
+func (s *ReturnService) Approve(ctx context.Context, returnID string) (*models.Return, error) {
+	ret, err := s.returnRepo.Approve(ctx, returnID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to approve return %s: %v", returnID, err)
+		return nil, fmt.Errorf("failed to approve return: %w", err)
+	}
+
+	s.publish(ctx, models.EventReturnApproved, ret)
+	return ret, nil
+}
+
+// Reject closes the return and gives the claimed quantities back to the order
+func (s *ReturnService) Reject(ctx context.Context, returnID, reason string) (*models.Return, error) {
+	ret, err := s.returnRepo.Reject(ctx, returnID, reason)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to reject return %s: %v", returnID, err)
+		return nil, fmt.Errorf("failed to reject return: %w", err)
+	}
+
+	if _, err := s.orderRepo.ReleaseReturn(ctx, ret.OrderID.Hex(), ret.Quantities()); err != nil {
+		log.ErrorfWithContext(ctx, "failed to release quantities of rejected return %s: %v", returnID, err)
+		// Reopen the return so its quantities stay claimed only while it is open, and the reject can be retried
+		if _, revertErr := s.returnRepo.RevertRejection(ctx, returnID); revertErr != nil {
+			log.ErrorfWithContext(ctx, "failed to reopen return %s: %v", returnID, revertErr)
+		}
+		return nil, fmt.Errorf("failed to release return quantities: %w", err)
+	}
+
+	s.publish(ctx, models.EventReturnRejected, ret)
+	return ret, nil
+}
+
+// Receive records the goods that arrived at hubID
+func (s *ReturnService) Receive(ctx context.Context, returnID, hubID string, quantities []models.ItemQuantity) (*models.Return, error) {
+	ret, err := s.returnRepo.Receive(ctx, returnID, hubID, quantities)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to receive return %s: %v", returnID, err)
+		return nil, fmt.Errorf("failed to receive return: %w", err)
+	}
+
+	s.publish(ctx, models.EventReturnReceived, ret)
+	return ret, nil
+}
+
+// Restock puts received goods back into stock at the receiving hub; the event tells inventory what to add
+func (s *ReturnService) Restock(ctx context.Context, returnID string, quantities []models.ItemQuantity) (*models.Return, error) {
+	ret, err := s.returnRepo.Restock(ctx, returnID, quantities)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to restock return %s: %v", returnID, err)
+		return nil, fmt.Errorf("failed to restock return: %w", err)
+	}
+
+	s.publish(ctx, models.EventReturnRestocked, ret)
+	return ret, nil
+}
+
+// publish emits a return event. The state change is already stored, so a failure is logged rather than returned.
+func (s *ReturnService) publish(ctx context.Context, eventType string, ret *models.Return) {
+	if err := s.events.Publish(ctx, eventType, ret.OrderID.Hex(), ret.TenantID, ret.SellerID, ret); err != nil {
+		log.ErrorfWithContext(ctx, "failed to publish %s for return %s: %v", eventType, ret.ID.Hex(), err)
+		return
+	}
+	log.InfofWithContext(ctx, "Return %s: %s", ret.ID.Hex(), eventType)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"testing"
+
+	"github.com/omniful/go_commons/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReturnServiceTest(t *testing.T) (*ReturnService, repositories.OrderRepository, *MockSQSPublisher) {
+	db := newTestDatabase(t)
+	orderRepo, err := repositories.NewOrderRepository(db)
+	require.NoError(t, err)
+	returnRepo, err := repositories.NewReturnRepository(db)
+	require.NoError(t, err)
+
+	mockSQS := &MockSQSPublisher{}
+	return NewReturnService(orderRepo, returnRepo, NewEventPublisher(mockSQS)), orderRepo, mockSQS
+}
+
+func TestReturnService_Lifecycle(t *testing.T) {
+	service, orderRepo, mockSQS := setupReturnServiceTest(t)
+	ctx := context.Background()
+
+	var published []string
+	mockSQS.On("Publish", mock.Anything, mock.MatchedBy(func(msg *sqs.Message) bool {
+		var event models.Event
+		require.NoError(t, json.Unmarshal(msg.Value, &event))
+		published = append(published, event.Type)
+		return msg.GroupId == event.OrderID
+	})).Return(nil)
+
+	order, err := orderRepo.Create(ctx, &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Status:   models.OrderStatusDelivered,
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 2, FulfilledQuantity: 2, DeliveredQuantity: 2},
+		},
+	})
+	require.NoError(t, err)
+	orderID := order.ID.Hex()
+
+	// Can't return more than was delivered
+	_, err = service.CreateReturn(ctx, orderID, &CreateReturnRequest{
+		Items: []models.ReturnItem{{SKUCode: "SKU001", Quantity: 3, Reason: models.ReturnReasonDamaged}},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+
+	ret, err := service.CreateReturn(ctx, orderID, &CreateReturnRequest{
+		Items: []models.ReturnItem{{SKUCode: "SKU001", Quantity: 2, Reason: models.ReturnReasonDamaged}},
+	})
+	require.NoError(t, err)
+	returnID := ret.ID.Hex()
+
+	_, err = service.Approve(ctx, returnID)
+	require.NoError(t, err)
+	_, err = service.Receive(ctx, returnID, "hub2", []models.ItemQuantity{{SKUCode: "SKU001", Quantity: 2}})
+	require.NoError(t, err)
+	ret, err = service.Restock(ctx, returnID, []models.ItemQuantity{{SKUCode: "SKU001", Quantity: 1}})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ReturnStatusRestocked, ret.Status)
+	assert.Equal(t, "hub2", ret.ReceivingHubID)
+	assert.Equal(t, []string{
+		models.EventReturnRequested,
+		models.EventReturnApproved,
+		models.EventReturnReceived,
+		models.EventReturnRestocked,
+	}, published)
+
+	stored, err := orderRepo.FindByID(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Items[0].ReturnedQuantity)
+}
+
+// releaseFailingRepository fails every attempt to give return quantities back to an order
+type releaseFailingRepository struct {
+	repositories.OrderRepository
+}
+
+func (r releaseFailingRepository) ReleaseReturn(ctx context.Context, id string, quantities []models.ItemQuantity) (*models.Order, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestReturnService_FailedRejectCanBeRetried(t *testing.T) {
+	service, orderRepo, mockSQS := setupReturnServiceTest(t)
+	ctx := context.Background()
+	mockSQS.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	order, err := orderRepo.Create(ctx, &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Status:   models.OrderStatusDelivered,
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 1, FulfilledQuantity: 1, DeliveredQuantity: 1},
+		},
+	})
+	require.NoError(t, err)
+	ret, err := service.CreateReturn(ctx, order.ID.Hex(), &CreateReturnRequest{
+		Items: []models.ReturnItem{{SKUCode: "SKU001", Quantity: 1, Reason: models.ReturnReasonDamaged}},
+	})
+	require.NoError(t, err)
+
+	failing := NewReturnService(releaseFailingRepository{orderRepo}, service.returnRepo, service.events)
+	_, err = failing.Reject(ctx, ret.ID.Hex(), "not eligible")
+	require.Error(t, err)
+
+	// The return is reopened, so it still agrees with the quantities claimed on the order
+	reopened, err := service.GetReturn(ctx, ret.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.ReturnStatusRequested, reopened.Status)
+
+	_, err = service.Reject(ctx, ret.ID.Hex(), "not eligible")
+	require.NoError(t, err)
+	stored, err := orderRepo.FindByID(ctx, order.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Items[0].ReturnedQuantity)
+}
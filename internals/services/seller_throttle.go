@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sellerThrottle keeps one seller's bulk imports from crowding out everyone else's. Each seller
+// may have a limited number of batches being written at once, across all of their jobs, and
+// a limited number of rows written per minute. The row quota is a bucket holding a minute's
+// rows: a batch may overdraw it, and the seller's next batch waits until the debt is repaid.
+type sellerThrottle struct {
+	concurrency   int // batches written at once per seller, 0 for no limit
+	rowsPerMinute int // rows written per minute per seller, 0 for no limit
+
+	mu      sync.Mutex
+	sellers map[string]*sellerLimit
+}
+
+type sellerLimit struct {
+	slots   chan struct{} // one entry per batch being written
+	tokens  float64       // rows the seller may write before waiting, negative when overdrawn
+	updated time.Time     // when tokens was last refilled
+	users   int           // batches holding or waiting for a slot
+}
+
+func newSellerThrottle(concurrency, rowsPerMinute int) *sellerThrottle {
+	return &sellerThrottle{
+		concurrency:   concurrency,
+		rowsPerMinute: rowsPerMinute,
+		sellers:       make(map[string]*sellerLimit),
+	}
+}
+
+// acquire waits until the seller may write a batch of rows, or until ctx is done. The returned
+// release must be called once the batch is written.
+func (t *sellerThrottle) acquire(ctx context.Context, seller string, rows int) (func(), error) {
+	if t.concurrency <= 0 && t.rowsPerMinute <= 0 {
+		return func() {}, nil
+	}
+
+	t.mu.Lock()
+	limit, ok := t.sellers[seller]
+	if !ok {
+		limit = &sellerLimit{tokens: float64(t.rowsPerMinute), updated: time.Now()}
+		if t.concurrency > 0 {
+			limit.slots = make(chan struct{}, t.concurrency)
+		}
+		t.sellers[seller] = limit
+	}
+	limit.users++
+	t.mu.Unlock()
+
+	release := func() {
+		if limit.slots != nil {
+			<-limit.slots
+		}
+		t.leave(seller, limit)
+	}
+
+	if limit.slots != nil {
+		select {
+		case limit.slots <- struct{}{}:
+		case <-ctx.Done():
+			t.leave(seller, limit)
+			return nil, ctx.Err()
+		}
+	}
+
+	if wait := t.take(limit, rows); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// leave drops a batch from the seller's count, forgetting the seller once nothing of theirs is
+// waiting and their bucket is full again, since that is the same as a seller never seen
+func (t *sellerThrottle) leave(seller string, limit *sellerLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit.users--
+	if limit.users == 0 && t.refill(limit, time.Now()) >= float64(t.rowsPerMinute) {
+		delete(t.sellers, seller)
+	}
+}
+
+// take draws rows from the seller's bucket and returns how long to wait for it to be out of debt
+func (t *sellerThrottle) take(limit *sellerLimit, rows int) time.Duration {
+	if t.rowsPerMinute <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tokens := t.refill(limit, time.Now()) - float64(rows)
+	limit.tokens = tokens
+	if tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tokens / float64(t.rowsPerMinute) * float64(time.Minute))
+}
+
+// refill adds the rows earned since the last refill, up to a minute's worth; the caller holds t.mu
+func (t *sellerThrottle) refill(limit *sellerLimit, now time.Time) float64 {
+	if t.rowsPerMinute <= 0 {
+		return 0
+	}
+	limit.tokens += now.Sub(limit.updated).Minutes() * float64(t.rowsPerMinute)
+	if limit.tokens > float64(t.rowsPerMinute) {
+		limit.tokens = float64(t.rowsPerMinute)
+	}
+	limit.updated = now
+	return limit.tokens
+}
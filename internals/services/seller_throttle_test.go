@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSellerThrottle_CapsEachSellerNotOthers(t *testing.T) {
+	throttle := newSellerThrottle(2, 0)
+
+	var (
+		writers sync.WaitGroup
+		running atomic.Int32
+		most    atomic.Int32
+	)
+	errs := make(chan error, 6)
+	for range 6 {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			release, err := throttle.acquire(context.Background(), "tenant1/seller1", 10)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+			now := running.Add(1)
+			for {
+				seen := most.Load()
+				if now <= seen || most.CompareAndSwap(seen, now) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+
+	// Another seller is not held up by the busy one
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	release, err := throttle.acquire(ctx, "tenant1/seller2", 10)
+	require.NoError(t, err)
+	release()
+
+	writers.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), most.Load())
+	assert.Empty(t, throttle.sellers)
+}
+
+func TestSellerThrottle_WaitsOutRowQuota(t *testing.T) {
+	// 6000 rows a minute is 100 a second
+	throttle := newSellerThrottle(0, 6000)
+
+	start := time.Now()
+	release, err := throttle.acquire(context.Background(), "tenant1/seller1", 6000)
+	require.NoError(t, err)
+	release()
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// The minute's rows are spent, so 20 more take about 200ms
+	release, err = throttle.acquire(context.Background(), "tenant1/seller1", 20)
+	require.NoError(t, err)
+	release()
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	// Other sellers have quotas of their own
+	start = time.Now()
+	release, err = throttle.acquire(context.Background(), "tenant1/seller2", 6000)
+	require.NoError(t, err)
+	release()
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestSellerThrottle_GivesUpWithContext(t *testing.T) {
+	throttle := newSellerThrottle(1, 60)
+	release, err := throttle.acquire(context.Background(), "tenant1/seller1", 60)
+	require.NoError(t, err)
+
+	// Waiting for the slot
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = throttle.acquire(ctx, "tenant1/seller1", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+
+	// Waiting out the quota, which is overdrawn by a minute's rows
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = throttle.acquire(ctx, "tenant1/seller1", 60)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The slot was handed back
+	assert.Empty(t, throttle.sellers["tenant1/seller1"].slots)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"time"
+)
+
+type ShipmentService struct {
+	orderRepo    repositories.OrderRepository
+	shipmentRepo repositories.ShipmentRepository
+}
+
+func NewShipmentService(orderRepo repositories.OrderRepository, shipmentRepo repositories.ShipmentRepository) *ShipmentService {
+	return &ShipmentService{
+		orderRepo:    orderRepo,
+		shipmentRepo: shipmentRepo,
+	}
+}
+
+type CreateShipmentRequest struct {
+	Items          []models.ItemQuantity `json:"items" binding:"required,min=1,dive"`
+	Carrier        string                `json:"carrier"`
+	TrackingNumber string                `json:"tracking_number"`
+	TrackingURL    string                `json:"tracking_url"`
+	Package        *models.Package       `json:"package"`
+}
+
+// CreateShipment ships items of an order. The shipped quantities are recorded as
+// fulfilled on the order, which advances it to partially_shipped or shipped.
+func (s *ShipmentService) CreateShipment(ctx context.Context, orderID string, request *CreateShipmentRequest) (*models.Shipment, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+
+	// Check the quantities on a copy first so we don't create a shipment the order can't accept
+	check := *order
+	check.Items = append([]models.OrderItem(nil), order.Items...)
+	if err := check.RecordFulfilment(request.Items); err != nil {
+		return nil, fmt.Errorf("cannot ship order %s: %w", orderID, err)
+	}
+
+	shipment := &models.Shipment{
+		OrderID:  order.ID,
+		TenantID: order.TenantID,
+		SellerID: order.SellerID,
+		HubID:    order.HubID,
+		Items:    request.Items,
+		Carrier:  request.Carrier,
+		Package:  request.Package,
+	}
+	// Tracking is stored with the shipment, so a created shipment never needs a second write
+	if request.TrackingNumber != "" {
+		if err := shipment.AttachTracking(request.Carrier, request.TrackingNumber, request.TrackingURL, time.Now()); err != nil {
+			return nil, fmt.Errorf("cannot ship order %s: %w", orderID, err)
+		}
+	}
+	shipment, err = s.shipmentRepo.Create(ctx, shipment)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to create shipment for order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	// The order update is the source of truth for quantities; undo the shipment if it loses a race
+	if _, err := s.orderRepo.RecordFulfilment(ctx, orderID, request.Items); err != nil {
+		log.ErrorfWithContext(ctx, "failed to record fulfilment for shipment %s: %v", shipment.ID.Hex(), err)
+		if deleteErr := s.shipmentRepo.Delete(ctx, shipment.ID); deleteErr != nil {
+			log.ErrorfWithContext(ctx, "failed to remove orphaned shipment %s: %v", shipment.ID.Hex(), deleteErr)
+		}
+		return nil, fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Shipment %s created for order %s", shipment.ID.Hex(), orderID)
+	return shipment, nil
+}
+
+func (s *ShipmentService) GetShipment(ctx context.Context, shipmentID string) (*models.Shipment, error) {
+	shipment, err := s.shipmentRepo.FindByID(ctx, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	return shipment, nil
+}
+
+func (s *ShipmentService) GetShipmentsByOrderID(ctx context.Context, orderID string) ([]*models.Shipment, error) {
+	shipments, err := s.shipmentRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get shipments for order %s: %v", orderID, err)
+		return nil, fmt.Errorf("failed to get shipments: %w", err)
+	}
+	return shipments, nil
+}
+
+// AttachTracking records the carrier AWB/tracking number and puts the shipment in transit
+func (s *ShipmentService) AttachTracking(ctx context.Context, shipmentID, carrier, trackingNumber, trackingURL string) (*models.Shipment, error) {
+	shipment, err := s.shipmentRepo.AttachTracking(ctx, shipmentID, carrier, trackingNumber, trackingURL)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to attach tracking to shipment %s: %v", shipmentID, err)
+		return nil, fmt.Errorf("failed to attach tracking: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Shipment %s in transit with %s %s", shipmentID, shipment.Carrier, trackingNumber)
+	return shipment, nil
+}
+
+// MarkDelivered closes the shipment and records its items as delivered on the order,
+// which moves the order to delivered once every shipped item has arrived
+func (s *ShipmentService) MarkDelivered(ctx context.Context, shipmentID string) (*models.Shipment, error) {
+	shipment, err := s.shipmentRepo.MarkDelivered(ctx, shipmentID)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to mark shipment %s delivered: %v", shipmentID, err)
+		return nil, fmt.Errorf("failed to mark shipment delivered: %w", err)
+	}
+
+	order, err := s.orderRepo.RecordDelivery(ctx, shipment.OrderID.Hex(), shipment.Items)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to record delivery of shipment %s on order %s: %v", shipmentID, shipment.OrderID.Hex(), err)
+		// Reopen the shipment so it agrees with the order and the delivery can be retried
+		if _, revertErr := s.shipmentRepo.RevertDelivery(ctx, shipmentID); revertErr != nil {
+			log.ErrorfWithContext(ctx, "failed to reopen shipment %s: %v", shipmentID, revertErr)
+		}
+		return nil, fmt.Errorf("failed to record delivery on order: %w", err)
+	}
+
+	log.InfofWithContext(ctx, "Shipment %s delivered, order %s is now %s", shipmentID, order.ID.Hex(), order.Status)
+	return shipment, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupShipmentServiceTest(t *testing.T) (*ShipmentService, repositories.OrderRepository) {
+	db := newTestDatabase(t)
+	orderRepo, err := repositories.NewOrderRepository(db)
+	require.NoError(t, err)
+	shipmentRepo, err := repositories.NewShipmentRepository(db)
+	require.NoError(t, err)
+
+	return NewShipmentService(orderRepo, shipmentRepo), orderRepo
+}
+
+func TestShipmentService_ShipAndDeliver(t *testing.T) {
+	service, orderRepo := setupShipmentServiceTest(t)
+	ctx := context.Background()
+
+	order, err := orderRepo.Create(ctx, &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Status:   models.OrderStatusNewOrder,
+		Items: []models.OrderItem{
+			{SKUCode: "SKU001", Quantity: 2},
+			{SKUCode: "SKU002", Quantity: 1},
+		},
+	})
+	require.NoError(t, err)
+	orderID := order.ID.Hex()
+
+	// First parcel
+	first, err := service.CreateShipment(ctx, orderID, &CreateShipmentRequest{
+		Items:          []models.ItemQuantity{{SKUCode: "SKU001", Quantity: 2}},
+		Carrier:        "aramex",
+		TrackingNumber: "AWB001",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShipmentStatusInTransit, first.Status)
+
+	stored, err := orderRepo.FindByID(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPartiallyShipped, stored.Status)
+
+	// Second parcel ships the rest
+	second, err := service.CreateShipment(ctx, orderID, &CreateShipmentRequest{
+		Items: []models.ItemQuantity{{SKUCode: "SKU002", Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ShipmentStatusCreated, second.Status)
+
+	stored, err = orderRepo.FindByID(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusShipped, stored.Status)
+
+	// Nothing left to ship
+	_, err = service.CreateShipment(ctx, orderID, &CreateShipmentRequest{
+		Items: []models.ItemQuantity{{SKUCode: "SKU002", Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+
+	// Deliver both parcels
+	_, err = service.MarkDelivered(ctx, first.ID.Hex())
+	require.NoError(t, err)
+	_, err = service.MarkDelivered(ctx, second.ID.Hex())
+	require.NoError(t, err)
+
+	stored, err = orderRepo.FindByID(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusDelivered, stored.Status)
+
+	shipments, err := service.GetShipmentsByOrderID(ctx, orderID)
+	require.NoError(t, err)
+	assert.Len(t, shipments, 2)
+}
+
+// deliveryFailingRepository fails every attempt to record a delivery on an order
+type deliveryFailingRepository struct {
+	repositories.OrderRepository
+}
+
+func (r deliveryFailingRepository) RecordDelivery(ctx context.Context, id string, items []models.ItemQuantity) (*models.Order, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestShipmentService_FailedDeliveryCanBeRetried(t *testing.T) {
+	service, orderRepo := setupShipmentServiceTest(t)
+	ctx := context.Background()
+
+	order, err := orderRepo.Create(ctx, &models.Order{
+		TenantID: "tenant1",
+		SellerID: "seller123",
+		HubID:    "hub1",
+		Status:   models.OrderStatusNewOrder,
+		Items:    []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+	})
+	require.NoError(t, err)
+	shipment, err := service.CreateShipment(ctx, order.ID.Hex(), &CreateShipmentRequest{
+		Items:          []models.ItemQuantity{{SKUCode: "SKU001", Quantity: 1}},
+		TrackingNumber: "AWB001",
+	})
+	require.NoError(t, err)
+
+	failing := NewShipmentService(deliveryFailingRepository{orderRepo}, service.shipmentRepo)
+	_, err = failing.MarkDelivered(ctx, shipment.ID.Hex())
+	require.Error(t, err)
+
+	// The shipment is reopened, so it still agrees with the order
+	reopened, err := service.GetShipment(ctx, shipment.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.ShipmentStatusInTransit, reopened.Status)
+	assert.Nil(t, reopened.DeliveredAt)
+
+	_, err = service.MarkDelivered(ctx, shipment.ID.Hex())
+	require.NoError(t, err)
+	stored, err := orderRepo.FindByID(ctx, order.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusDelivered, stored.Status)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"oms-service-goc/internals/configs"
+	"oms-service-goc/internals/log"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+	"time"
+)
+
+// slaStatuses are the statuses an order can get stuck in before it ships
+var slaStatuses = []models.OrderStatus{models.OrderStatusOnHold, models.OrderStatusNewOrder}
+
+type SLAService struct {
+	orderRepo repositories.OrderRepository
+	sla       configs.SLAConfig
+	events    *EventPublisher
+}
+
+func NewSLAService(orderRepo repositories.OrderRepository, sla configs.SLAConfig, events *EventPublisher) *SLAService {
+	return &SLAService{
+		orderRepo: orderRepo,
+		sla:       sla,
+		events:    events,
+	}
+}
+
+// threshold is how long a tenant's orders may stay in status
+func (s *SLAService) threshold(tenantID string, status models.OrderStatus) time.Duration {
+	thresholds := s.sla.Thresholds(tenantID)
+	if status == models.OrderStatusOnHold {
+		return thresholds.OnHold
+	}
+	return thresholds.NewOrder
+}
+
+// shortestThreshold is the smallest threshold any tenant has for status, which bounds the query
+func (s *SLAService) shortestThreshold(status models.OrderStatus) time.Duration {
+	shortest := s.threshold("", status)
+	for tenantID := range s.sla.Tenants {
+		if threshold := s.threshold(tenantID, status); threshold < shortest {
+			shortest = threshold
+		}
+	}
+	return shortest
+}
+
+// DetectBreaches flags every open order that has not changed for longer than its tenant's
+// SLA for its status and publishes an event per breach. It returns how many were flagged.
+func (s *SLAService) DetectBreaches(ctx context.Context, now time.Time) (int, error) {
+	flagged := 0
+	for _, status := range slaStatuses {
+		cutoff := now.Add(-s.shortestThreshold(status))
+		notFlagged := false
+
+		orders, err := s.orderRepo.FindByFilters(ctx, repositories.OrderFilters{
+			Status:        string(status),
+			UpdatedBefore: &cutoff,
+			SLABreached:   &notFlagged,
+		})
+		if err != nil {
+			return flagged, fmt.Errorf("failed to find %s orders past SLA: %w", status, err)
+		}
+
+		for _, order := range orders {
+			threshold := s.threshold(order.TenantID, status)
+			// Split parents follow their children, which are checked on their own
+			if order.IsSplit() || now.Sub(order.UpdatedAt) < threshold {
+				continue
+			}
+
+			ok, err := s.orderRepo.MarkSLABreached(ctx, order, now)
+			if err != nil {
+				log.ErrorfWithContext(ctx, "failed to flag SLA breach on order %s: %v", order.ID.Hex(), err)
+				continue
+			}
+			if !ok {
+				// The order moved on after it was read
+				continue
+			}
+
+			flagged++
+			data := map[string]interface{}{
+				"status":          order.Status,
+				"threshold":       threshold.String(),
+				"updated_at":      order.UpdatedAt,
+				"sla_breached_at": now,
+			}
+			if err := s.events.Publish(ctx, models.EventOrderSLABreached, order.ID.Hex(), order.TenantID, order.SellerID, data); err != nil {
+				log.ErrorfWithContext(ctx, "failed to publish SLA breach for order %s: %v", order.ID.Hex(), err)
+			}
+		}
+	}
+	return flagged, nil
+}
+
+// GetBreaches lists orders flagged as breaching their SLA
+func (s *SLAService) GetBreaches(ctx context.Context, filters repositories.OrderFilters) ([]*models.Order, error) {
+	breached := true
+	filters.SLABreached = &breached
+
+	orders, err := s.orderRepo.FindByFilters(ctx, filters)
+	if err != nil {
+		log.ErrorfWithContext(ctx, "failed to get SLA breaches: %v", err)
+		return nil, fmt.Errorf("unable to fetch SLA breaches: %w", err)
+	}
+	return orders, nil
+}
+
+// RunBreachJob calls DetectBreaches every interval until ctx is cancelled
+func (s *SLAService) RunBreachJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			flagged, err := s.DetectBreaches(ctx, now)
+			if err != nil {
+				log.ErrorfWithContext(ctx, "SLA breach job failed: %v", err)
+				continue
+			}
+			if flagged > 0 {
+				log.InfofWithContext(ctx, "SLA breach job flagged %d orders", flagged)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oms-service-goc/internals/configs"
+	"oms-service-goc/internals/models"
+	"oms-service-goc/internals/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSLAService_DetectBreaches(t *testing.T) {
+	// DetectBreaches looks at every order, so none may be left by earlier tests, and this
+	// test's own orders must not turn up in later ones
+	cleanupTestData(t, newTestDatabase(t))
+	_, repo := setupOrderServiceTest(t)
+	mockSQS := &MockSQSPublisher{}
+	mockSQS.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	strictTenant, relaxedTenant, sellerID := bson.NewObjectID().Hex(), bson.NewObjectID().Hex(), bson.NewObjectID().Hex()
+	sla := configs.SLAConfig{
+		Default: configs.SLAThresholds{OnHold: 24 * time.Hour, NewOrder: 24 * time.Hour},
+		Tenants: map[string]configs.SLAThresholds{strictTenant: {OnHold: time.Hour}},
+	}
+	service := NewSLAService(repo, sla, NewEventPublisher(mockSQS))
+
+	create := func(tenantID string) *models.Order {
+		order, err := repo.Create(context.Background(), &models.Order{
+			TenantID: tenantID,
+			SellerID: sellerID,
+			HubID:    "hub1",
+			Items:    []models.OrderItem{{SKUCode: "SKU001", Quantity: 1}},
+		})
+		require.NoError(t, err)
+		return order
+	}
+	strict := create(strictTenant)
+	relaxed := create(relaxedTenant)
+
+	// Two hours later only the strict tenant's order is past its SLA
+	flagged, err := service.DetectBreaches(context.Background(), time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, flagged)
+	mockSQS.AssertNumberOfCalls(t, "Publish", 1)
+
+	breaches, err := service.GetBreaches(context.Background(), repositories.OrderFilters{SellerID: sellerID})
+	require.NoError(t, err)
+	require.Len(t, breaches, 1)
+	assert.Equal(t, strict.ID, breaches[0].ID)
+
+	// Already flagged orders are not flagged again
+	flagged, err = service.DetectBreaches(context.Background(), time.Now().Add(25*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, flagged)
+
+	stored, err := repo.FindByID(context.Background(), relaxed.ID.Hex())
+	require.NoError(t, err)
+	assert.NotNil(t, stored.SLABreachedAt)
+
+	// Moving on clears the flag
+	require.NoError(t, repo.UpdateStatus(context.Background(), strict.ID.Hex(), models.OrderStatusNewOrder))
+	stored, err = repo.FindByID(context.Background(), strict.ID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, stored.SLABreachedAt)
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps files on the local filesystem under Root. Paths outside Root are
+// refused so user-supplied locations cannot reach arbitrary files on the host.
+type LocalStore struct {
+	Root string
+}
+
+func NewLocalStore(root string) (*LocalStore, error) {
+	absolute, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage root %q: %w", root, err)
+	}
+	return &LocalStore{Root: absolute}, nil
+}
+
+func (s *LocalStore) path(uri string) (string, error) {
+	parsed, err := ParseLocation(uri)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme != SchemeFile {
+		return "", fmt.Errorf("%w: %s is not a file:// location", ErrUnsupportedScheme, uri)
+	}
+
+	path := filepath.Clean(filepath.FromSlash(parsed.Path))
+	if path != s.Root && !strings.HasPrefix(path, s.Root+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside %s", ErrInvalidLocation, path, s.Root)
+	}
+	return path, nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, uri string) (io.ReadCloser, error) {
+	path, err := s.path(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", uri, err)
+	}
+	return file, nil
+}
+
+// Create writes to a temporary file next to the target and renames it into place on Close
+func (s *LocalStore) Create(ctx context.Context, uri string) (io.WriteCloser, error) {
+	path, err := s.path(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", uri, err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", uri, err)
+	}
+	return &localWriter{file: file, path: path}, nil
+}
+
+type localWriter struct {
+	file *os.File
+	path string
+}
+
+func (w *localWriter) Write(p []byte) (int, error) {
+	return w.file.Write(p)
+}
+
+func (w *localWriter) Close() error {
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return fmt.Errorf("failed to write %s: %w", w.path, err)
+	}
+	if err := os.Rename(w.file.Name(), w.path); err != nil {
+		os.Remove(w.file.Name())
+		return fmt.Errorf("failed to write %s: %w", w.path, err)
+	}
+	return nil
+}
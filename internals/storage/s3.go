@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3API is the part of the S3 client S3Store uses
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Store keeps files in S3 at s3://bucket/key
+type S3Store struct {
+	Client S3API
+}
+
+// S3Config configures the S3 client. Endpoint points at a local stand-in such as LocalStack;
+// credentials fall back to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type S3Config struct {
+	Region   string
+	Endpoint string
+}
+
+func NewS3Store(cfg S3Config) *S3Store {
+	client := s3.New(s3.Options{
+		Region:      cfg.Region,
+		Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(environmentCredentials)),
+	}, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &S3Store{Client: client}
+}
+
+func environmentCredentials(ctx context.Context) (aws.Credentials, error) {
+	credentials := aws.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Source:          "environment",
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return aws.Credentials{}, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for s3:// locations")
+	}
+	return credentials, nil
+}
+
+func splitS3(uri string) (bucket, key string, err error) {
+	parsed, err := ParseLocation(uri)
+	if err != nil {
+		return "", "", err
+	}
+	if parsed.Scheme != SchemeS3 {
+		return "", "", fmt.Errorf("%w: %s is not an s3:// location", ErrUnsupportedScheme, uri)
+	}
+	return parsed.Host, strings.TrimPrefix(parsed.Path, "/"), nil
+}
+
+func (s *S3Store) Open(ctx context.Context, uri string) (io.ReadCloser, error) {
+	bucket, key, err := splitS3(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.Client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", uri, err)
+	}
+	return output.Body, nil
+}
+
+// Create buffers the file in a temporary file and uploads it on Close, since S3 needs
+// the length of the object up front
+func (s *S3Store) Create(ctx context.Context, uri string) (io.WriteCloser, error) {
+	bucket, key, err := splitS3(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := os.CreateTemp("", "oms-s3-upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer %s: %w", uri, err)
+	}
+	return &s3Writer{ctx: ctx, store: s, uri: uri, bucket: bucket, key: key, buffer: buffer}, nil
+}
+
+type s3Writer struct {
+	ctx         context.Context
+	store       *S3Store
+	uri         string
+	bucket, key string
+	buffer      *os.File
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	return w.buffer.Write(p)
+}
+
+func (w *s3Writer) Close() error {
+	defer os.Remove(w.buffer.Name())
+	defer w.buffer.Close()
+
+	size, err := w.buffer.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", w.uri, err)
+	}
+	if _, err := w.buffer.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", w.uri, err)
+	}
+
+	_, err = w.store.Client.PutObject(w.ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(w.bucket),
+		Key:           aws.String(w.key),
+		Body:          w.buffer,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", w.uri, err)
+	}
+	return nil
+}
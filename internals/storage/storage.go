@@ -0,0 +1,140 @@
+// Package storage reads and writes files addressed by URI, so bulk uploads, exports and
+// error reports can live on local disk, in S3 or behind a URL without callers caring which.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidLocation   = errors.New("invalid file location")
+	ErrUnsupportedScheme = errors.New("unsupported file location scheme")
+	ErrNotFound          = errors.New("file not found")
+	ErrReadOnly          = errors.New("file location is read-only")
+)
+
+// Schemes this package knows how to handle
+const (
+	SchemeFile  = "file"
+	SchemeS3    = "s3"
+	SchemeHTTPS = "https"
+)
+
+// FileStore opens files for reading and creates them for writing. A file written with
+// Create is only visible at uri once the writer has been closed without error.
+type FileStore interface {
+	Open(ctx context.Context, uri string) (io.ReadCloser, error)
+	Create(ctx context.Context, uri string) (io.WriteCloser, error)
+}
+
+// Put copies r into a new file at uri
+func Put(ctx context.Context, store FileStore, uri string, r io.Reader) error {
+	writer, err := store.Create(ctx, uri)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write %s: %w", uri, err)
+	}
+	return writer.Close()
+}
+
+// ParseLocation checks that uri is absolute and uses one of the known schemes
+func ParseLocation(uri string) (*url.URL, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLocation, err)
+	}
+
+	switch parsed.Scheme {
+	case SchemeFile:
+		if parsed.Path == "" || parsed.Host != "" {
+			return nil, fmt.Errorf("%w: %q must look like file:///path/to/file", ErrInvalidLocation, uri)
+		}
+	case SchemeS3:
+		if parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" {
+			return nil, fmt.Errorf("%w: %q must look like s3://bucket/key", ErrInvalidLocation, uri)
+		}
+	case SchemeHTTPS:
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("%w: %q has no host", ErrInvalidLocation, uri)
+		}
+	case "":
+		return nil, fmt.Errorf("%w: %q has no scheme, use file://, s3:// or https://", ErrInvalidLocation, uri)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, parsed.Scheme)
+	}
+	return parsed, nil
+}
+
+// LocalLocation returns the file:// URI of an absolute local path
+func LocalLocation(path string) string {
+	return (&url.URL{Scheme: SchemeFile, Path: filepath.ToSlash(path)}).String()
+}
+
+// NormalizeLocation turns a bare absolute path, as older clients send, into a file:// URI
+// and checks the result with ParseLocation
+func NormalizeLocation(location string) (string, error) {
+	if strings.HasPrefix(location, "/") {
+		location = LocalLocation(location)
+	}
+	if _, err := ParseLocation(location); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// Join appends a file name to a directory-like location such as s3://bucket/exports
+func Join(base, name string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(name, "/")
+}
+
+// Router is a FileStore that hands each URI to the store registered for its scheme
+type Router struct {
+	stores map[string]FileStore
+}
+
+func NewRouter() *Router {
+	return &Router{stores: map[string]FileStore{}}
+}
+
+// Register makes store handle URIs with the given scheme
+func (r *Router) Register(scheme string, store FileStore) *Router {
+	r.stores[scheme] = store
+	return r
+}
+
+func (r *Router) store(uri string) (FileStore, error) {
+	parsed, err := ParseLocation(uri)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := r.stores[parsed.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not configured", ErrUnsupportedScheme, parsed.Scheme)
+	}
+	return store, nil
+}
+
+func (r *Router) Open(ctx context.Context, uri string) (io.ReadCloser, error) {
+	store, err := r.store(uri)
+	if err != nil {
+		return nil, err
+	}
+	return store.Open(ctx, uri)
+}
+
+func (r *Router) Create(ctx context.Context, uri string) (io.WriteCloser, error) {
+	store, err := r.store(uri)
+	if err != nil {
+		return nil, err
+	}
+	return store.Create(ctx, uri)
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocation(t *testing.T) {
+	for _, uri := range []string{"file:///data/orders.csv", "s3://bucket/uploads/orders.csv", "https://example.com/orders.csv?X-Amz-Signature=abc"} {
+		_, err := ParseLocation(uri)
+		assert.NoError(t, err, uri)
+	}
+
+	for uri, want := range map[string]error{
+		"orders.csv":                    ErrInvalidLocation,
+		"file://host/orders.csv":        ErrInvalidLocation,
+		"s3://bucket":                   ErrInvalidLocation,
+		"https:///orders.csv":           ErrInvalidLocation,
+		"ftp://example.com/orders.csv":  ErrUnsupportedScheme,
+		"http://example.com/orders.csv": ErrUnsupportedScheme,
+	} {
+		_, err := ParseLocation(uri)
+		assert.ErrorIs(t, err, want, uri)
+	}
+}
+
+func TestNormalizeLocation(t *testing.T) {
+	location, err := NormalizeLocation("/data/orders.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "file:///data/orders.csv", location)
+
+	location, err = NormalizeLocation("s3://bucket/orders.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/orders.csv", location)
+
+	_, err = NormalizeLocation("data/orders.csv")
+	assert.ErrorIs(t, err, ErrInvalidLocation)
+}
+
+func writeFile(t *testing.T, store FileStore, uri, content string) {
+	t.Helper()
+	writer, err := store.Create(context.Background(), uri)
+	require.NoError(t, err)
+	_, err = io.WriteString(writer, content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+}
+
+func readFile(t *testing.T, store FileStore, uri string) string {
+	t.Helper()
+	reader, err := store.Open(context.Background(), uri)
+	require.NoError(t, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestLocalStore(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+	uri := LocalLocation(filepath.Join(root, "reports", "errors.csv"))
+
+	writeFile(t, store, uri, "line,message\n")
+	assert.Equal(t, "line,message\n", readFile(t, store, uri))
+
+	_, err = store.Open(context.Background(), LocalLocation(filepath.Join(root, "missing.csv")))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, outside := range []string{LocalLocation(filepath.Join(root, "..", "escape.csv")), "file:///etc/passwd"} {
+		_, err = store.Open(context.Background(), outside)
+		assert.ErrorIs(t, err, ErrInvalidLocation, outside)
+		_, err = store.Create(context.Background(), outside)
+		assert.ErrorIs(t, err, ErrInvalidLocation, outside)
+	}
+}
+
+func TestHTTPStore(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orders.csv":
+			io.WriteString(w, "tenant_id,seller_id\n")
+		case "/forbidden.csv":
+			w.WriteHeader(http.StatusForbidden)
+		case "/metadata":
+			http.Redirect(w, r, "https://169.254.169.254/latest/meta-data/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	store := NewHTTPStore(server.Client(), []string{"127.0.0.1"})
+	assert.Equal(t, "tenant_id,seller_id\n", readFile(t, store, server.URL+"/orders.csv?X-Amz-Signature=secret"))
+
+	_, err := store.Open(context.Background(), server.URL+"/missing.csv")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Open(context.Background(), server.URL+"/forbidden.csv?X-Amz-Signature=secret")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+
+	// Redirects are only followed to allowed hosts
+	_, err = store.Open(context.Background(), server.URL+"/metadata")
+	assert.ErrorIs(t, err, ErrInvalidLocation)
+
+	_, err = store.Create(context.Background(), server.URL+"/orders.csv")
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
+
+func TestHTTPStore_OnlyAllowedHosts(t *testing.T) {
+	store := NewHTTPStore(nil, []string{"files.example.com", "*.s3.amazonaws.com"})
+	assert.Equal(t, DefaultHTTPTimeout, store.Client.Timeout)
+
+	for _, uri := range []string{
+		"https://files.example.com/orders.csv",
+		"https://bucket.s3.amazonaws.com/orders.csv",
+		"https://FILES.example.com:8443/orders.csv",
+	} {
+		parsed, err := ParseLocation(uri)
+		require.NoError(t, err, uri)
+		assert.NoError(t, store.allow(parsed), uri)
+	}
+
+	for _, uri := range []string{
+		"https://169.254.169.254/latest/meta-data/",
+		"https://localhost/orders.csv",
+		"https://s3.amazonaws.com/orders.csv",
+		"https://files.example.com.evil.test/orders.csv",
+		"https://other.example.com/orders.csv",
+	} {
+		_, err := store.Open(context.Background(), uri)
+		assert.ErrorIs(t, err, ErrInvalidLocation, uri)
+	}
+
+	// Nothing is allowed until hosts are configured
+	_, err := NewHTTPStore(nil, nil).Open(context.Background(), "https://files.example.com/orders.csv")
+	assert.ErrorIs(t, err, ErrInvalidLocation)
+}
+
+// fakeS3 is a local stand-in for S3 that serves path-style GETs and PUTs from memory
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		w.Write(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	s3 := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	store := NewS3Store(S3Config{Region: "us-east-1", Endpoint: server.URL})
+	writeFile(t, store, "s3://uploads/seller1/orders.csv", "tenant_id,seller_id\n")
+	assert.Equal(t, "tenant_id,seller_id\n", string(s3.objects["/uploads/seller1/orders.csv"]))
+	assert.Equal(t, "tenant_id,seller_id\n", readFile(t, store, "s3://uploads/seller1/orders.csv"))
+
+	_, err := store.Open(context.Background(), "s3://uploads/missing.csv")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRouter(t *testing.T) {
+	root := t.TempDir()
+	local, err := NewLocalStore(root)
+	require.NoError(t, err)
+	router := NewRouter().Register(SchemeFile, local)
+
+	uri := LocalLocation(filepath.Join(root, "orders.csv"))
+	writeFile(t, router, uri, "tenant_id\n")
+	assert.True(t, strings.HasPrefix(readFile(t, router, uri), "tenant_id"))
+
+	_, err = router.Open(context.Background(), "s3://bucket/orders.csv")
+	assert.ErrorIs(t, err, ErrUnsupportedScheme)
+}
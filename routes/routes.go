@@ -2,12 +2,14 @@ package routes
 
 import (
 	"oms-service-goc/internals/handlers/http"
+	"oms-service-goc/internals/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(orderHandler *http.OrderHandler) *gin.Engine {
+func SetupRoutes(orderHandler *http.OrderHandler, shipmentHandler *http.ShipmentHandler, returnHandler *http.ReturnHandler, holdHandler *http.HoldHandler, slaHandler *http.SLAHandler, exportHandler *http.ExportHandler, bulkHandler *http.BulkHandler, templateHandler *http.ImportTemplateHandler, deadLetterHandler *http.DeadLetterHandler) *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.RequestID())
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -22,10 +24,72 @@ func SetupRoutes(orderHandler *http.OrderHandler) *gin.Engine {
 	{
 		orders := v1.Group("/orders")
 		{
-			orders.GET("", orderHandler.GetOrderBySeller)             // GET /api/v1/orders?seller_id=xxx
-			orders.GET("/:id", orderHandler.GetOrderByID)             // GET /api/v1/orders/{id}
-			orders.PUT("/:id/status", orderHandler.UpdateOrderStatus) // PUT /api/v1/orders/{id}/status
-			orders.POST("/bulk", orderHandler.CreateBulkOrder)        // POST /api/v1/orders/bulk
+			orders.GET("", orderHandler.GetOrderBySeller)                       // GET /api/v1/orders?seller_id=xxx
+			orders.GET("/sla-breaches", slaHandler.GetBreaches)                 // GET /api/v1/orders/sla-breaches?tenant_id=xxx
+			orders.GET("/export", exportHandler.ExportOrders)                   // GET /api/v1/orders/export?format=csv&seller_id=xxx
+			orders.GET("/:id", orderHandler.GetOrderByID)                       // GET /api/v1/orders/{id}
+			orders.PUT("/:id/status", orderHandler.UpdateOrderStatus)           // PUT /api/v1/orders/{id}/status
+			orders.POST("/status/bulk", orderHandler.BulkUpdateStatus)          // POST /api/v1/orders/status/bulk
+			orders.POST("/:id/fulfilments", orderHandler.RecordFulfilment)      // POST /api/v1/orders/{id}/fulfilments
+			orders.PATCH("/:id", orderHandler.EditOrder)                        // PATCH /api/v1/orders/{id}
+			orders.POST("/bulk", bulkHandler.CreateBulkOrder)                   // POST /api/v1/orders/bulk
+			orders.POST("/bulk/upload", bulkHandler.UploadBulkOrder)            // POST /api/v1/orders/bulk/upload (multipart)
+			orders.POST("/bulk/preview", bulkHandler.PreviewBulkOrder)          // POST /api/v1/orders/bulk/preview (multipart)
+			orders.GET("/bulk/:job_id", bulkHandler.GetBulkJob)                 // GET /api/v1/orders/bulk/{job_id}
+			orders.GET("/bulk/:job_id/errors", bulkHandler.DownloadErrorReport) // GET /api/v1/orders/bulk/{job_id}/errors
+			orders.POST("/bulk/:job_id/cancel", bulkHandler.CancelBulkJob)      // POST /api/v1/orders/bulk/{job_id}/cancel?rollback=true
+			orders.POST("/:id/split", orderHandler.SplitOrder)                  // POST /api/v1/orders/{id}/split
+			orders.GET("/:id/children", orderHandler.GetChildOrders)            // GET /api/v1/orders/{id}/children
+
+			orders.POST("/:id/shipments", shipmentHandler.CreateShipment)     // POST /api/v1/orders/{id}/shipments
+			orders.GET("/:id/shipments", shipmentHandler.GetShipmentsByOrder) // GET /api/v1/orders/{id}/shipments
+
+			orders.POST("/:id/returns", returnHandler.CreateReturn)     // POST /api/v1/orders/{id}/returns
+			orders.GET("/:id/returns", returnHandler.GetReturnsByOrder) // GET /api/v1/orders/{id}/returns
+
+			orders.POST("/:id/holds", holdHandler.PlaceHold)             // POST /api/v1/orders/{id}/holds
+			orders.GET("/:id/holds", holdHandler.EvaluateHolds)          // GET /api/v1/orders/{id}/holds
+			orders.DELETE("/:id/holds/:reason", holdHandler.ReleaseHold) // DELETE /api/v1/orders/{id}/holds/{reason}
+		}
+
+		shipments := v1.Group("/shipments")
+		{
+			shipments.GET("/:id", shipmentHandler.GetShipment)             // GET /api/v1/shipments/{id}
+			shipments.PUT("/:id/tracking", shipmentHandler.AttachTracking) // PUT /api/v1/shipments/{id}/tracking
+			shipments.POST("/:id/deliver", shipmentHandler.MarkDelivered)  // POST /api/v1/shipments/{id}/deliver
+		}
+
+		exports := v1.Group("/exports")
+		{
+			exports.POST("", exportHandler.StartExport)                // POST /api/v1/exports
+			exports.GET("/:id", exportHandler.GetExportJob)            // GET /api/v1/exports/{id}
+			exports.GET("/:id/download", exportHandler.DownloadExport) // GET /api/v1/exports/{id}/download
+		}
+
+		templates := v1.Group("/import-templates")
+		{
+			templates.POST("", templateHandler.CreateTemplate)       // POST /api/v1/import-templates
+			templates.GET("", templateHandler.GetTemplatesBySeller)  // GET /api/v1/import-templates?seller_id=xxx
+			templates.GET("/:id", templateHandler.GetTemplate)       // GET /api/v1/import-templates/{id}
+			templates.PUT("/:id", templateHandler.UpdateTemplate)    // PUT /api/v1/import-templates/{id}
+			templates.DELETE("/:id", templateHandler.DeleteTemplate) // DELETE /api/v1/import-templates/{id}
+		}
+
+		returns := v1.Group("/returns")
+		{
+			returns.GET("/:id", returnHandler.GetReturn)        // GET /api/v1/returns/{id}
+			returns.POST("/:id/approve", returnHandler.Approve) // POST /api/v1/returns/{id}/approve
+			returns.POST("/:id/reject", returnHandler.Reject)   // POST /api/v1/returns/{id}/reject
+			returns.POST("/:id/receive", returnHandler.Receive) // POST /api/v1/returns/{id}/receive
+			returns.POST("/:id/restock", returnHandler.Restock) // POST /api/v1/returns/{id}/restock
+		}
+
+		admin := v1.Group("/admin")
+		{
+			admin.GET("/dead-letters", deadLetterHandler.ListDeadLetters)              // GET /api/v1/admin/dead-letters?queue=xxx
+			admin.GET("/dead-letters/:id", deadLetterHandler.GetDeadLetter)            // GET /api/v1/admin/dead-letters/{id}
+			admin.POST("/dead-letters/replay", deadLetterHandler.ReplayDeadLetters)    // POST /api/v1/admin/dead-letters/replay?queue=xxx
+			admin.POST("/dead-letters/:id/replay", deadLetterHandler.ReplayDeadLetter) // POST /api/v1/admin/dead-letters/{id}/replay
 		}
 	}
 